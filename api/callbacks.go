@@ -0,0 +1,23 @@
+package api
+
+import "time"
+
+const (
+	DefaultCallbackTimeout       = 10 * time.Second       // the default maximum time a HubReaderInterface callback is waited for
+	DefaultCallbackWarnThreshold = 500 * time.Millisecond // the default duration after which a slow HubReaderInterface callback is logged
+)
+
+// limits for invoking the HubReaderInterface callbacks of the application
+type CallbackConfig struct {
+	// the maximum time a callback returning a value is waited for, including waiting
+	// for the previous callbacks of the SKI, DefaultCallbackTimeout if not positive
+	//
+	// the callback is then treated as failed, or skipped if it was not invoked yet.
+	// A running callback is not interrupted, the next callbacks for the SKI are
+	// delivered once it returned
+	Timeout time.Duration
+
+	// callbacks still running after this duration are logged,
+	// DefaultCallbackWarnThreshold if not positive
+	WarnThreshold time.Duration
+}
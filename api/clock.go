@@ -0,0 +1,43 @@
+package api
+
+import "time"
+
+/* Clock */
+
+// interface for the source of the current time and the timers of the hub,
+// the SHIP and websocket connections, the pairing policies and the mDNS manager
+//
+// allows tests to control the time instead of waiting for timeouts and delays,
+// implemented by clock.SystemClock and clocktest.FakeClock
+type ClockInterface interface {
+	// returns the current time
+	Now() time.Time
+
+	// returns a channel receiving the current time once the duration elapsed
+	After(d time.Duration) <-chan time.Time
+
+	// invokes f in its own goroutine once the duration elapsed
+	//
+	// the returned timer can be used to cancel the invocation
+	AfterFunc(d time.Duration, f func()) TimerInterface
+
+	// returns a ticker sending the current time on its channel after each period
+	NewTicker(d time.Duration) TickerInterface
+}
+
+// interface for a timer created by ClockInterface.AfterFunc
+type TimerInterface interface {
+	// prevents the timer from firing
+	//
+	// returns false if the timer already fired or was stopped
+	Stop() bool
+}
+
+// interface for a ticker created by ClockInterface.NewTicker
+type TickerInterface interface {
+	// returns the channel the ticks are delivered on
+	C() <-chan time.Time
+
+	// turns off the ticker, no more ticks will be sent
+	Stop()
+}
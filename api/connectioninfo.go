@@ -0,0 +1,43 @@
+package api
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/enbility/ship-go/model"
+)
+
+// The role of the local service in a SHIP connection
+type ShipRole string
+
+const (
+	ShipRoleServer ShipRole = "server" // the remote service connected to the local service
+	ShipRoleClient ShipRole = "client" // the local service connected to the remote service
+)
+
+// snapshot of the details and statistics of a websocket connection
+type WebsocketConnectionInfo struct {
+	RemoteAddress   string            // the address of the remote service in the host:port format
+	LocalAddress    string            // the local address of the connection in the host:port format
+	TLSVersion      uint16            // the negotiated TLS version, e.g. tls.VersionTLS12
+	CipherSuite     uint16            // the negotiated cipher suite, e.g. tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+	PeerCertificate *x509.Certificate // the certificate provided by the remote service
+	BytesIn         uint64            // the number of received message bytes
+	BytesOut        uint64            // the number of sent message bytes
+	MessagesIn      uint64            // the number of received messages
+	MessagesOut     uint64            // the number of sent messages
+	LastPingRTT     time.Duration     // the round trip time of the last answered ping, 0 if none was answered yet
+}
+
+// snapshot of the details of an active SHIP connection
+type ShipConnectionInfo struct {
+	RemoteSKI      string                          // the SKI of the remote service
+	Generation     uint64                          // the generation reported by HubReaderInterface.RemoteSKIConnected, 0 until the handshake is completed
+	RemoteShipID   string                          // the SHIP ID of the remote service, empty if not known yet
+	Role           ShipRole                        // the role of the local service in the connection
+	ConnectedSince time.Time                       // the time the connection was established
+	State          model.ShipMessageExchangeState  // the current SHIP handshake state
+	ShipVersion    model.Version                   // the negotiated SHIP protocol version, empty until the protocol handshake is completed
+	ShipFormat     model.MessageProtocolFormatType // the negotiated SHIP message format, empty until the protocol handshake is completed
+	Websocket      WebsocketConnectionInfo         // the details and statistics of the websocket connection
+}
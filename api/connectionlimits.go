@@ -0,0 +1,30 @@
+package api
+
+import "time"
+
+// limits protecting the websocket server against misbehaving or malicious remote services
+//
+// a value of 0 disables the corresponding limit
+type ConnectionLimits struct {
+	// the maximum number of concurrent incoming connections with an incomplete SHIP handshake
+	MaxPendingHandshakes int
+
+	// the maximum number of concurrent incoming connections per source IP address
+	MaxConnectionsPerIP int
+
+	// the maximum number of incoming connection attempts per SKI within AttemptWindow
+	MaxAttemptsPerSKI int
+
+	// the time window used for MaxAttemptsPerSKI
+	AttemptWindow time.Duration
+
+	// how long incoming connections of a SKI are rejected after it exceeded MaxAttemptsPerSKI
+	BanDuration time.Duration
+
+	// the maximum number of incoming messages per second on each connection
+	// the connection is closed if this is exceeded
+	MaxMessagesPerSecond float64
+
+	// the number of incoming messages that may be received at once before MaxMessagesPerSecond applies
+	MessageBurst int
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/enbility/ship-go/model"
+)
+
+// The phase of the SHIP handshake
+type HandshakePhase string
+
+const (
+	HandshakePhaseInit          HandshakePhase = "init"          // SHIP 13.4.3: connection mode initialisation
+	HandshakePhaseHello         HandshakePhase = "hello"         // SHIP 13.4.4.1: connection data preparation
+	HandshakePhaseProtocol      HandshakePhase = "protocol"      // SHIP 13.4.4.2: protocol handshake
+	HandshakePhasePin           HandshakePhase = "pin"           // SHIP 13.4.4.3: PIN verification
+	HandshakePhaseAccessMethods HandshakePhase = "accessMethods" // SHIP 13.4.6: access methods exchange
+)
+
+// ErrHandshakeTimeout if the remote service did not respond in time during the handshake
+var ErrHandshakeTimeout = errors.New("SHIP handshake timeout")
+
+// ErrProtocolMismatch if no common protocol version or format could be agreed on
+var ErrProtocolMismatch = errors.New("SHIP protocol mismatch")
+
+// ErrPinRequired if the remote service requires a PIN, which is not supported
+var ErrPinRequired = errors.New("SHIP PIN required")
+
+// ErrShipIDMismatch if the SHIP ID of the remote service does not match the stored one
+var ErrShipIDMismatch = errors.New("SHIP id mismatch")
+
+// ErrRemoteAbort if the remote service aborted the handshake, e.g. as the user denied trust
+var ErrRemoteAbort = errors.New("SHIP handshake aborted by remote service")
+
+// ErrRejectedByRemote if the remote application rejected the connection
+var ErrRejectedByRemote = errors.New("rejected by remote application")
+
+// ErrCertificateVerification if the certificate or the SKI of the remote service could not be verified
+var ErrCertificateVerification = errors.New("certificate verification failed")
+
+// ErrInvalidHandshakeMessage if the remote service sent an invalid or unexpected handshake message
+var ErrInvalidHandshakeMessage = errors.New("invalid SHIP handshake message")
+
+// Reported if the remote service did not respond in time, matches ErrHandshakeTimeout
+type HandshakeTimeoutError struct {
+	Phase HandshakePhase // the handshake phase the timeout occurred in
+}
+
+func (e *HandshakeTimeoutError) Error() string {
+	return fmt.Sprintf("%s in phase %s", ErrHandshakeTimeout, e.Phase)
+}
+
+func (e *HandshakeTimeoutError) Is(target error) bool {
+	return target == ErrHandshakeTimeout
+}
+
+// Reported if the protocol handshake failed, matches ErrProtocolMismatch
+type ProtocolMismatchError struct {
+	Offered  model.Version                     // the version offered by this service
+	Selected model.Version                     // the version selected by the remote service
+	Formats  []model.MessageProtocolFormatType // the formats selected by the remote service
+}
+
+func (e *ProtocolMismatchError) Error() string {
+	return fmt.Sprintf("%s: offered version %d.%d, selected version %d.%d with formats %v",
+		ErrProtocolMismatch, e.Offered.Major, e.Offered.Minor, e.Selected.Major, e.Selected.Minor, e.Formats)
+}
+
+func (e *ProtocolMismatchError) Is(target error) bool {
+	return target == ErrProtocolMismatch
+}
+
+// Reported if the remote service sent an unsupported PIN state
+//
+// matches ErrPinRequired if the remote service requires a PIN
+type PinStateError struct {
+	State model.PinStateType // the PIN state sent by the remote service
+}
+
+func (e *PinStateError) Error() string {
+	return fmt.Sprintf("unsupported SHIP PIN state: %s", e.State)
+}
+
+func (e *PinStateError) Is(target error) bool {
+	return target == ErrPinRequired && e.State == model.PinStateTypeRequired
+}
+
+// Reported if the SHIP ID of the remote service does not match the stored one, matches ErrShipIDMismatch
+type ShipIDMismatchError struct {
+	Expected string // the stored SHIP ID
+	Actual   string // the SHIP ID provided by the remote service
+}
+
+func (e *ShipIDMismatchError) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", ErrShipIDMismatch, e.Expected, e.Actual)
+}
+
+func (e *ShipIDMismatchError) Is(target error) bool {
+	return target == ErrShipIDMismatch
+}
+
+// Reported if the certificate or SKI of the remote service could not be verified,
+// matches ErrCertificateVerification
+type CertificateVerificationError struct {
+	Ski string // the expected SKI, empty if it is not known
+	Err error  // the reason of the failure
+}
+
+func (e *CertificateVerificationError) Error() string {
+	if len(e.Ski) == 0 {
+		return fmt.Sprintf("%s: %s", ErrCertificateVerification, e.Err)
+	}
+
+	return fmt.Sprintf("%s for %s: %s", ErrCertificateVerification, e.Ski, e.Err)
+}
+
+func (e *CertificateVerificationError) Is(target error) bool {
+	return target == ErrCertificateVerification
+}
+
+func (e *CertificateVerificationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/enbility/ship-go/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandshakeErrors(t *testing.T) {
+	var err error = &HandshakeTimeoutError{Phase: HandshakePhaseHello}
+	assert.ErrorIs(t, err, ErrHandshakeTimeout)
+	assert.Equal(t, "SHIP handshake timeout in phase hello", err.Error())
+
+	err = fmt.Errorf("wrapped: %w", &ShipIDMismatchError{Expected: "a", Actual: "b"})
+	assert.ErrorIs(t, err, ErrShipIDMismatch)
+	var mismatchErr *ShipIDMismatchError
+	assert.True(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, "a", mismatchErr.Expected)
+
+	err = &ProtocolMismatchError{Offered: model.Version{Major: 1}, Selected: model.Version{Major: 2}}
+	assert.ErrorIs(t, err, ErrProtocolMismatch)
+	assert.NotErrorIs(t, err, ErrHandshakeTimeout)
+
+	assert.ErrorIs(t, &PinStateError{State: model.PinStateTypeRequired}, ErrPinRequired)
+	assert.NotErrorIs(t, &PinStateError{State: model.PinStateTypeOptional}, ErrPinRequired)
+
+	cause := errors.New("cause")
+	err = &CertificateVerificationError{Ski: "ski", Err: cause}
+	assert.ErrorIs(t, err, ErrCertificateVerification)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "certificate verification failed for ski: cause", err.Error())
+}
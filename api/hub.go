@@ -1,5 +1,11 @@
 package api
 
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
 //go:generate mockery
 //go:generate mockgen -destination=../mocks/mockgen_api.go -package=mocks github.com/enbility/ship-go/api MdnsInterface,HubReaderInterface
 
@@ -16,6 +22,15 @@ type HubInterface interface {
 	// return the service for a SKI
 	ServiceForSKI(ski string) *ServiceDetails
 
+	// Return snapshots of all active connections, sorted by SKI
+	Connections() []ShipConnectionInfo
+
+	// Return the SHIP handshake state histories of the last 10 closed connections to a SKI, oldest first
+	ConnectionHistoryForSKI(ski string) []ShipConnectionHistory
+
+	// Return all known remote services with their trust and connection state, sorted by SKI
+	KnownServices() []*ServiceDetails
+
 	// Provide the current pairing state for a SKI
 	PairingDetailForSki(ski string) *ConnectionStateDetail
 
@@ -24,9 +39,91 @@ type HubInterface interface {
 	// Default: false
 	SetAutoAccept(bool)
 
+	// Sets the policy deciding about pairing and connection requests,
+	// which takes precedence over auto accept and trusted services.
+	// Use nil to remove the policy
+	//
+	// Default: nil
+	SetPairingPolicy(policy PairingPolicyInterface)
+
+	// Sets the maximum number of outgoing messages that can be queued
+	// per priority for each websocket connection.
+	// Applies to connections established after this call
+	//
+	// Default: 100
+	SetWebsocketWriteQueueSize(size int)
+
+	// Sets the limits and policy for SPINE messages received
+	// before the SHIP handshake is completed.
+	// Applies to connections established after this call
+	//
+	// Default: drop the oldest messages if more than 100 messages or 1 MiB are buffered
+	SetSpineBufferConfig(config SpineBufferConfig)
+
+	// Sets the limits for incoming connections and messages
+	// Applies to connections established after this call
+	//
+	// Default: no limits
+	SetConnectionLimits(limits ConnectionLimits)
+
+	// Sets the timeout and the warning threshold for invoking the HubReaderInterface callbacks
+	//
+	// Default: DefaultCallbackTimeout and DefaultCallbackWarnThreshold
+	SetCallbackConfig(config CallbackConfig)
+
+	// Sets the clock used for all timeouts and delays of the hub, its pairing policy
+	// and mDNS manager, and of the connections established after this call
+	//
+	// The protocol timers of the mDNS providers and the network deadlines
+	// of the websocket connections always use the system time
+	//
+	// Default: the system clock
+	SetClock(clock ClockInterface)
+
+	// Sets if the pairing of a SKI is removed automatically,
+	// when the remote service announces that it removed the pairing
+	//
+	// Default: false
+	SetUnpairOnRemoteRemoval(enable bool)
+
+	// Sets the listener the websocket server accepts incoming connections on,
+	// instead of listening on the port on all interfaces.
+	// The listener has to provide plain TCP connections, TLS is applied by the hub.
+	// Needs to be invoked before Start
+	//
+	// Default: nil
+	SetWebsocketListener(listener net.Listener)
+
+	// Sets the path of the websocket server announced via mDNS
+	// Needs to be invoked before Start
+	//
+	// Default: "/ship/"
+	SetWebsocketPath(path string)
+
+	// Disables the websocket server of the hub, as the hub is mounted as handler
+	// on an existing HTTPS server of the application under the websocket path.
+	// The existing server has to use the TLS configuration provided by TLSConfig,
+	// port is the port of the existing server announced via mDNS.
+	// Needs to be invoked before Start
+	SetExternalWebsocketServer(port int)
+
+	// Returns the TLS configuration required for the websocket server
+	TLSConfig() *tls.Config
+
+	// Handles incoming websocket connection requests
+	http.Handler
+
 	// Pair with the SKI
 	RegisterRemoteSKI(ski string)
 
+	// Pre-authorize a remote service, e.g. with the SKI and SHIP ID scanned from its QR code.
+	// The service is paired and connected automatically, once it is discovered via mDNS
+	// with matching SKI and ID or it connects with this SKI and ID
+	PreAuthorizeRemoteService(ski, shipID string)
+
+	// Remove the pre-authorization of a remote service
+	RemovePreAuthorizedRemoteService(ski string)
+
 	// Unpair the SKI
 	UnregisterRemoteSKI(ski string)
 
@@ -39,13 +136,32 @@ type HubInterface interface {
 
 // Interface to pass information from the hub to the eebus service
 //
+// The callbacks are invoked in their own goroutine and in order per SKI, so they don't block
+// the SHIP handshake. The callbacks of a SKI are never invoked concurrently. AllowWaitingForTrust
+// and SetupRemoteDevice are waited for up to the CallbackConfig timeout, AllowWaitingForTrust then
+// returns false and the connection waiting for SetupRemoteDevice is closed
+//
 // Implemented by eebus service implementation, used by Hub
 type HubReaderInterface interface {
 	// report a connection to a SKI
-	RemoteSKIConnected(ski string)
+	//
+	// Invoked exactly once for every completed handshake. The generation identifies
+	// the connection and is unique for the hub, a newer connection has a higher generation
+	RemoteSKIConnected(ski string, generation uint64)
 
 	// report a disconnection to a SKI
-	RemoteSKIDisconnected(ski string)
+	//
+	// Invoked exactly once for every connection reported by RemoteSKIConnected with the same generation.
+	// The connection of a newer generation may already be reported as connected, in which case
+	// its state must not be affected. The details describe why the connection was closed
+	RemoteSKIDisconnected(ski string, generation uint64, details ShipCloseDetails)
+
+	// report that the remote service removed the pairing with this service
+	//
+	// Connection attempts to the SKI are stopped until it is paired again or connects by itself.
+	// The application should remove the pairing via UnregisterRemoteSKI, unless the hub
+	// is configured to do so automatically with SetUnpairOnRemoteRemoval
+	RemoteSKIPairingRemoved(ski string)
 
 	// report an approved handshake by a remote device
 	SetupRemoteDevice(ski string, writeI ShipConnectionDataWriterInterface) ShipConnectionDataReaderInterface
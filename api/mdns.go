@@ -1,6 +1,9 @@
 package api
 
-import "net"
+import (
+	"net"
+	"time"
+)
 
 /* Mdns */
 
@@ -18,11 +21,51 @@ type MdnsEntry struct {
 	Host       string               // mandatory, the host name
 	Port       int                  // mandatory, the port for the websocket service
 	Addresses  []net.IP             // mandatory, the IP addresses used by the service
+	LastSeen   time.Time            // the time the service was last reported by any mDNS provider
+	Providers  []string             // the names of the mDNS providers currently reporting the service
+}
+
+// The fields of an mDNS entry that changed with an update
+type MdnsEntryChanges uint
+
+const (
+	MdnsEntryChangeName MdnsEntryChanges = 1 << iota
+	MdnsEntryChangeIdentifier
+	MdnsEntryChangePath
+	MdnsEntryChangeRegister
+	MdnsEntryChangeBrand
+	MdnsEntryChangeType
+	MdnsEntryChangeModel
+	MdnsEntryChangeSerial
+	MdnsEntryChangeCategories
+	MdnsEntryChangeHost
+	MdnsEntryChangePort
+	MdnsEntryChangeAddresses
+	MdnsEntryChangeProviders
+)
+
+// Returns true if any of the given changes is contained
+func (c MdnsEntryChanges) Has(changes MdnsEntryChanges) bool {
+	return c&changes != 0
+}
+
+// Describes the update of an already known mDNS entry
+type MdnsEntryUpdate struct {
+	Previous         *MdnsEntry       // the entry before the update
+	Current          *MdnsEntry       // the entry after the update
+	Changes          MdnsEntryChanges // the fields that changed
+	AddedAddresses   []net.IP         // the addresses the service is now also available at
+	RemovedAddresses []net.IP         // the addresses the service is no longer available at
 }
 
 // implemented by Hub, used by mdns
 type MdnsReportInterface interface {
 	ReportMdnsEntries(entries map[string]*MdnsEntry, newEntries bool)
+
+	// Report the changes of an already known mDNS entry
+	//
+	// Invoked before ReportMdnsEntries reports the updated list of entries
+	ReportMdnsEntryUpdate(update MdnsEntryUpdate)
 }
 
 // implemented by mdns, used by Hub
@@ -33,6 +76,10 @@ type MdnsInterface interface {
 	UnannounceMdnsEntry()
 	SetAutoAccept(bool)
 
+	// Sets the port and path of the websocket server that are announced,
+	// an empty path announces the default SHIP websocket path
+	SetWebsocketEndpoint(port int, path string)
+
 	// Returns the QR code text for the service
 	// as defined in SHIP Requirements for Installation Process V1.0.0
 	QRCodeText() string
@@ -40,7 +87,21 @@ type MdnsInterface interface {
 	RequestMdnsEntries()
 }
 
+// Interface for persisting static mDNS entries, so they survive restarts
+//
+// Implemented by the application or mdns.StaticEntryFileLoader, used by mdns
+type MdnsStaticEntryLoaderInterface interface {
+	// Return the persisted static entries
+	LoadStaticEntries() ([]MdnsEntry, error)
+
+	// Persist the static entries, replacing all previously persisted entries
+	SaveStaticEntries(entries []MdnsEntry) error
+}
+
 // implemented by mdns, used by Providers
+//
+// For a service that is available, addresses contains all addresses it is currently available at.
+// A remove reports that the service is no longer available at all
 type MdnsResolveCB func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool)
 
 // implemented by mdns providers, used by mdns
@@ -49,4 +110,10 @@ type MdnsProviderInterface interface {
 	Shutdown()
 	Announce(serviceName string, port int, txt []string) error
 	Unannounce()
+
+	// Query the network for SHIP services again
+	//
+	// All services that are currently known to be available are reported
+	// again via the resolve callback, which refreshes their last seen time
+	Query()
 }
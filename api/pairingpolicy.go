@@ -0,0 +1,31 @@
+package api
+
+// the decision of a pairing policy for a remote service
+type PairingDecision uint
+
+const (
+	PairingDecisionDefault PairingDecision = iota // No decision, the default handling for trusted services, auto accept and user verification applies
+	PairingDecisionAccept                         // Accept the remote service automatically
+	PairingDecisionDeny                           // Deny the remote service, its connections are rejected
+)
+
+// Interface for deciding about pairing and connection requests of remote services
+//
+// Implemented by the application or the policies provided by the hub package, used by Hub
+type PairingPolicyInterface interface {
+	// Return the decision for a remote service
+	//
+	// entry contains the mDNS entry of the remote service if it is known, otherwise it is nil
+	PairingDecision(ski string, entry *MdnsEntry) PairingDecision
+}
+
+// Optional interface of a pairing policy which needs to know the remote services that got paired
+//
+// PairingDecision is also used for checks without a following pairing, e.g. for reporting
+// visible services, so a policy limiting the number of pairings has to count them here
+//
+// Implemented by CommissioningWindowPairingPolicy and PairingPolicyChain, used by Hub
+type PairingPolicyCompletionInterface interface {
+	// Called when the SHIP handshake with a remote service reached the trusted hello state
+	PairingCompleted(ski string)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/enbility/ship-go/util"
+)
+
+/* QR Code */
+
+const (
+	qrCodePrefix = "SHIP;"
+	qrCodeSuffix = "ENDSHIP;"
+)
+
+// The content of a SHIP QR code
+// as defined in SHIP Requirements for Installation Process V1.0.0
+type QRCode struct {
+	Ski        string               // mandatory, the certificates SKI, normalized
+	Identifier string               // mandatory, the identifier used for SHIP ID
+	Brand      string               // optional, the brand of the device
+	Type       string               // optional, the type of the device
+	Model      string               // optional, the model of the device
+	Serial     string               // optional, the serial number of the device
+	Categories []DeviceCategoryType // optional, the device categories of the device
+	Unknown    map[string]string    // all other key value pairs, with uppercase keys
+}
+
+var ErrInvalidQRCode = errors.New("invalid SHIP QR code")
+
+// Parse a SHIP QR code text, e.g. `SHIP;SKI:...;ID:...;BRAND:...;ENDSHIP;`
+//
+// Leading and trailing whitespace is ignored, everything else has to follow
+// the format, otherwise an error wrapping ErrInvalidQRCode is returned
+func ParseQRCodeText(text string) (*QRCode, error) {
+	text = strings.TrimSpace(text)
+
+	if !strings.HasPrefix(text, qrCodePrefix) || !strings.HasSuffix(text, qrCodeSuffix) ||
+		len(text) < len(qrCodePrefix)+len(qrCodeSuffix) {
+		return nil, fmt.Errorf("%w: missing SHIP; or ENDSHIP; framing", ErrInvalidQRCode)
+	}
+
+	content := strings.TrimSuffix(strings.TrimPrefix(text, qrCodePrefix), qrCodeSuffix)
+
+	if len(content) > 0 && !strings.HasSuffix(content, ";") {
+		return nil, fmt.Errorf("%w: missing separator before ENDSHIP;", ErrInvalidQRCode)
+	}
+
+	result := &QRCode{
+		Unknown: make(map[string]string),
+	}
+	found := make(map[string]bool)
+
+	items := strings.Split(content, ";")
+	for i, item := range items {
+		if len(item) == 0 {
+			// only the separator at the end of the last field is allowed
+			if i == len(items)-1 {
+				continue
+			}
+
+			return nil, fmt.Errorf("%w: empty field", ErrInvalidQRCode)
+		}
+
+		key, value, ok := strings.Cut(item, ":")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !ok || len(key) == 0 {
+			return nil, fmt.Errorf("%w: invalid field %q", ErrInvalidQRCode, item)
+		}
+
+		if found[key] {
+			return nil, fmt.Errorf("%w: duplicate field %s", ErrInvalidQRCode, key)
+		}
+		found[key] = true
+
+		switch key {
+		case "SKI":
+			result.Ski = util.NormalizeSKI(value)
+		case "ID":
+			result.Identifier = value
+		case "BRAND":
+			result.Brand = value
+		case "TYPE":
+			result.Type = value
+		case "MODEL":
+			result.Model = value
+		case "SERIAL":
+			result.Serial = value
+		case "CAT":
+			categories, err := parseQRCodeCategories(value)
+			if err != nil {
+				return nil, err
+			}
+			result.Categories = categories
+		default:
+			result.Unknown[key] = value
+		}
+	}
+
+	// SHIP 12.2: the SKI is a SHA-1 hash with 20 bytes
+	if ski, err := hex.DecodeString(result.Ski); err != nil || len(ski) != 20 {
+		return nil, fmt.Errorf("%w: invalid or missing SKI", ErrInvalidQRCode)
+	}
+
+	if len(result.Identifier) == 0 {
+		return nil, fmt.Errorf("%w: missing ID", ErrInvalidQRCode)
+	}
+
+	return result, nil
+}
+
+// parse a comma separated list of device categories
+func parseQRCodeCategories(value string) ([]DeviceCategoryType, error) {
+	var categories []DeviceCategoryType
+
+	if len(value) == 0 {
+		return categories, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		category, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid category %q", ErrInvalidQRCode, item)
+		}
+		categories = append(categories, DeviceCategoryType(category))
+	}
+
+	return categories, nil
+}
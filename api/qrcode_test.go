@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestQRCodeSuite(t *testing.T) {
+	suite.Run(t, new(QRCodeSuite))
+}
+
+type QRCodeSuite struct {
+	suite.Suite
+}
+
+func (s *QRCodeSuite) Test_ParseQRCodeText() {
+	text := "SHIP;SKI:0123456789ABCDEF0123456789ABCDEF01234567;ID:Demo-EVSE-234567890;BRAND:Demo;TYPE:Charger;MODEL:EVSE;SERIAL:234567890;CAT:3,5;EXTRA:a:b;ENDSHIP;\n"
+
+	qr, err := ParseQRCodeText(text)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), qr)
+	assert.Equal(s.T(), "0123456789abcdef0123456789abcdef01234567", qr.Ski)
+	assert.Equal(s.T(), "Demo-EVSE-234567890", qr.Identifier)
+	assert.Equal(s.T(), "Demo", qr.Brand)
+	assert.Equal(s.T(), "Charger", qr.Type)
+	assert.Equal(s.T(), "EVSE", qr.Model)
+	assert.Equal(s.T(), "234567890", qr.Serial)
+	assert.Equal(s.T(), []DeviceCategoryType{DeviceCategoryTypeEMobility, DeviceCategoryTypeInverter}, qr.Categories)
+	assert.Equal(s.T(), map[string]string{"EXTRA": "a:b"}, qr.Unknown)
+
+	qr, err = ParseQRCodeText("SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;ENDSHIP;")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "id", qr.Identifier)
+	assert.Equal(s.T(), 0, len(qr.Categories))
+}
+
+func (s *QRCodeSuite) Test_ParseQRCodeText_Invalid() {
+	tests := []string{
+		"",
+		"SHIP;ENDSHIP;",
+		"SKI:0123456789abcdef0123456789abcdef01234567;ID:id;ENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:idENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ENDSHIP;",
+		"SHIP;SKI:0123;ID:id;ENDSHIP;",
+		"SHIP;SKI:xyz3456789abcdef0123456789abcdef01234567;ID:id;ENDSHIP;",
+		"SHIP;ID:id;ENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;ID:id2;ENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;BRAND;ENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;:value;ENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;CAT:3,a;ENDSHIP;",
+		"SHIP;;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;ENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;;ID:id;ENDSHIP;",
+		"SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:id;;ENDSHIP;",
+	}
+
+	for _, text := range tests {
+		qr, err := ParseQRCodeText(text)
+		assert.Nil(s.T(), qr, text)
+		assert.True(s.T(), errors.Is(err, ErrInvalidQRCode), text)
+	}
+}
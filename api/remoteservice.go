@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 type RemoteService struct {
 	Name       string               `json:"name"`
 	Ski        string               `json:"ski"`
@@ -9,4 +11,6 @@ type RemoteService struct {
 	Model      string               `json:"model"`
 	Serial     string               `json:"serial"`
 	Categories []DeviceCategoryType `json:"categories"`
+	Register   bool                 `json:"register"`
+	LastSeen   time.Time            `json:"lastSeen"`
 }
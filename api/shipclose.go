@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/enbility/ship-go/model"
+)
+
+// The close code of a SHIP websocket connection
+type ShipCloseCode int
+
+const (
+	ShipCloseCodeNone           ShipCloseCode = 0    // no close code is available, e.g. as the connection dropped
+	ShipCloseCodeNormal         ShipCloseCode = 4001 // the connection is closed without a specific reason, e.g. after a connection termination
+	ShipCloseCodeShipIDMismatch ShipCloseCode = 4450 // the SHIP ID does not match the one stored for the SKI
+	ShipCloseCodeRejected       ShipCloseCode = 4452 // the node was rejected by the application
+	ShipCloseCodeUserClose      ShipCloseCode = 4500 // the connection is closed by the application, e.g. as the pairing was removed
+)
+
+// Returns the description of the close code, used as the websocket close reason
+func (c ShipCloseCode) String() string {
+	switch c {
+	case ShipCloseCodeNone:
+		return "no close code"
+	case ShipCloseCodeNormal:
+		return "close"
+	case ShipCloseCodeShipIDMismatch:
+		return "SHIP id mismatch"
+	case ShipCloseCodeRejected:
+		return "Node rejected by application"
+	case ShipCloseCodeUserClose:
+		return "User close"
+	default:
+		return fmt.Sprintf("close code %d", int(c))
+	}
+}
+
+// Describes why a SHIP connection was closed
+type ShipCloseDetails struct {
+	Remote      bool                            // true if the connection was closed by the remote service
+	Code        ShipCloseCode                   // the websocket close code, ShipCloseCodeNone if the connection dropped
+	Reason      string                          // the websocket close reason or the error the connection was closed with
+	CloseReason model.ConnectionCloseReasonType // the reason of the SHIP connection termination, empty if the connection was not terminated via connectionClose
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ShipCloseCodeString(t *testing.T) {
+	assert.Equal(t, "close", ShipCloseCodeNormal.String())
+	assert.Equal(t, "SHIP id mismatch", ShipCloseCodeShipIDMismatch.String())
+	assert.Equal(t, "Node rejected by application", ShipCloseCodeRejected.String())
+	assert.Equal(t, "User close", ShipCloseCodeUserClose.String())
+	assert.Equal(t, "close code 1000", ShipCloseCode(1000).String())
+}
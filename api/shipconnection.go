@@ -8,11 +8,29 @@ import (
 
 type ShipConnectionInterface interface {
 	DataHandler() WebsocketDataWriterInterface
-	CloseConnection(safe bool, code int, reason string)
+	// close the connection with a code and a reason
+	//
+	// if safe is true and the handshake is completed, the connection termination
+	// is announced to the remote service first. ShipCloseCodeNone uses ShipCloseCodeNormal
+	CloseConnection(safe bool, code ShipCloseCode, reason string)
+	// close the connection as the pairing with the remote service was removed
+	//
+	// if the handshake is completed, the connection termination is announced
+	// to the remote service with the reason removedConnection (SHIP 13.4.7)
+	CloseConnectionPairingRemoved()
 	RemoteSKI() string
 	ApprovePendingHandshake()
 	AbortPendingHandshake()
 	ShipHandshakeState() (model.ShipMessageExchangeState, error)
+
+	// return a snapshot of the details of the connection
+	ConnectionInfo() ShipConnectionInfo
+
+	// return why the connection was closed, empty while the connection is open
+	CloseDetails() ShipCloseDetails
+
+	// return the recorded SHIP handshake state transitions of the connection
+	StateHistory() ShipConnectionHistory
 }
 
 // interface for getting service wide information
@@ -25,12 +43,21 @@ type ShipConnectionInfoProviderInterface interface {
 	// check if auto accept is true
 	IsAutoAcceptEnabled() bool
 
+	// return the pairing policy decision for a SKI
+	PairingDecisionForSKI(string) PairingDecision
+
+	// report a completed handshake of a connection
+	HandleShipHandshakeCompleted(ShipConnectionInterface)
+
 	// report closing of a connection and if handshake did complete
 	HandleConnectionClosed(ShipConnectionInterface, bool)
 
 	// report the ship ID provided during the handshake
 	ReportServiceShipID(string, string)
 
+	// report that the remote service removed the pairing for a SKI
+	HandleRemotePairingRemoved(string)
+
 	// check if the user is still able to trust the connection
 	AllowWaitingForTrust(string) bool
 
@@ -38,14 +65,24 @@ type ShipConnectionInfoProviderInterface interface {
 	HandleShipHandshakeStateUpdate(string, model.ShipState)
 
 	// report an approved handshake by a remote device
-	SetupRemoteDevice(ski string, writeI ShipConnectionDataWriterInterface) ShipConnectionDataReaderInterface
+	//
+	// returns an error if no data reader is available for the remote device,
+	// the connection is then closed
+	SetupRemoteDevice(ski string, writeI ShipConnectionDataWriterInterface) (ShipConnectionDataReaderInterface, error)
 }
 
 // Used to pass an outgoing SPINE message from a DeviceLocal to the SHIP connection
 //
 // Implemented by ShipConnection, used by spine DeviceLocal
 type ShipConnectionDataWriterInterface interface {
+	// send a SPINE message, errors are only logged
 	WriteShipMessageWithPayload(message []byte)
+
+	// send a SPINE message and report the delivery result
+	//
+	// the returned channel receives exactly one value: nil once the message
+	// was written to the connection, or the error why it was not sent
+	WriteShipMessageWithPayloadResult(message []byte) <-chan error
 }
 
 // Used to pass an incoming SPINE message from a SHIP connection to the proper DeviceRemote
@@ -54,3 +91,24 @@ type ShipConnectionDataWriterInterface interface {
 type ShipConnectionDataReaderInterface interface {
 	HandleShipPayloadMessage(message []byte)
 }
+
+// defines how SPINE messages received before the SHIP handshake is completed are handled
+type SpineBufferPolicy uint
+
+const (
+	SpineBufferPolicyDropOldest SpineBufferPolicy = iota // Buffer the messages and drop the oldest ones if a limit is exceeded, default
+	SpineBufferPolicyReject                              // Buffer the messages and close the connection with an error if a limit is exceeded
+	SpineBufferPolicyForbid                              // Close the connection with an error if any SPINE message is received before the handshake is completed
+)
+
+const (
+	DefaultSpineBufferMaxMessages = 100         // the default maximum number of buffered SPINE messages
+	DefaultSpineBufferMaxBytes    = 1024 * 1024 // the default maximum number of buffered SPINE message bytes
+)
+
+// limits for buffering SPINE messages received before the SHIP handshake is completed
+type SpineBufferConfig struct {
+	Policy      SpineBufferPolicy // how to handle messages received before the handshake is completed
+	MaxMessages int               // the maximum number of buffered messages, DefaultSpineBufferMaxMessages if not positive
+	MaxBytes    int               // the maximum number of buffered payload bytes, DefaultSpineBufferMaxBytes if not positive
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enbility/ship-go/model"
+)
+
+// The event which triggered a SHIP handshake state transition
+//
+// for received SHIP messages this is the message type, e.g. "connectionHello"
+type ShipStateTrigger string
+
+const (
+	ShipStateTriggerNone            ShipStateTrigger = ""                // the transition was not triggered by an event, e.g. it was set directly
+	ShipStateTriggerStart           ShipStateTrigger = "start"           // the connection was started
+	ShipStateTriggerInit            ShipStateTrigger = "init"            // the CMI init message was received, SHIP 13.4.3
+	ShipStateTriggerTimeout         ShipStateTrigger = "timeout"         // a handshake timer expired
+	ShipStateTriggerApproved        ShipStateTrigger = "approved"        // the pending handshake was approved by the application
+	ShipStateTriggerAborted         ShipStateTrigger = "aborted"         // the pending handshake was aborted by the application
+	ShipStateTriggerClose           ShipStateTrigger = "close"           // the connection was closed locally
+	ShipStateTriggerConnectionError ShipStateTrigger = "connectionError" // the data connection was closed or failed
+)
+
+// A transition of the SHIP handshake state of a connection
+type ShipStateTransition struct {
+	Time    time.Time                      // the time of the transition
+	From    model.ShipMessageExchangeState // the previous state
+	To      model.ShipMessageExchangeState // the new state
+	Trigger ShipStateTrigger               // the event which triggered the transition
+	Error   error                          // the error the new state was entered with, nil if there is none
+}
+
+// The recorded SHIP handshake state transitions of a connection
+type ShipConnectionHistory struct {
+	RemoteSKI          string                // the SKI of the remote service
+	Role               ShipRole              // the role of the local service in the connection
+	ConnectedSince     time.Time             // the time the connection was established
+	ClosedAt           time.Time             // the time the connection was closed, zero while the connection is open
+	CloseDetails       ShipCloseDetails      // why the connection was closed, empty while the connection is open
+	Transitions        []ShipStateTransition // the transitions in the order they occurred, oldest first
+	DroppedTransitions uint64                // the number of oldest transitions dropped to limit the size of the history
+}
+
+// Returns the observed path of the handshake as a Graphviz DOT digraph
+//
+// every transition is an edge labeled with its sequence number and trigger,
+// transitions with an error are colored red and labeled with the error
+func (h ShipConnectionHistory) DOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote("SHIP "+h.RemoteSKI))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+
+	// number the edges like the transitions including the dropped ones
+	number := h.DroppedTransitions
+	for _, transition := range h.Transitions {
+		number++
+
+		label := fmt.Sprintf("%d", number)
+		if transition.Trigger != ShipStateTriggerNone {
+			label += ": " + string(transition.Trigger)
+		}
+
+		attributes := ""
+		if transition.Error != nil {
+			label += "\n" + transition.Error.Error()
+			attributes = ", color=red, fontcolor=red"
+		}
+
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s%s];\n",
+			strconv.Quote(shipStateName(transition.From)),
+			strconv.Quote(shipStateName(transition.To)),
+			strconv.Quote(label),
+			attributes)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// the names of the SHIP handshake states as used in the SHIP specification
+var shipStateNames = map[model.ShipMessageExchangeState]string{
+	model.CmiStateInitStart:                 "CMI_STATE_INIT_START",
+	model.CmiStateClientSend:                "CMI_STATE_CLIENT_SEND",
+	model.CmiStateClientWait:                "CMI_STATE_CLIENT_WAIT",
+	model.CmiStateClientEvaluate:            "CMI_STATE_CLIENT_EVALUATE",
+	model.CmiStateServerWait:                "CMI_STATE_SERVER_WAIT",
+	model.CmiStateServerEvaluate:            "CMI_STATE_SERVER_EVALUATE",
+	model.SmeHelloState:                     "SME_HELLO_STATE",
+	model.SmeHelloStateReadyInit:            "SME_HELLO_STATE_READY_INIT",
+	model.SmeHelloStateReadyListen:          "SME_HELLO_STATE_READY_LISTEN",
+	model.SmeHelloStateReadyTimeout:         "SME_HELLO_STATE_READY_TIMEOUT",
+	model.SmeHelloStatePendingInit:          "SME_HELLO_STATE_PENDING_INIT",
+	model.SmeHelloStatePendingListen:        "SME_HELLO_STATE_PENDING_LISTEN",
+	model.SmeHelloStatePendingTimeout:       "SME_HELLO_STATE_PENDING_TIMEOUT",
+	model.SmeHelloStateOk:                   "SME_HELLO_OK",
+	model.SmeHelloStateAbort:                "SME_HELLO_ABORT",
+	model.SmeHelloStateAbortDone:            "SME_HELLO_ABORT_DONE",
+	model.SmeHelloStateRemoteAbortDone:      "SME_HELLO_REMOTE_ABORT_DONE",
+	model.SmeHelloStateRejected:             "SME_HELLO_REJECTED",
+	model.SmeProtHStateServerInit:           "SME_PROT_H_STATE_SERVER_INIT",
+	model.SmeProtHStateClientInit:           "SME_PROT_H_STATE_CLIENT_INIT",
+	model.SmeProtHStateServerListenProposal: "SME_PROT_H_STATE_SERVER_LISTEN_PROPOSAL",
+	model.SmeProtHStateServerListenConfirm:  "SME_PROT_H_STATE_SERVER_LISTEN_CONFIRM",
+	model.SmeProtHStateClientListenChoice:   "SME_PROT_H_STATE_CLIENT_LISTEN_CHOICE",
+	model.SmeProtHStateTimeout:              "SME_PROT_H_STATE_TIMEOUT",
+	model.SmeProtHStateClientOk:             "SME_PROT_H_STATE_CLIENT_OK",
+	model.SmeProtHStateServerOk:             "SME_PROT_H_STATE_SERVER_OK",
+	model.SmePinStateCheckInit:              "SME_PIN_STATE_CHECK_INIT",
+	model.SmePinStateCheckListen:            "SME_PIN_STATE_CHECK_LISTEN",
+	model.SmePinStateCheckError:             "SME_PIN_STATE_CHECK_ERROR",
+	model.SmePinStateCheckBusyInit:          "SME_PIN_STATE_CHECK_BUSY_INIT",
+	model.SmePinStateCheckBusyWait:          "SME_PIN_STATE_CHECK_BUSY_WAIT",
+	model.SmePinStateCheckOk:                "SME_PIN_STATE_CHECK_OK",
+	model.SmePinStateAskInit:                "SME_PIN_STATE_ASK_INIT",
+	model.SmePinStateAskProcess:             "SME_PIN_STATE_ASK_PROCESS",
+	model.SmePinStateAskRestricted:          "SME_PIN_STATE_ASK_RESTRICTED",
+	model.SmePinStateAskOk:                  "SME_PIN_STATE_ASK_OK",
+	model.SmeAccessMethodsRequest:           "SME_ACCESS_METHODS_REQUEST",
+	model.SmeStateApproved:                  "SME_STATE_APPROVED",
+	model.SmeStateComplete:                  "SME_STATE_COMPLETE",
+	model.SmeStateError:                     "SME_STATE_ERROR",
+}
+
+// returns the name of a SHIP handshake state, or its number if it is unknown
+func shipStateName(state model.ShipMessageExchangeState) string {
+	if name, ok := shipStateNames[state]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("state %d", state)
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/enbility/ship-go/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ShipConnectionHistoryDOT(t *testing.T) {
+	history := ShipConnectionHistory{
+		RemoteSKI: "ski",
+		Transitions: []ShipStateTransition{
+			{From: model.CmiStateInitStart, To: model.CmiStateServerWait, Trigger: ShipStateTriggerStart},
+			{From: model.SmeHelloStateReadyListen, To: model.SmeHelloStateAbort, Trigger: ShipStateTriggerTimeout,
+				Error: &HandshakeTimeoutError{Phase: HandshakePhaseHello}},
+			{From: model.SmeHelloStateAbort, To: model.ShipMessageExchangeState(100)},
+		},
+		DroppedTransitions: 2,
+	}
+
+	expected := `digraph "SHIP ski" {
+	rankdir=LR;
+	node [shape=box];
+	"CMI_STATE_INIT_START" -> "CMI_STATE_SERVER_WAIT" [label="3: start"];
+	"SME_HELLO_STATE_READY_LISTEN" -> "SME_HELLO_ABORT" [label="4: timeout\nSHIP handshake timeout in phase hello", color=red, fontcolor=red];
+	"SME_HELLO_ABORT" -> "state 100" [label="5"];
+}
+`
+	assert.Equal(t, expected, history.DOT())
+}
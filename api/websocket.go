@@ -1,5 +1,11 @@
 package api
 
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
 /* WebsocketConnection */
 
 // interface for handling the actual remote device data connection
@@ -10,9 +16,31 @@ type WebsocketDataWriterInterface interface {
 	InitDataProcessing(WebsocketDataReaderInterface)
 
 	// send data via the connection to the remote device
+	//
+	// blocks until the message is queued or the connection is closed
 	WriteMessageToWebsocketConnection([]byte) error
 
+	// send data via the connection to the remote device
+	//
+	// blocks until the message is queued, the context is done or the connection is closed
+	WriteMessageToWebsocketConnectionWithContext(context.Context, []byte) error
+
+	// send data via the connection to the remote device
+	//
+	// the returned channel receives exactly one value: nil once the message
+	// was written to the connection, or the error why it was not queued or written,
+	// including the write deadline being exceeded
+	WriteMessageToWebsocketConnectionWithResult([]byte) <-chan error
+
+	// return the current statistics of the outgoing message queue
+	WriteQueueStats() WebsocketWriteQueueStats
+
+	// return the details and statistics of the connection
+	ConnectionInfo() WebsocketConnectionInfo
+
 	// close the data connection
+	//
+	// the reason is sent to the remote side, shortened to the maximum length of a close frame
 	CloseDataConnection(closeCode int, reason string)
 
 	// report if the data connection is closed and the error if availab le
@@ -31,4 +59,30 @@ type WebsocketDataReaderInterface interface {
 	ReportConnectionError(error)
 }
 
+// statistics of the outgoing message queue of a websocket connection
+//
+// SHIP control messages (init, control) are queued separately from
+// SPINE data messages and are always sent first, SHIP end messages
+// are queued after the SPINE data messages
+type WebsocketWriteQueueStats struct {
+	Capacity      int    // the maximum number of messages per priority queue
+	ControlQueued int    // the number of currently queued SHIP control messages
+	DataQueued    int    // the number of currently queued SPINE data messages
+	MaxQueued     int    // the highest number of messages queued at the same time
+	Rejected      uint64 // the number of messages rejected because the queue was full
+}
+
 const ShipWebsocketSubProtocol = "ship" // SHIP 10.2: sub protocol is required for websocket connections
+
+// ErrWebsocketWriteQueueFull if a message could not be queued as the outgoing queue is full
+var ErrWebsocketWriteQueueFull = errors.New("websocket write queue is full")
+
+// reported as connection error if the remote service closed the websocket connection
+type WebsocketCloseError struct {
+	Code int    // the close code sent by the remote service
+	Text string // the close reason sent by the remote service
+}
+
+func (e *WebsocketCloseError) Error() string {
+	return fmt.Sprintf("websocket closed by remote: %d %s", e.Code, e.Text)
+}
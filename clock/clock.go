@@ -0,0 +1,46 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/enbility/ship-go/api"
+)
+
+// The clock using the system time and the timers of the time package
+type SystemClock struct{}
+
+// create a new clock using the system time
+func NewSystemClock() *SystemClock {
+	return &SystemClock{}
+}
+
+var _ api.ClockInterface = (*SystemClock)(nil)
+
+func (c *SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (c *SystemClock) AfterFunc(d time.Duration, f func()) api.TimerInterface {
+	return time.AfterFunc(d, f)
+}
+
+func (c *SystemClock) NewTicker(d time.Duration) api.TickerInterface {
+	return &systemTicker{ticker: time.NewTicker(d)}
+}
+
+// wraps time.Ticker, as its channel is a field
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t *systemTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t *systemTicker) Stop() {
+	t.ticker.Stop()
+}
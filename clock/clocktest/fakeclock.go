@@ -0,0 +1,220 @@
+package clocktest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+)
+
+// A clock for tests, its time only moves when Advance is invoked
+//
+// Timers and tickers fire synchronously within Advance, in the order of their deadlines,
+// so timeouts and delays can be tested instantly and deterministically
+type FakeClock struct {
+	now time.Time
+
+	// the pending timers and tickers in the order they were created
+	waiters []*fakeWaiter
+
+	// signals added waiters to BlockUntil
+	cond *sync.Cond
+
+	mux sync.Mutex
+}
+
+// a pending timer or ticker of the fake clock
+type fakeWaiter struct {
+	clock *FakeClock
+
+	deadline time.Time
+
+	// the period of a ticker, 0 for timers
+	period time.Duration
+
+	// invoked when the deadline is reached
+	fire func(now time.Time)
+}
+
+// create a new fake clock starting at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mux)
+
+	return c
+}
+
+var _ api.ClockInterface = (*FakeClock)(nil)
+
+func (c *FakeClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.now
+}
+
+// the channel receives the time once the clock was advanced by at least the duration
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+
+	c.addWaiter(d, 0, func(now time.Time) {
+		ch <- now
+	})
+
+	return ch
+}
+
+// f is invoked synchronously by Advance once the clock was advanced by at least the duration,
+// or immediately if the duration is not positive
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) api.TimerInterface {
+	return c.addWaiter(d, 0, func(time.Time) {
+		f()
+	})
+}
+
+// ticks which are not received before the next tick are dropped, as with time.Ticker
+func (c *FakeClock) NewTicker(d time.Duration) api.TickerInterface {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	ticker := &fakeTicker{
+		ch: make(chan time.Time, 1),
+	}
+	ticker.waiter = c.addWaiter(d, d, func(now time.Time) {
+		select {
+		case ticker.ch <- now:
+		default:
+		}
+	})
+
+	return ticker
+}
+
+// move the time forward and fire all timers and tickers which deadline is reached
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mux.Lock()
+	target := c.now.Add(d)
+
+	for {
+		waiter := c.nextDueWaiter(target)
+		if waiter == nil {
+			break
+		}
+
+		c.now = waiter.deadline
+		if waiter.period > 0 {
+			waiter.deadline = waiter.deadline.Add(waiter.period)
+		} else {
+			c.removeWaiter(waiter)
+		}
+		now := c.now
+
+		// the waiter may use the clock
+		c.mux.Unlock()
+		waiter.fire(now)
+		c.mux.Lock()
+	}
+
+	if target.After(c.now) {
+		c.now = target
+	}
+	c.mux.Unlock()
+}
+
+// returns the number of pending timers and tickers
+func (c *FakeClock) Waiters() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return len(c.waiters)
+}
+
+// block until at least n timers and tickers are pending
+//
+// used to wait for timers created in other goroutines before advancing the clock
+func (c *FakeClock) BlockUntil(n int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) addWaiter(d, period time.Duration, fire func(now time.Time)) *fakeWaiter {
+	c.mux.Lock()
+
+	waiter := &fakeWaiter{
+		clock:    c,
+		deadline: c.now.Add(d),
+		period:   period,
+		fire:     fire,
+	}
+
+	if d <= 0 && period == 0 {
+		now := c.now
+		c.mux.Unlock()
+
+		fire(now)
+		return waiter
+	}
+
+	c.waiters = append(c.waiters, waiter)
+	c.cond.Broadcast()
+	c.mux.Unlock()
+
+	return waiter
+}
+
+// returns the waiter with the earliest deadline not after target, nil if there is none
+//
+// needs to be called with mux locked
+func (c *FakeClock) nextDueWaiter(target time.Time) *fakeWaiter {
+	var next *fakeWaiter
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(target) {
+			continue
+		}
+		if next == nil || waiter.deadline.Before(next.deadline) {
+			next = waiter
+		}
+	}
+
+	return next
+}
+
+// returns false if the waiter is not pending
+//
+// needs to be called with mux locked
+func (c *FakeClock) removeWaiter(waiter *fakeWaiter) bool {
+	for i, item := range c.waiters {
+		if item == waiter {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mux.Lock()
+	defer w.clock.mux.Unlock()
+
+	return w.clock.removeWaiter(w)
+}
+
+// a ticker of the fake clock
+type fakeTicker struct {
+	ch     chan time.Time
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.waiter.Stop()
+}
@@ -0,0 +1,117 @@
+package clocktest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFakeClockSuite(t *testing.T) {
+	suite.Run(t, new(FakeClockSuite))
+}
+
+type FakeClockSuite struct {
+	suite.Suite
+
+	start time.Time
+	sut   *FakeClock
+}
+
+func (s *FakeClockSuite) BeforeTest(suiteName, testName string) {
+	s.start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.sut = NewFakeClock(s.start)
+}
+
+func (s *FakeClockSuite) Test_Now() {
+	assert.Equal(s.T(), s.start, s.sut.Now())
+
+	s.sut.Advance(time.Minute)
+	assert.Equal(s.T(), s.start.Add(time.Minute), s.sut.Now())
+}
+
+func (s *FakeClockSuite) Test_After() {
+	ch := s.sut.After(time.Second)
+	assert.Equal(s.T(), 1, s.sut.Waiters())
+
+	s.sut.Advance(time.Second - time.Millisecond)
+	assert.Len(s.T(), ch, 0)
+
+	s.sut.Advance(time.Millisecond)
+	assert.Equal(s.T(), s.start.Add(time.Second), <-ch)
+	assert.Equal(s.T(), 0, s.sut.Waiters())
+}
+
+func (s *FakeClockSuite) Test_AfterFunc() {
+	var fired []int
+	s.sut.AfterFunc(time.Second*2, func() { fired = append(fired, 2) })
+	timer := s.sut.AfterFunc(time.Second*3, func() { fired = append(fired, 3) })
+	s.sut.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+
+	// timers fire in the order of their deadlines
+	s.sut.Advance(time.Second * 2)
+	assert.Equal(s.T(), []int{1, 2}, fired)
+
+	assert.True(s.T(), timer.Stop())
+	assert.False(s.T(), timer.Stop())
+
+	s.sut.Advance(time.Second * 2)
+	assert.Equal(s.T(), []int{1, 2}, fired)
+
+	// a timer without a duration fires immediately
+	s.sut.AfterFunc(0, func() { fired = append(fired, 0) })
+	assert.Equal(s.T(), []int{1, 2, 0}, fired)
+}
+
+func (s *FakeClockSuite) Test_AfterFunc_Nested() {
+	var fired []time.Time
+	s.sut.AfterFunc(time.Second, func() {
+		fired = append(fired, s.sut.Now())
+
+		// timers created while advancing fire within the same advance
+		s.sut.AfterFunc(time.Second, func() {
+			fired = append(fired, s.sut.Now())
+		})
+	})
+
+	s.sut.Advance(time.Second * 5)
+	assert.Equal(s.T(), []time.Time{s.start.Add(time.Second), s.start.Add(time.Second * 2)}, fired)
+	assert.Equal(s.T(), s.start.Add(time.Second*5), s.sut.Now())
+}
+
+func (s *FakeClockSuite) Test_Ticker() {
+	assert.Panics(s.T(), func() { s.sut.NewTicker(0) })
+
+	ticker := s.sut.NewTicker(time.Second)
+
+	s.sut.Advance(time.Second)
+	assert.Equal(s.T(), s.start.Add(time.Second), <-ticker.C())
+
+	// ticks which are not received are dropped
+	s.sut.Advance(time.Second * 3)
+	assert.Equal(s.T(), s.start.Add(time.Second*2), <-ticker.C())
+	assert.Len(s.T(), ticker.C(), 0)
+
+	ticker.Stop()
+	assert.Equal(s.T(), 0, s.sut.Waiters())
+
+	s.sut.Advance(time.Second)
+	assert.Len(s.T(), ticker.C(), 0)
+}
+
+func (s *FakeClockSuite) Test_BlockUntil() {
+	done := make(chan struct{})
+	go func() {
+		s.sut.BlockUntil(1)
+		close(done)
+	}()
+
+	_ = s.sut.After(time.Second)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		s.T().Fatal("BlockUntil did not return")
+	}
+}
@@ -0,0 +1,240 @@
+package hub
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/util"
+)
+
+// Sets the timeout and the warning threshold for invoking the HubReaderInterface callbacks
+//
+// Non positive values are replaced by the defaults
+func (h *Hub) SetCallbackConfig(config api.CallbackConfig) {
+	h.callbacks.setConfig(config)
+}
+
+// a queued invocation of an application callback
+type callback struct {
+	// the name of the HubReaderInterface method, used for logging
+	name string
+
+	invoke func()
+
+	// receives if the callback returned in time, nil if nobody waits for the result
+	result chan bool
+
+	// set once the callback is invoked, protected by the dispatcher mutex
+	started bool
+
+	// set if the caller stopped waiting before the callback was invoked, it is then skipped,
+	// protected by the dispatcher mutex
+	abandoned bool
+}
+
+// Delivers the HubReaderInterface callbacks to the application
+//
+// The callbacks of each SKI are delivered in order by a goroutine, which exists
+// as long as callbacks are queued for the SKI. Slow callbacks are logged, blocking
+// or panicking callbacks don't stall the SHIP handshake or the websocket pumps
+type callbackDispatcher struct {
+	// the queued callbacks per SKI, a SKI has a running worker while it is in the map
+	queues map[string][]*callback
+
+	config api.CallbackConfig
+
+	clock api.ClockInterface
+
+	// signaled when a worker stops as its queue is empty
+	idle *sync.Cond
+
+	mux sync.Mutex
+}
+
+func newCallbackDispatcher(clock api.ClockInterface) *callbackDispatcher {
+	d := &callbackDispatcher{
+		queues: make(map[string][]*callback),
+		clock:  clock,
+	}
+	d.idle = sync.NewCond(&d.mux)
+	d.setConfig(api.CallbackConfig{})
+
+	return d
+}
+
+func (d *callbackDispatcher) setConfig(config api.CallbackConfig) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if config.Timeout <= 0 {
+		config.Timeout = api.DefaultCallbackTimeout
+	}
+	if config.WarnThreshold <= 0 {
+		config.WarnThreshold = api.DefaultCallbackWarnThreshold
+	}
+
+	d.config = config
+}
+
+func (d *callbackDispatcher) setClock(clock api.ClockInterface) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.clock = clock
+}
+
+// queue a callback for the SKI without waiting for it
+func (d *callbackDispatcher) notify(ski, name string, invoke func()) {
+	d.enqueue(ski, &callback{name: name, invoke: invoke})
+}
+
+// queue a callback for the SKI and wait until it returned
+//
+// The timeout includes waiting for the queued callbacks of the SKI, so a caller
+// is not stalled by several slow callbacks. A callback not invoked within the
+// timeout is skipped.
+//
+// returns false if the callback timed out, was skipped or panicked
+func (d *callbackDispatcher) call(ski, name string, invoke func()) bool {
+	cb := &callback{name: name, invoke: invoke, result: make(chan bool, 1)}
+
+	d.mux.Lock()
+	config := d.config
+	clock := d.clock
+	d.mux.Unlock()
+
+	timeout := make(chan struct{})
+	timer := clock.AfterFunc(config.Timeout, func() {
+		close(timeout)
+	})
+	defer timer.Stop()
+
+	d.enqueue(ski, cb)
+
+	select {
+	case ok := <-cb.result:
+		return ok
+
+	case <-timeout:
+		d.mux.Lock()
+		defer d.mux.Unlock()
+
+		// a running callback is reported by its delivery
+		if !cb.started {
+			cb.abandoned = true
+			logging.Log().Errorf("application callback %s for %s was not invoked within %s, it is skipped", name, ski, config.Timeout)
+		}
+		return false
+	}
+}
+
+// block until all queued callbacks are delivered
+func (d *callbackDispatcher) wait() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	for len(d.queues) > 0 {
+		d.idle.Wait()
+	}
+}
+
+func (d *callbackDispatcher) enqueue(ski string, cb *callback) {
+	ski = util.NormalizeSKI(ski)
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	queue, running := d.queues[ski]
+	d.queues[ski] = append(queue, cb)
+
+	if !running {
+		go d.run(ski)
+	}
+}
+
+// deliver the queued callbacks of a SKI until its queue is empty
+func (d *callbackDispatcher) run(ski string) {
+	for {
+		d.mux.Lock()
+		queue := d.queues[ski]
+		if len(queue) == 0 {
+			delete(d.queues, ski)
+			d.idle.Broadcast()
+			d.mux.Unlock()
+			return
+		}
+
+		cb := queue[0]
+		queue[0] = nil
+		d.queues[ski] = queue[1:]
+		if cb.abandoned {
+			d.mux.Unlock()
+			continue
+		}
+		cb.started = true
+		config := d.config
+		clock := d.clock
+		d.mux.Unlock()
+
+		d.deliver(ski, cb, config, clock)
+	}
+}
+
+// invoke a callback and wait until it returned
+//
+// A waiting caller gets false once the callback exceeds the configured timeout or panicked.
+// The next callbacks of the SKI are delivered only after a timed out callback returned,
+// so the callbacks of a SKI are never invoked concurrently
+func (d *callbackDispatcher) deliver(ski string, cb *callback, config api.CallbackConfig, clock api.ClockInterface) {
+	returned := make(chan bool, 1)
+	start := clock.Now()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.Log().Errorf("application callback %s for %s panicked: %v", cb.name, ski, r)
+				returned <- false
+			}
+		}()
+
+		cb.invoke()
+		returned <- true
+	}()
+
+	// log while the callback is still running, as it may never return
+	warning := clock.AfterFunc(config.WarnThreshold, func() {
+		logging.Log().Errorf("application callback %s for %s is slow, it did not return within %s", cb.name, ski, config.WarnThreshold)
+	})
+	defer warning.Stop()
+
+	timeout := make(chan struct{})
+	timer := clock.AfterFunc(config.Timeout, func() {
+		close(timeout)
+	})
+	defer timer.Stop()
+
+	var ok bool
+	select {
+	case ok = <-returned:
+
+	case <-timeout:
+		logging.Log().Errorf("application callback %s for %s did not return within %s, the next callbacks for the SKI are delayed", cb.name, ski, config.Timeout)
+		if cb.result != nil {
+			cb.result <- false
+		}
+
+		<-returned
+		logging.Log().Infof("application callback %s for %s returned after %s", cb.name, ski, clock.Now().Sub(start).Round(time.Millisecond))
+		return
+	}
+
+	if duration := clock.Now().Sub(start); duration > config.WarnThreshold {
+		logging.Log().Infof("application callback %s for %s returned after %s", cb.name, ski, duration.Round(time.Millisecond))
+	}
+
+	if cb.result != nil {
+		cb.result <- ok
+	}
+}
@@ -0,0 +1,197 @@
+package hub
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
+	"github.com/enbility/ship-go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCallbackDispatcherSuite(t *testing.T) {
+	suite.Run(t, new(CallbackDispatcherSuite))
+}
+
+type CallbackDispatcherSuite struct {
+	suite.Suite
+
+	clock *clocktest.FakeClock
+	sut   *callbackDispatcher
+}
+
+func (s *CallbackDispatcherSuite) BeforeTest(suiteName, testName string) {
+	s.clock = clocktest.NewFakeClock(time.Now())
+	s.sut = newCallbackDispatcher(s.clock)
+}
+
+func (s *CallbackDispatcherSuite) AfterTest(suiteName, testName string) {
+	s.sut.wait()
+}
+
+func (s *CallbackDispatcherSuite) Test_Config() {
+	assert.Equal(s.T(), api.DefaultCallbackTimeout, s.sut.config.Timeout)
+	assert.Equal(s.T(), api.DefaultCallbackWarnThreshold, s.sut.config.WarnThreshold)
+
+	s.sut.setConfig(api.CallbackConfig{Timeout: time.Second})
+	assert.Equal(s.T(), time.Second, s.sut.config.Timeout)
+	assert.Equal(s.T(), api.DefaultCallbackWarnThreshold, s.sut.config.WarnThreshold)
+}
+
+func (s *CallbackDispatcherSuite) Test_OrderedPerSki() {
+	var mux sync.Mutex
+	var order []int
+
+	for i := 0; i < 20; i++ {
+		// the SKI is normalized, so all callbacks use the same queue
+		ski := "ab cd"
+		if i%2 == 0 {
+			ski = "ABCD"
+		}
+
+		s.sut.notify(ski, "test", func() {
+			mux.Lock()
+			defer mux.Unlock()
+
+			order = append(order, i)
+		})
+	}
+
+	s.sut.wait()
+
+	mux.Lock()
+	defer mux.Unlock()
+	if assert.Equal(s.T(), 20, len(order)) {
+		for i, value := range order {
+			assert.Equal(s.T(), i, value)
+		}
+	}
+}
+
+func (s *CallbackDispatcherSuite) Test_BlockingSkiDoesNotBlockOthers() {
+	release := make(chan struct{})
+	s.sut.notify("ab", "test", func() {
+		<-release
+	})
+
+	called := false
+	ok := s.sut.call("cd", "test", func() {
+		called = true
+	})
+	assert.True(s.T(), ok)
+	assert.True(s.T(), called)
+
+	close(release)
+}
+
+func (s *CallbackDispatcherSuite) Test_Timeout() {
+	release := make(chan struct{})
+
+	// the timeout of the caller, the warning and the timeout of the delivery
+	go func() {
+		s.clock.BlockUntil(3)
+		s.clock.Advance(api.DefaultCallbackTimeout)
+	}()
+
+	ok := s.sut.call("ab", "test", func() {
+		<-release
+	})
+	assert.False(s.T(), ok)
+
+	// the following callbacks of the SKI are delivered once the timed out one returned
+	called := make(chan struct{})
+	s.sut.notify("ab", "test", func() {
+		close(called)
+	})
+
+	select {
+	case <-called:
+		assert.Fail(s.T(), "callback invoked while the previous one is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-called
+}
+
+func (s *CallbackDispatcherSuite) Test_TimeoutIncludesQueueWait() {
+	release := make(chan struct{})
+
+	// the first callback times out and keeps the queue blocked
+	for i := 0; i < 2; i++ {
+		s.sut.notify("ab", "test", func() {
+			<-release
+		})
+	}
+
+	go func() {
+		s.clock.BlockUntil(3)
+		s.clock.Advance(api.DefaultCallbackTimeout)
+	}()
+
+	// the caller is not stalled by both queued callbacks
+	called := false
+	ok := s.sut.call("ab", "test", func() {
+		called = true
+	})
+	assert.False(s.T(), ok)
+
+	close(release)
+	s.sut.wait()
+
+	// the callback is skipped as it was not invoked in time
+	assert.False(s.T(), called)
+}
+
+type slowLogger struct {
+	logging.NoLogging
+
+	warnings chan string
+}
+
+func (l *slowLogger) Errorf(format string, args ...interface{}) {
+	if message := fmt.Sprintf(format, args...); strings.Contains(message, "is slow") {
+		l.warnings <- message
+	}
+}
+
+func (s *CallbackDispatcherSuite) Test_SlowWarning() {
+	logger := &slowLogger{warnings: make(chan string, 1)}
+	logging.SetLogging(logger)
+	defer logging.SetLogging(&logging.NoLogging{})
+
+	release := make(chan struct{})
+	result := make(chan bool, 1)
+	go func() {
+		result <- s.sut.call("ab", "test", func() {
+			<-release
+		})
+	}()
+
+	// the warning is logged while the callback is still running
+	s.clock.BlockUntil(3)
+	s.clock.Advance(api.DefaultCallbackWarnThreshold)
+	assert.Contains(s.T(), <-logger.warnings, "is slow")
+
+	close(release)
+	assert.True(s.T(), <-result)
+}
+
+func (s *CallbackDispatcherSuite) Test_Panic() {
+	ok := s.sut.call("ab", "test", func() {
+		panic("callback failed")
+	})
+	assert.False(s.T(), ok)
+
+	called := false
+	ok = s.sut.call("ab", "test", func() {
+		called = true
+	})
+	assert.True(s.T(), ok)
+	assert.True(s.T(), called)
+}
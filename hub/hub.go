@@ -3,12 +3,18 @@ package hub
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/util"
+	"github.com/enbility/ship-go/ws"
 )
 
 // used for randomizing the connection initiation delay
@@ -30,10 +36,20 @@ var connectionInitiationDelayTimeRanges = []connectionInitiationDelayTimeRange{
 type Hub struct {
 	connections map[string]api.ShipConnectionInterface
 
+	// the generations of the connections with a completed handshake,
+	// which are not reported as disconnected yet
+	connectedGenerations map[api.ShipConnectionInterface]uint64
+
+	// the generation of the most recently completed connection
+	connectionGeneration uint64
+
 	// which attempt is it to initate an connection to the remote SKI
 	connectionAttemptCounter map[string]int
 	connectionAttemptRunning map[string]bool
 
+	// the timers of the delayed connection attempts per SKI
+	connectionAttemptTimers map[string]api.TimerInterface
+
 	port        int
 	certifciate tls.Certificate
 
@@ -43,12 +59,42 @@ type Hub struct {
 
 	autoaccept bool
 
+	// the policy deciding about pairing and connection requests
+	pairingPolicy api.PairingPolicyInterface
+
+	// the SHIP IDs of pre-authorized remote services, e.g. scanned from QR codes
+	preAuthorized map[string]string
+
+	// wether the pairing is removed, when the remote service removed the pairing
+	unpairOnRemoteRemoval bool
+
+	// the SKIs of remote services which removed the pairing, no connections are initiated to them
+	remotePairingRemoved map[string]bool
+
+	// the maximum number of queued outgoing messages per priority for each websocket connection
+	wsWriteQueueSize int
+
+	// the limits and policy for SPINE messages received before the SHIP handshake is completed
+	spineBufferConfig api.SpineBufferConfig
+
+	// the source of the current time and all timers
+	clock api.ClockInterface
+
 	// The list of known remote services
 	remoteServices map[string]*api.ServiceDetails
 
 	// The web server for handling incoming websocket connections
 	httpServer *http.Server
 
+	// the listener for the web server, optional
+	listener net.Listener
+
+	// the websocket path announced via mDNS
+	websocketPath string
+
+	// wether the hub is mounted on a web server of the application
+	externalServer bool
+
 	// Handling mDNS related tasks
 	mdns api.MdnsInterface
 
@@ -57,11 +103,40 @@ type Hub struct {
 
 	hasStarted bool
 
+	// the limits for incoming connections and messages
+	limits api.ConnectionLimits
+
+	// the number of incoming connections with an incomplete handshake
+	pendingHandshakes int
+
+	// the number of incoming connections per source IP address
+	connectionsPerIP map[string]int
+
+	// the incoming connections accounted for in the connection limits
+	incomingConnections map[api.WebsocketDataWriterInterface]*incomingConnection
+
+	// the recent incoming connection attempts per SKI
+	skiAttempts map[string][]time.Time
+
+	// the SKIs which incoming connections are rejected until the given time
+	skiBans map[string]time.Time
+
+	// the state histories of the last closed connections per SKI
+	connectionHistories map[string][]api.ShipConnectionHistory
+
+	// the SKIs of connectionHistories, ordered by their last closed connection
+	connectionHistorySKIs []string
+
+	// delivers the callbacks to hubReader
+	callbacks *callbackDispatcher
+
 	muxCon        sync.Mutex
 	muxConAttempt sync.Mutex
 	muxReg        sync.Mutex
 	muxMdns       sync.Mutex
 	muxStarted    sync.Mutex
+	muxLimits     sync.Mutex
+	muxHistory    sync.Mutex
 }
 
 func NewHub(hubReader api.HubReaderInterface,
@@ -71,16 +146,29 @@ func NewHub(hubReader api.HubReaderInterface,
 	localService *api.ServiceDetails) *Hub {
 	hub := &Hub{
 		connections:              make(map[string]api.ShipConnectionInterface),
+		connectedGenerations:     make(map[api.ShipConnectionInterface]uint64),
 		connectionAttemptCounter: make(map[string]int),
 		connectionAttemptRunning: make(map[string]bool),
+		connectionAttemptTimers:  make(map[string]api.TimerInterface),
 		remoteServices:           make(map[string]*api.ServiceDetails),
+		preAuthorized:            make(map[string]string),
+		remotePairingRemoved:     make(map[string]bool),
 		knownMdnsEntries:         make([]*api.MdnsEntry, 0),
+		connectionsPerIP:         make(map[string]int),
+		incomingConnections:      make(map[api.WebsocketDataWriterInterface]*incomingConnection),
+		skiAttempts:              make(map[string][]time.Time),
+		skiBans:                  make(map[string]time.Time),
+		connectionHistories:      make(map[string][]api.ShipConnectionHistory),
 		hubReader:                hubReader,
 		port:                     port,
 		certifciate:              certificate,
 		localService:             localService,
 		mdns:                     mdns,
+		wsWriteQueueSize:         ws.DefaultWriteQueueSize,
+		websocketPath:            defaultWebsocketPath,
+		clock:                    clock.NewSystemClock(),
 	}
+	hub.callbacks = newCallbackDispatcher(hub.clock)
 
 	return hub
 }
@@ -108,8 +196,16 @@ func (h *Hub) Start() {
 // close all connections
 func (h *Hub) Shutdown() {
 	h.mdns.Shutdown()
+
+	h.muxCon.Lock()
+	connections := make([]api.ShipConnectionInterface, 0, len(h.connections))
 	for _, c := range h.connections {
-		c.CloseConnection(false, 0, "")
+		connections = append(connections, c)
+	}
+	h.muxCon.Unlock()
+
+	for _, c := range connections {
+		c.CloseConnection(false, api.ShipCloseCodeNone, "")
 	}
 	if h.httpServer == nil {
 		return
@@ -136,6 +232,23 @@ func (h *Hub) ServiceForSKI(ski string) *api.ServiceDetails {
 	return service
 }
 
+// return all known remote services, sorted by SKI
+func (h *Hub) KnownServices() []*api.ServiceDetails {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	services := make([]*api.ServiceDetails, 0, len(h.remoteServices))
+	for _, service := range h.remoteServices {
+		services = append(services, service)
+	}
+
+	slices.SortFunc(services, func(a, b *api.ServiceDetails) int {
+		return strings.Compare(a.SKI(), b.SKI())
+	})
+
+	return services
+}
+
 // return the number of paired services
 func (h *Hub) numberPairedServices() int {
 	amount := 0
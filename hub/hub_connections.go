@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/enbility/ship-go/api"
@@ -35,44 +36,45 @@ func (h *Hub) verifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.
 		}
 	}
 	if !skiFound {
-		return errors.New("no valid SKI provided in certificate")
+		return &api.CertificateVerificationError{Err: errors.New("no valid SKI provided in certificate")}
 	}
 
 	return nil
 }
 
-// start the ship websocket server
-func (h *Hub) startWebsocketServer() error {
-	addr := fmt.Sprintf(":%d", h.port)
-	logging.Log().Debug("starting websocket server on", addr)
-
-	h.httpServer = &http.Server{
-		Addr:              addr,
-		Handler:           h,
-		ReadHeaderTimeout: time.Duration(time.Second * 10),
-		TLSConfig: &tls.Config{
-			Certificates:          []tls.Certificate{h.certifciate},
-			ClientAuth:            tls.RequireAnyClientCert, // SHIP 9: Client authentication is required
-			CipherSuites:          cert.CipherSuites,        // #nosec G402 // SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
-			VerifyPeerCertificate: h.verifyPeerCertificate,
-			MinVersion:            tls.VersionTLS12, // SHIP 9: Mandatory TLS version
-		},
-	}
+// Connection Handling
 
-	go func() {
-		if err := h.httpServer.ListenAndServeTLS("", ""); err != nil {
-			logging.Log().Error("websocket server error:", err)
-			// TODO: decide how to handle this case
+// HTTP Server callback for handling incoming connection requests
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the SKI is checked against the pairing policy and limits before upgrading, if it is already available
+	var limitSki string
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		if ski, err := cert.SkiFromCertificate(r.TLS.PeerCertificates[0]); err == nil {
+			limitSki = api.NewServiceDetails(ski).SKI()
 		}
-	}()
+	}
 
-	return nil
-}
+	if len(limitSki) > 0 && h.PairingDecisionForSKI(limitSki) == api.PairingDecisionDeny {
+		logging.Log().Debug("rejecting incoming connection: denied by pairing policy", limitSki)
+		http.Error(w, "denied by pairing policy", http.StatusForbidden)
+		return
+	}
 
-// Connection Handling
+	incomingConn, err := h.reserveIncomingConnection(remoteIPFromAddr(r.RemoteAddr), limitSki)
+	if err != nil {
+		logging.Log().Debug("rejecting incoming connection:", err)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+
+	// the connection is accounted for until it is handed over to the ship connection
+	handedOver := false
+	defer func() {
+		if !handedOver {
+			h.releaseIncomingConnection(incomingConn)
+		}
+	}()
 
-// HTTP Server callback for handling incoming connection requests
-func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  ws.MaxMessageSize,
 		WriteBufferSize: ws.MaxMessageSize,
@@ -116,7 +118,7 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	connectionStateDetail := service.ConnectionStateDetail()
 	if connectionStateDetail.State() == api.ConnectionStateQueued {
 		connectionStateDetail.SetState(api.ConnectionStateReceivedPairingRequest)
-		h.hubReader.ServicePairingDetailUpdate(ski, connectionStateDetail)
+		h.reportServicePairingDetail(ski, connectionStateDetail)
 	}
 
 	remoteService = service
@@ -127,14 +129,40 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dataHandler := ws.NewWebsocketConnection(conn, remoteService.SKI())
+	dataHandler := h.newWebsocketConnection(conn, remoteService.SKI())
+
+	// the ship connection starts processing the websocket data, so the connection
+	// may already get closed before the ship connection is returned
+	h.trackIncomingConnection(dataHandler, incomingConn)
+	handedOver = true
+
 	shipConnection := ship.NewConnectionHandler(h, dataHandler, ship.ShipRoleServer,
 		h.localService.ShipID(), remoteService.SKI(), remoteService.ShipID())
+	h.configureShipConnection(shipConnection)
+
 	shipConnection.Run()
 
 	h.registerConnection(shipConnection)
 }
 
+// create a new websocket connection handler with the hub settings applied
+func (h *Hub) newWebsocketConnection(conn *websocket.Conn, remoteSki string) *ws.WebsocketConnection {
+	dataHandler := ws.NewWebsocketConnection(conn, remoteSki)
+	dataHandler.SetClock(h.getClock())
+	dataHandler.SetWriteQueueSize(h.websocketWriteQueueSize())
+
+	limits := h.connectionLimits()
+	dataHandler.SetMessageRateLimit(limits.MaxMessagesPerSecond, limits.MessageBurst)
+
+	return dataHandler
+}
+
+// apply the hub settings to a new ship connection before it is started
+func (h *Hub) configureShipConnection(shipConnection *ship.ShipConnection) {
+	shipConnection.SetClock(h.getClock())
+	shipConnection.SetSpineBufferConfig(h.getSpineBufferConfig())
+}
+
 // return if there is a connection for a SKI
 func (h *Hub) isSkiConnected(ski string) bool {
 	h.muxCon.Lock()
@@ -186,24 +214,27 @@ func (h *Hub) connectFoundService(remoteService *api.ServiceDetails, host, port,
 
 	if len(remoteCerts) == 0 || remoteCerts[0].SubjectKeyId == nil {
 		// Close connection as we couldn't get the remote SKI
-		errorString := fmt.Sprintf("closing connection to %s: could not get remote SKI from certificate", remoteService.SKI())
 		_ = conn.Close()
-		return errors.New(errorString)
+		return &api.CertificateVerificationError{
+			Ski: remoteService.SKI(),
+			Err: errors.New("could not get remote SKI from certificate"),
+		}
 	}
 
 	if _, err := cert.SkiFromCertificate(remoteCerts[0]); err != nil {
 		// Close connection as the remote SKI can't be correct
-		errorString := fmt.Sprintf("closing connection to %s: %s", remoteService.SKI(), err)
 		_ = conn.Close()
-		return errors.New(errorString)
+		return &api.CertificateVerificationError{Ski: remoteService.SKI(), Err: err}
 	}
 
 	remoteSKI := fmt.Sprintf("%0x", remoteCerts[0].SubjectKeyId)
 
 	if remoteSKI != remoteService.SKI() {
-		errorString := fmt.Sprintf("closing connection to %s: SKI does not match %s", remoteService.SKI(), remoteSKI)
 		_ = conn.Close()
-		return errors.New(errorString)
+		return &api.CertificateVerificationError{
+			Ski: remoteService.SKI(),
+			Err: fmt.Errorf("SKI does not match %s", remoteSKI),
+		}
 	}
 
 	if !h.keepThisConnection(conn, false, remoteService) {
@@ -211,9 +242,10 @@ func (h *Hub) connectFoundService(remoteService *api.ServiceDetails, host, port,
 		return errors.New(errorString)
 	}
 
-	dataHandler := ws.NewWebsocketConnection(conn, remoteService.SKI())
+	dataHandler := h.newWebsocketConnection(conn, remoteService.SKI())
 	shipConnection := ship.NewConnectionHandler(h, dataHandler, ship.ShipRoleClient,
 		h.localService.ShipID(), remoteService.SKI(), remoteService.ShipID())
+	h.configureShipConnection(shipConnection)
 	shipConnection.Run()
 
 	h.registerConnection(shipConnection)
@@ -252,7 +284,7 @@ func (h *Hub) keepThisConnection(conn *websocket.Conn, incomingRequest bool, rem
 		// we have an existing connection
 		// so keep the new (most recent) and close the old one
 		logging.Log().Debug("closing existing double connection")
-		go existingC.CloseConnection(false, 0, "")
+		go existingC.CloseConnection(false, api.ShipCloseCodeNone, "")
 	} else {
 		connType := "incoming"
 		if !incomingRequest {
@@ -269,7 +301,7 @@ func (h *Hub) keepThisConnection(conn *websocket.Conn, incomingRequest bool, rem
 
 func (h *Hub) sendWSCloseMessage(conn *websocket.Conn) {
 	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "double connection"))
-	<-time.After(time.Millisecond * 100)
+	<-h.getClock().After(time.Millisecond * 100)
 	_ = conn.Close()
 }
 
@@ -291,20 +323,20 @@ func (h *Hub) coordinateConnectionInitations(ski string, entry *api.MdnsEntry) {
 
 	logging.Log().Debugf("delaying connection to %s by %s to minimize double connection probability", ski, duration)
 
-	// we do not stop this thread and just let the timer run out
-	// otherwise we would need a stop channel for each ski
-	go func() {
-		// wait
-		<-time.After(duration)
-
+	// the timer is kept, so the attempt can be canceled if the endpoint changes
+	timer := h.getClock().AfterFunc(duration, func() {
 		h.prepareConnectionInitation(ski, counter, entry)
-	}()
+	})
+	h.setConnectionAttemptTimer(ski, timer)
 }
 
 // invoked by coordinateConnectionInitations either with a delay or directly
 // when initating a pairing process
 func (h *Hub) prepareConnectionInitation(ski string, counter int, entry *api.MdnsEntry) {
-	h.setConnectionAttemptRunning(ski, false)
+	h.muxConAttempt.Lock()
+	h.connectionAttemptRunning[ski] = false
+	delete(h.connectionAttemptTimers, ski)
+	h.muxConAttempt.Unlock()
 
 	// check if the current counter is still the same, otherwise this counter is irrelevant
 	currentCounter, exists := h.getCurrentConnectionAttemptCounter(ski)
@@ -324,10 +356,20 @@ func (h *Hub) prepareConnectionInitation(ski string, counter int, entry *api.Mdn
 		return
 	}
 
+	// connection attempt is not relevant if the remote service removed the pairing
+	if h.isRemotePairingRemoved(ski) {
+		return
+	}
+
 	// now initiate the connection
 	// check if the remoteService still exists
 	service := h.ServiceForSKI(ski)
 
+	// the entry may have been updated in the meantime, e.g. with a new port
+	if known := h.knownMdnsEntry(ski); known != nil {
+		entry = known
+	}
+
 	if success := h.initateConnection(service, entry); !success {
 		h.checkAutoReannounce()
 	}
@@ -350,6 +392,7 @@ func (h *Hub) initateConnection(remoteService *api.ServiceDetails, entry *api.Md
 		logging.Log().Debug("trying to connect to", remoteService.SKI(), "at", entry.Host)
 		if err = h.connectFoundService(remoteService, entry.Host, strconv.Itoa(entry.Port), entry.Path); err != nil {
 			logging.Log().Debugf("connection to %s failed: %s", remoteService.SKI(), err)
+			h.checkCertificateVerificationError(remoteService, err)
 		} else {
 			return true
 		}
@@ -377,6 +420,7 @@ func (h *Hub) initateConnection(remoteService *api.ServiceDetails, entry *api.Md
 		}
 		if err = h.connectFoundService(remoteService, addressValue, strconv.Itoa(entry.Port), entry.Path); err != nil {
 			logging.Log().Debug("connection to", remoteService.SKI(), "failed: ", err)
+			h.checkCertificateVerificationError(remoteService, err)
 		} else {
 			return true
 		}
@@ -387,6 +431,19 @@ func (h *Hub) initateConnection(remoteService *api.ServiceDetails, entry *api.Md
 	return false
 }
 
+// report a failed certificate verification of a remote service as connection error,
+// as it will not resolve by retrying
+func (h *Hub) checkCertificateVerificationError(remoteService *api.ServiceDetails, err error) {
+	if !errors.Is(err, api.ErrCertificateVerification) {
+		return
+	}
+
+	detail := api.NewConnectionStateDetail(api.ConnectionStateError, err)
+	remoteService.SetConnectionStateDetail(detail)
+
+	h.reportServicePairingDetail(remoteService.SKI(), detail)
+}
+
 // increase the connection attempt counter for the given ski
 func (h *Hub) increaseConnectionAttemptCounter(ski string) int {
 	h.muxConAttempt.Lock()
@@ -452,6 +509,28 @@ func (h *Hub) setConnectionAttemptRunning(ski string, active bool) {
 	h.connectionAttemptRunning[ski] = active
 }
 
+// keep the timer of a delayed connection attempt
+func (h *Hub) setConnectionAttemptTimer(ski string, timer api.TimerInterface) {
+	h.muxConAttempt.Lock()
+	defer h.muxConAttempt.Unlock()
+
+	h.connectionAttemptTimers[ski] = timer
+}
+
+// stop a delayed connection attempt and reset the attempt counter
+func (h *Hub) cancelConnectionAttempt(ski string) {
+	h.muxConAttempt.Lock()
+	defer h.muxConAttempt.Unlock()
+
+	if timer, ok := h.connectionAttemptTimers[ski]; ok {
+		timer.Stop()
+		delete(h.connectionAttemptTimers, ski)
+	}
+
+	delete(h.connectionAttemptCounter, ski)
+	h.connectionAttemptRunning[ski] = false
+}
+
 // return if a connection attempt is runnning/in progress
 func (h *Hub) isConnectionAttemptRunning(ski string) bool {
 	h.muxConAttempt.Lock()
@@ -473,6 +552,31 @@ func (h *Hub) registerConnection(connection api.ShipConnectionInterface) {
 	h.connections[connection.RemoteSKI()] = connection
 }
 
+// return snapshots of all active connections, sorted by SKI
+func (h *Hub) Connections() []api.ShipConnectionInfo {
+	h.muxCon.Lock()
+	connections := make([]api.ShipConnectionInterface, 0, len(h.connections))
+	generations := make([]uint64, 0, len(h.connections))
+	for _, con := range h.connections {
+		connections = append(connections, con)
+		generations = append(generations, h.connectedGenerations[con])
+	}
+	h.muxCon.Unlock()
+
+	result := make([]api.ShipConnectionInfo, 0, len(connections))
+	for i, con := range connections {
+		info := con.ConnectionInfo()
+		info.Generation = generations[i]
+		result = append(result, info)
+	}
+
+	slices.SortFunc(result, func(a, b api.ShipConnectionInfo) int {
+		return strings.Compare(a.RemoteSKI, b.RemoteSKI)
+	})
+
+	return result
+}
+
 // return the connection for a specific SKI
 func (h *Hub) connectionForSKI(ski string) api.ShipConnectionInterface {
 	h.muxCon.Lock()
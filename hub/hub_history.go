@@ -0,0 +1,57 @@
+package hub
+
+import (
+	"slices"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/util"
+)
+
+// the maximum number of closed connections per SKI the state history is kept for
+const connectionHistorySize = 10
+
+// the maximum number of SKIs the state histories are kept for
+//
+// includes unpaired remote services, whose SKIs are chosen by the remote side
+const connectionHistorySKIs = 100
+
+// keep the state history of a closed connection, drops the oldest if there are too many
+//
+// the histories of the SKI with the least recently closed connection are dropped,
+// if there are histories for too many SKIs
+func (h *Hub) recordConnectionHistory(history api.ShipConnectionHistory) {
+	h.muxHistory.Lock()
+	defer h.muxHistory.Unlock()
+
+	ski := util.NormalizeSKI(history.RemoteSKI)
+
+	histories := h.connectionHistories[ski]
+	if len(histories) >= connectionHistorySize {
+		histories = histories[len(histories)-connectionHistorySize+1:]
+	}
+
+	h.connectionHistories[ski] = append(histories, history)
+
+	// the most recently closed connection is last
+	h.connectionHistorySKIs = slices.DeleteFunc(h.connectionHistorySKIs, func(item string) bool {
+		return item == ski
+	})
+	h.connectionHistorySKIs = append(h.connectionHistorySKIs, ski)
+
+	if len(h.connectionHistorySKIs) > connectionHistorySKIs {
+		delete(h.connectionHistories, h.connectionHistorySKIs[0])
+		h.connectionHistorySKIs = h.connectionHistorySKIs[1:]
+	}
+}
+
+// Provide the SHIP handshake state histories of the last closed connections to a SKI, oldest first
+func (h *Hub) ConnectionHistoryForSKI(ski string) []api.ShipConnectionHistory {
+	h.muxHistory.Lock()
+	defer h.muxHistory.Unlock()
+
+	histories := h.connectionHistories[util.NormalizeSKI(ski)]
+	result := make([]api.ShipConnectionHistory, len(histories))
+	copy(result, histories)
+
+	return result
+}
@@ -0,0 +1,248 @@
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+)
+
+// an incoming connection accounted for in the connection limits
+type incomingConnection struct {
+	// the source IP address of the connection
+	ip string
+
+	// true as long as the SHIP handshake is not completed
+	pending bool
+}
+
+// Sets the limits for incoming connections and messages
+func (h *Hub) SetConnectionLimits(limits api.ConnectionLimits) {
+	h.muxLimits.Lock()
+	defer h.muxLimits.Unlock()
+
+	h.limits = limits
+}
+
+func (h *Hub) connectionLimits() api.ConnectionLimits {
+	h.muxLimits.Lock()
+	defer h.muxLimits.Unlock()
+
+	return h.limits
+}
+
+// return the IP address of a remote address in the host:port format
+func remoteIPFromAddr(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
+
+// check the limits for a new incoming connection and account for it if it is allowed
+//
+// the SKI may be empty if it is not known yet
+// returns the accounted connection or an error if the connection has to be rejected
+func (h *Hub) reserveIncomingConnection(ip, ski string) (*incomingConnection, error) {
+	h.muxLimits.Lock()
+	defer h.muxLimits.Unlock()
+
+	limits := h.limits
+
+	if limits.MaxPendingHandshakes > 0 && h.pendingHandshakes >= limits.MaxPendingHandshakes {
+		return nil, errors.New("too many pending handshakes")
+	}
+
+	if limits.MaxConnectionsPerIP > 0 && h.connectionsPerIP[ip] >= limits.MaxConnectionsPerIP {
+		return nil, fmt.Errorf("too many connections from %s", ip)
+	}
+
+	if len(ski) > 0 {
+		if err := h.checkSkiAttemptLimit(ski, limits); err != nil {
+			return nil, err
+		}
+	}
+
+	h.pendingHandshakes++
+	h.connectionsPerIP[ip]++
+
+	return &incomingConnection{ip: ip, pending: true}, nil
+}
+
+// the maximum number of SKIs for which connection attempts and bans are tracked
+//
+// the SKIs of incoming connections are not verified yet, so a remote service
+// using random certificates must not be able to grow these without limit
+const maxTrackedSKIs = 1000
+
+// record a connection attempt of a SKI and check if the SKI is or will be banned
+//
+// needs to be called with muxLimits locked
+func (h *Hub) checkSkiAttemptLimit(ski string, limits api.ConnectionLimits) error {
+	now := h.getClock().Now()
+
+	h.pruneSkiAttempts(now, limits)
+
+	if bannedUntil, ok := h.skiBans[ski]; ok {
+		if now.Before(bannedUntil) {
+			return fmt.Errorf("connection attempts of %s are rejected until %s", ski, bannedUntil.Format(time.RFC3339))
+		}
+		delete(h.skiBans, ski)
+	}
+
+	if limits.MaxAttemptsPerSKI <= 0 || limits.AttemptWindow <= 0 {
+		return nil
+	}
+
+	// only keep the attempts within the window
+	var attempts []time.Time
+	for _, attempt := range h.skiAttempts[ski] {
+		if now.Sub(attempt) < limits.AttemptWindow {
+			attempts = append(attempts, attempt)
+		}
+	}
+	attempts = append(attempts, now)
+	h.skiAttempts[ski] = attempts
+
+	if len(h.skiAttempts) > maxTrackedSKIs {
+		h.evictOldestSkiAttempts(ski)
+	}
+
+	if len(attempts) <= limits.MaxAttemptsPerSKI {
+		return nil
+	}
+
+	if limits.BanDuration > 0 {
+		h.skiBans[ski] = now.Add(limits.BanDuration)
+		delete(h.skiAttempts, ski)
+
+		if len(h.skiBans) > maxTrackedSKIs {
+			h.evictEarliestSkiBan(ski)
+		}
+	}
+
+	return fmt.Errorf("too many connection attempts from %s", ski)
+}
+
+// remove the expired bans and the SKIs without attempts within the window
+//
+// needs to be called with muxLimits locked
+func (h *Hub) pruneSkiAttempts(now time.Time, limits api.ConnectionLimits) {
+	for ski, bannedUntil := range h.skiBans {
+		if !now.Before(bannedUntil) {
+			delete(h.skiBans, ski)
+		}
+	}
+
+	for ski, attempts := range h.skiAttempts {
+		// the attempts are ordered, so the last one is the most recent
+		if len(attempts) == 0 || limits.AttemptWindow <= 0 ||
+			now.Sub(attempts[len(attempts)-1]) >= limits.AttemptWindow {
+			delete(h.skiAttempts, ski)
+		}
+	}
+}
+
+// remove the SKI with the oldest most recent connection attempt, except the given SKI
+//
+// needs to be called with muxLimits locked
+func (h *Hub) evictOldestSkiAttempts(except string) {
+	var oldestSki string
+	var oldest time.Time
+
+	for ski, attempts := range h.skiAttempts {
+		if ski == except {
+			continue
+		}
+
+		last := attempts[len(attempts)-1]
+		if len(oldestSki) == 0 || last.Before(oldest) {
+			oldestSki = ski
+			oldest = last
+		}
+	}
+
+	delete(h.skiAttempts, oldestSki)
+}
+
+// remove the SKI with the earliest ending ban, except the given SKI
+//
+// needs to be called with muxLimits locked
+func (h *Hub) evictEarliestSkiBan(except string) {
+	var earliestSki string
+	var earliest time.Time
+
+	for ski, bannedUntil := range h.skiBans {
+		if ski == except {
+			continue
+		}
+
+		if len(earliestSki) == 0 || bannedUntil.Before(earliest) {
+			earliestSki = ski
+			earliest = bannedUntil
+		}
+	}
+
+	delete(h.skiBans, earliestSki)
+}
+
+// assign an accounted incoming connection to its data handler
+func (h *Hub) trackIncomingConnection(dataHandler api.WebsocketDataWriterInterface, connection *incomingConnection) {
+	h.muxLimits.Lock()
+	defer h.muxLimits.Unlock()
+
+	h.incomingConnections[dataHandler] = connection
+}
+
+// the handshake of a connection completed, so it is no longer pending
+func (h *Hub) incomingHandshakeCompleted(dataHandler api.WebsocketDataWriterInterface) {
+	h.muxLimits.Lock()
+	defer h.muxLimits.Unlock()
+
+	connection, ok := h.incomingConnections[dataHandler]
+	if !ok || !connection.pending {
+		return
+	}
+
+	connection.pending = false
+	h.pendingHandshakes--
+}
+
+// remove an incoming connection from the connection limits accounting
+func (h *Hub) releaseIncomingConnection(connection *incomingConnection) {
+	h.muxLimits.Lock()
+	defer h.muxLimits.Unlock()
+
+	h.releaseIncomingConnectionLocked(connection)
+}
+
+// needs to be called with muxLimits locked
+func (h *Hub) releaseIncomingConnectionLocked(connection *incomingConnection) {
+	if connection.pending {
+		connection.pending = false
+		h.pendingHandshakes--
+	}
+
+	h.connectionsPerIP[connection.ip]--
+	if h.connectionsPerIP[connection.ip] <= 0 {
+		delete(h.connectionsPerIP, connection.ip)
+	}
+}
+
+// a connection got closed, remove it from the connection limits accounting
+func (h *Hub) untrackIncomingConnection(dataHandler api.WebsocketDataWriterInterface) {
+	h.muxLimits.Lock()
+	defer h.muxLimits.Unlock()
+
+	connection, ok := h.incomingConnections[dataHandler]
+	if !ok {
+		return
+	}
+
+	delete(h.incomingConnections, dataHandler)
+	h.releaseIncomingConnectionLocked(connection)
+}
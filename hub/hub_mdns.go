@@ -6,10 +6,27 @@ import (
 	"strings"
 
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/util"
 )
 
 var _ api.MdnsReportInterface = (*Hub)(nil)
 
+// return the currently known mDNS entry for a SKI, nil if there is none
+func (h *Hub) knownMdnsEntry(ski string) *api.MdnsEntry {
+	h.muxMdns.Lock()
+	defer h.muxMdns.Unlock()
+
+	ski = util.NormalizeSKI(ski)
+	for _, entry := range h.knownMdnsEntries {
+		if util.NormalizeSKI(entry.Ski) == ski {
+			return entry
+		}
+	}
+
+	return nil
+}
+
 // Process reported mDNS services
 func (h *Hub) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bool) {
 	var mdnsEntries []*api.MdnsEntry
@@ -22,8 +39,26 @@ func (h *Hub) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bo
 			continue
 		}
 
+		// the remote service removed the pairing, so do not connect to it
+		if h.isRemotePairingRemoved(ski) {
+			continue
+		}
+
+		// Check the pairing policy
+		decision := h.pairingDecision(ski, entry)
+		if decision == api.PairingDecisionDeny {
+			continue
+		}
+
 		// Check if the remote service is paired or queued for connection
 		service := h.ServiceForSKI(ski)
+		if decision == api.PairingDecisionAccept &&
+			service.ConnectionStateDetail().State() == api.ConnectionStateNone {
+			// the policy accepts the remote service, so queue it for pairing
+			service.ConnectionStateDetail().SetState(api.ConnectionStateQueued)
+			h.reportServicePairingDetail(ski, service.ConnectionStateDetail())
+		}
+
 		if !h.IsRemoteServiceForSKIPaired(ski) &&
 			service.ConnectionStateDetail().State() != api.ConnectionStateQueued {
 			continue
@@ -67,10 +102,45 @@ func (h *Hub) ReportMdnsEntries(entries map[string]*api.MdnsEntry, newEntries bo
 			Model:      entry.Model,
 			Serial:     entry.Serial,
 			Categories: entry.Categories,
+			Register:   entry.Register,
+			LastSeen:   entry.LastSeen,
 		}
 
 		remoteServices = append(remoteServices, remoteService)
 	}
 
-	h.hubReader.VisibleRemoteServicesUpdated(remoteServices)
+	// not related to a SKI, so delivered in order with the other mDNS updates
+	h.callbacks.notify("", "VisibleRemoteServicesUpdated", func() {
+		h.hubReader.VisibleRemoteServicesUpdated(remoteServices)
+	})
+}
+
+// Process the update of an already known mDNS entry
+func (h *Hub) ReportMdnsEntryUpdate(update api.MdnsEntryUpdate) {
+	if update.Current == nil {
+		return
+	}
+
+	ski := update.Current.Ski
+
+	if update.Changes.Has(api.MdnsEntryChangeRegister) {
+		logging.Log().Debug("mdns: remote service", ski, "changed register to", update.Current.Register)
+	}
+
+	endpointChanges := api.MdnsEntryChangeHost | api.MdnsEntryChangePort |
+		api.MdnsEntryChangePath | api.MdnsEntryChangeAddresses
+	if !update.Changes.Has(endpointChanges) {
+		return
+	}
+
+	// an established connection is kept, reconnecting will use the updated endpoint
+	if h.isSkiConnected(ski) {
+		return
+	}
+
+	logging.Log().Debug("mdns: remote service", ski, "changed its endpoint, resetting connection attempts")
+
+	// a pending connection attempt is replaced by a new one without delay increase,
+	// which is initiated when the updated entries are reported
+	h.cancelConnectionAttempt(ski)
 }
@@ -73,6 +73,148 @@ func (h *Hub) SetAutoAccept(autoaccept bool) {
 	h.mdns.SetAutoAccept(autoaccept)
 }
 
+// Sets the policy deciding about pairing and connection requests
+func (h *Hub) SetPairingPolicy(policy api.PairingPolicyInterface) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.pairingPolicy = policy
+
+	if setter, ok := policy.(clockSetter); ok {
+		setter.SetClock(h.clock)
+	}
+}
+
+// Sets the clock used for all timeouts and delays of the hub, its pairing policy
+// and mDNS manager, and of the connections established after this call
+func (h *Hub) SetClock(clock api.ClockInterface) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.clock = clock
+	h.callbacks.setClock(clock)
+
+	if setter, ok := h.pairingPolicy.(clockSetter); ok {
+		setter.SetClock(clock)
+	}
+
+	if setter, ok := h.mdns.(clockSetter); ok {
+		setter.SetClock(clock)
+	}
+}
+
+func (h *Hub) getClock() api.ClockInterface {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	return h.clock
+}
+
+// Sets if the pairing of a SKI is removed automatically,
+// when the remote service announces that it removed the pairing
+func (h *Hub) SetUnpairOnRemoteRemoval(enable bool) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.unpairOnRemoteRemoval = enable
+}
+
+func (h *Hub) isUnpairOnRemoteRemovalEnabled() bool {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	return h.unpairOnRemoteRemoval
+}
+
+// sets if the remote service removed the pairing for the SKI
+func (h *Hub) setRemotePairingRemoved(ski string, removed bool) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	if removed {
+		h.remotePairingRemoved[util.NormalizeSKI(ski)] = true
+		return
+	}
+
+	delete(h.remotePairingRemoved, util.NormalizeSKI(ski))
+}
+
+// check if the remote service removed the pairing for the SKI
+func (h *Hub) isRemotePairingRemoved(ski string) bool {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	return h.remotePairingRemoved[util.NormalizeSKI(ski)]
+}
+
+// return the pairing policy decision for a SKI
+func (h *Hub) PairingDecisionForSKI(ski string) api.PairingDecision {
+	return h.pairingDecision(ski, h.knownMdnsEntry(ski))
+}
+
+// return the pairing policy decision for a SKI and its mDNS entry
+//
+// pre-authorized remote services are accepted, if the mDNS entry is unknown or has a matching ID
+func (h *Hub) pairingDecision(ski string, entry *api.MdnsEntry) api.PairingDecision {
+	h.muxReg.Lock()
+	policy := h.pairingPolicy
+	shipID, preAuthorized := h.preAuthorized[util.NormalizeSKI(ski)]
+	h.muxReg.Unlock()
+
+	if preAuthorized && (entry == nil || entry.Identifier == shipID) {
+		return api.PairingDecisionAccept
+	}
+
+	if policy == nil {
+		return api.PairingDecisionDefault
+	}
+
+	return policy.PairingDecision(util.NormalizeSKI(ski), entry)
+}
+
+// report a paired remote service to the pairing policy
+func (h *Hub) pairingCompleted(ski string) {
+	h.muxReg.Lock()
+	policy := h.pairingPolicy
+	h.muxReg.Unlock()
+
+	if completion, ok := policy.(api.PairingPolicyCompletionInterface); ok {
+		completion.PairingCompleted(util.NormalizeSKI(ski))
+	}
+}
+
+// Sets the maximum number of outgoing messages that can be queued
+// per priority for each websocket connection
+func (h *Hub) SetWebsocketWriteQueueSize(size int) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.wsWriteQueueSize = size
+}
+
+func (h *Hub) websocketWriteQueueSize() int {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	return h.wsWriteQueueSize
+}
+
+// Sets the limits and policy for SPINE messages received
+// before the SHIP handshake is completed
+func (h *Hub) SetSpineBufferConfig(config api.SpineBufferConfig) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	h.spineBufferConfig = config
+}
+
+func (h *Hub) getSpineBufferConfig() api.SpineBufferConfig {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	return h.spineBufferConfig
+}
+
 // check if auto accept is true
 func (h *Hub) IsAutoAcceptEnabled() bool {
 	h.muxReg.Lock()
@@ -93,6 +235,8 @@ func (h *Hub) checkHasStarted() bool {
 func (h *Hub) RegisterRemoteSKI(ski string) {
 	ski = util.NormalizeSKI(ski)
 
+	h.setRemotePairingRemoved(ski, false)
+
 	// if the hub has not started, simply add it
 	if !h.checkHasStarted() {
 		service := h.ServiceForSKI(ski)
@@ -118,13 +262,56 @@ func (h *Hub) RegisterRemoteSKI(ski string) {
 	// locally initiated
 	service.ConnectionStateDetail().SetState(api.ConnectionStateQueued)
 
-	h.hubReader.ServicePairingDetailUpdate(ski, service.ConnectionStateDetail())
+	h.reportServicePairingDetail(ski, service.ConnectionStateDetail())
 
 	h.mdns.RequestMdnsEntries()
 }
 
+// Pre-authorize a remote service with its SKI and SHIP ID, e.g. scanned from its QR code
+//
+// The service is paired and connected automatically, once it is discovered via mDNS
+// with matching SKI and ID. The SHIP ID is also verified during the handshake
+func (h *Hub) PreAuthorizeRemoteService(ski, shipID string) {
+	ski = util.NormalizeSKI(ski)
+
+	h.muxReg.Lock()
+	h.preAuthorized[ski] = shipID
+	h.muxReg.Unlock()
+
+	service := h.ServiceForSKI(ski)
+	service.SetShipID(shipID)
+
+	// trigger a search if the hub has started, the connection is initiated once it is found
+	if h.checkHasStarted() {
+		h.mdns.RequestMdnsEntries()
+	}
+}
+
+// Remove the pre-authorization of a remote service
+func (h *Hub) RemovePreAuthorizedRemoteService(ski string) {
+	h.muxReg.Lock()
+	defer h.muxReg.Unlock()
+
+	delete(h.preAuthorized, util.NormalizeSKI(ski))
+}
+
 // Remove pairing for the SKI
+//
+// the removal is announced to the remote service, if it is connected
 func (h *Hub) UnregisterRemoteSKI(ski string) {
+	h.setRemotePairingRemoved(ski, false)
+
+	h.unpairRemoteSKI(ski)
+
+	if existingC := h.connectionForSKI(ski); existingC != nil {
+		existingC.CloseConnectionPairingRemoved()
+	}
+}
+
+// remove the pairing for the SKI without closing its connection
+func (h *Hub) unpairRemoteSKI(ski string) {
+	h.RemovePreAuthorizedRemoteService(ski)
+
 	service := h.ServiceForSKI(ski)
 	service.SetTrusted(false)
 
@@ -132,11 +319,7 @@ func (h *Hub) UnregisterRemoteSKI(ski string) {
 
 	service.ConnectionStateDetail().SetState(api.ConnectionStateNone)
 
-	h.hubReader.ServicePairingDetailUpdate(ski, service.ConnectionStateDetail())
-
-	if existingC := h.connectionForSKI(ski); existingC != nil {
-		existingC.CloseConnection(true, 4500, "User close")
-	}
+	h.reportServicePairingDetail(ski, service.ConnectionStateDetail())
 }
 
 // Disconnect a connection to an SKI, used by a service implementation
@@ -147,7 +330,7 @@ func (h *Hub) DisconnectSKI(ski string, reason string) {
 		return
 	}
 
-	con.CloseConnection(true, 0, reason)
+	con.CloseConnection(true, api.ShipCloseCodeNone, reason)
 }
 
 // Cancels the pairing process for a SKI
@@ -162,5 +345,5 @@ func (h *Hub) CancelPairingWithSKI(ski string) {
 	service.ConnectionStateDetail().SetState(api.ConnectionStateNone)
 	service.SetTrusted(false)
 
-	h.hubReader.ServicePairingDetailUpdate(ski, service.ConnectionStateDetail())
+	h.reportServicePairingDetail(ski, service.ConnectionStateDetail())
 }
@@ -0,0 +1,106 @@
+package hub
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/logging"
+)
+
+// the default path of the SHIP websocket server
+const defaultWebsocketPath = "/ship/"
+
+// Sets the listener the websocket server accepts incoming connections on,
+// instead of listening on the port on all interfaces.
+// The listener has to provide plain TCP connections, TLS is applied by the hub.
+// The port of the listener address is announced via mDNS and the listener
+// is closed on Shutdown.
+// Needs to be invoked before Start
+//
+// Default: nil
+func (h *Hub) SetWebsocketListener(listener net.Listener) {
+	h.listener = listener
+}
+
+// Sets the path of the websocket server announced via mDNS
+// Needs to be invoked before Start
+//
+// Default: "/ship/"
+func (h *Hub) SetWebsocketPath(path string) {
+	if len(path) == 0 {
+		path = defaultWebsocketPath
+	}
+
+	h.websocketPath = path
+}
+
+// Disables the websocket server of the hub, as the hub is mounted as handler
+// on an existing HTTPS server of the application under the websocket path.
+// The existing server has to use the TLS configuration provided by TLSConfig,
+// port is the port of the existing server announced via mDNS.
+// Needs to be invoked before Start
+func (h *Hub) SetExternalWebsocketServer(port int) {
+	h.port = port
+	h.externalServer = true
+}
+
+// Returns the TLS configuration required for the websocket server
+//
+// SHIP requires client authentication, the provided client certificates
+// are checked to contain a SKI
+func (h *Hub) TLSConfig() *tls.Config {
+	return &tls.Config{
+		Certificates:          []tls.Certificate{h.certifciate},
+		ClientAuth:            tls.RequireAnyClientCert, // SHIP 9: Client authentication is required
+		CipherSuites:          cert.CipherSuites,        // #nosec G402 // SHIP 9.1: the ciphers are reported insecure but are defined to be used by SHIP
+		VerifyPeerCertificate: h.verifyPeerCertificate,
+		MinVersion:            tls.VersionTLS12, // SHIP 9: Mandatory TLS version
+	}
+}
+
+// start the ship websocket server
+//
+// the port the server actually listens on and the path are passed on to mDNS
+func (h *Hub) startWebsocketServer() error {
+	if h.externalServer {
+		h.mdns.SetWebsocketEndpoint(h.port, h.websocketPath)
+		return nil
+	}
+
+	listener := h.listener
+	if listener == nil {
+		addr := fmt.Sprintf(":%d", h.port)
+
+		var err error
+		if listener, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	}
+
+	// the port may have been chosen by the system, e.g. when using port 0
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		h.port = addr.Port
+	}
+	h.mdns.SetWebsocketEndpoint(h.port, h.websocketPath)
+
+	logging.Log().Debug("starting websocket server on", listener.Addr())
+
+	h.httpServer = &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: time.Duration(time.Second * 10),
+		TLSConfig:         h.TLSConfig(),
+	}
+
+	go func() {
+		if err := h.httpServer.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
+			logging.Log().Error("websocket server error:", err)
+			// TODO: decide how to handle this case
+		}
+	}()
+
+	return nil
+}
@@ -17,10 +17,34 @@ func (h *Hub) IsRemoteServiceForSKIPaired(ski string) bool {
 	return service.Trusted()
 }
 
+// report a completed handshake of a connection
+func (h *Hub) HandleShipHandshakeCompleted(connection api.ShipConnectionInterface) {
+	// a completed handshake no longer counts against the pending handshakes limit,
+	// use this connection as there may be another one for the same SKI
+	h.incomingHandshakeCompleted(connection.DataHandler())
+
+	h.muxCon.Lock()
+	h.connectionGeneration++
+	generation := h.connectionGeneration
+	h.connectedGenerations[connection] = generation
+	h.muxCon.Unlock()
+
+	// the remote service connected again, so it is paired again
+	h.setRemotePairingRemoved(connection.RemoteSKI(), false)
+
+	ski := connection.RemoteSKI()
+	h.callbacks.notify(ski, "RemoteSKIConnected", func() {
+		h.hubReader.RemoteSKIConnected(ski, generation)
+	})
+}
+
 // report closing of a connection and if handshake did complete
 func (h *Hub) HandleConnectionClosed(connection api.ShipConnectionInterface, handshakeCompleted bool) {
 	remoteSki := connection.RemoteSKI()
 
+	h.untrackIncomingConnection(connection.DataHandler())
+	h.recordConnectionHistory(connection.StateHistory())
+
 	// only remove this connection if it is the registered one for the ski!
 	// as we can have double connections but only one can be registered
 	if existingC := h.connectionForSKI(remoteSki); existingC != nil {
@@ -36,7 +60,18 @@ func (h *Hub) HandleConnectionClosed(connection api.ShipConnectionInterface, han
 		}
 	}
 
-	h.hubReader.RemoteSKIDisconnected(connection.RemoteSKI())
+	// only connections reported as connected are reported as disconnected, and only once
+	h.muxCon.Lock()
+	generation, connected := h.connectedGenerations[connection]
+	delete(h.connectedGenerations, connection)
+	h.muxCon.Unlock()
+
+	if connected {
+		closeDetails := connection.CloseDetails()
+		h.callbacks.notify(remoteSki, "RemoteSKIDisconnected", func() {
+			h.hubReader.RemoteSKIDisconnected(remoteSki, generation, closeDetails)
+		})
+	}
 
 	// Do not automatically reconnect if handshake failed and not already paired
 	remoteService := h.ServiceForSKI(connection.RemoteSKI())
@@ -49,9 +84,25 @@ func (h *Hub) HandleConnectionClosed(connection api.ShipConnectionInterface, han
 
 // report the ship ID provided during the handshake
 func (h *Hub) ReportServiceShipID(ski string, shipdID string) {
-	h.hubReader.RemoteSKIConnected(ski)
+	h.callbacks.notify(ski, "ServiceShipIDUpdate", func() {
+		h.hubReader.ServiceShipIDUpdate(ski, shipdID)
+	})
+}
 
-	h.hubReader.ServiceShipIDUpdate(ski, shipdID)
+// report that the remote service removed the pairing for a SKI
+func (h *Hub) HandleRemotePairingRemoved(ski string) {
+	// stop initiating connections to the remote service
+	h.setRemotePairingRemoved(ski, true)
+	h.removeConnectionAttemptCounter(ski)
+
+	if h.isUnpairOnRemoteRemovalEnabled() {
+		// the connection is closed by the connection termination
+		h.unpairRemoteSKI(ski)
+	}
+
+	h.callbacks.notify(ski, "RemoteSKIPairingRemoved", func() {
+		h.hubReader.RemoteSKIPairingRemoved(ski)
+	})
 }
 
 // check if the user is still able to trust the connection
@@ -62,7 +113,15 @@ func (h *Hub) AllowWaitingForTrust(ski string) bool {
 		}
 	}
 
-	return h.hubReader.AllowWaitingForTrust(ski)
+	// the handshake can't wait for trust, if the application doesn't answer in time
+	var allow bool
+	if !h.callbacks.call(ski, "AllowWaitingForTrust", func() {
+		allow = h.hubReader.AllowWaitingForTrust(ski)
+	}) {
+		return false
+	}
+
+	return allow
 }
 
 // report the updated SHIP handshake state and optional error message for a SKI
@@ -71,13 +130,14 @@ func (h *Hub) HandleShipHandshakeStateUpdate(ski string, state model.ShipState)
 	if state.State == model.SmeHelloStateOk {
 		service := h.ServiceForSKI(ski)
 		service.SetTrusted(true)
-	}
 
-	pairingState := h.mapShipMessageExchangeState(state.State, ski)
-	if state.Error != nil && !errors.Is(state.Error, api.ErrConnectionNotFound) {
-		pairingState = api.ConnectionStateError
+		// the service is now paired, a pre-authorization is no longer needed
+		h.RemovePreAuthorizedRemoteService(ski)
+		h.pairingCompleted(ski)
 	}
 
+	// the error describes why the state was entered, e.g. the remote service aborted the handshake
+	pairingState := h.mapShipMessageExchangeState(state.State, ski)
 	pairingDetail := api.NewConnectionStateDetail(pairingState, state.Error)
 
 	service := h.ServiceForSKI(ski)
@@ -90,14 +150,34 @@ func (h *Hub) HandleShipHandshakeStateUpdate(ski string, state model.ShipState)
 		// always send a delayed update, as the processing of the new state has to be done
 		// and the SHIP message has to be received by the other service before
 		// acting upon the new state is safe
-		go func() {
-			<-time.After(time.Millisecond * 500)
-			h.hubReader.ServicePairingDetailUpdate(ski, pairingDetail)
-		}()
+		h.getClock().AfterFunc(time.Millisecond*500, func() {
+			h.reportServicePairingDetail(ski, pairingDetail)
+		})
 	}
 }
 
 // report an approved handshake by a remote device
-func (h *Hub) SetupRemoteDevice(ski string, writeI api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface {
-	return h.hubReader.SetupRemoteDevice(ski, writeI)
+func (h *Hub) SetupRemoteDevice(ski string, writeI api.ShipConnectionDataWriterInterface) (api.ShipConnectionDataReaderInterface, error) {
+	var dataReader api.ShipConnectionDataReaderInterface
+	if !h.callbacks.call(ski, "SetupRemoteDevice", func() {
+		dataReader = h.hubReader.SetupRemoteDevice(ski, writeI)
+	}) {
+		return nil, errors.New("remote device setup failed")
+	}
+
+	if dataReader == nil {
+		return nil, errors.New("remote device setup returned no data reader")
+	}
+
+	return dataReader, nil
+}
+
+// report the pairing detail of a SKI to the application
+func (h *Hub) reportServicePairingDetail(ski string, detail *api.ConnectionStateDetail) {
+	// the detail of a service is changed in place, so report the current values
+	snapshot := api.NewConnectionStateDetail(detail.State(), detail.Error())
+
+	h.callbacks.notify(ski, "ServicePairingDetailUpdate", func() {
+		h.hubReader.ServicePairingDetailUpdate(ski, snapshot)
+	})
 }
@@ -10,6 +10,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"errors"
+	"fmt"
 	"math/big"
 	"net"
 	"net/http"
@@ -21,8 +22,10 @@ import (
 
 	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/cert"
+	"github.com/enbility/ship-go/clock/clocktest"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/ws"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -79,8 +82,8 @@ func (s *HubSuite) BeforeTest(suiteName, testName string) {
 
 	s.hubReader = mocks.NewMockHubReaderInterface(ctrl)
 	// s.serviceProvider = mocks.NewServiceProvider(s.T())
-	s.hubReader.EXPECT().RemoteSKIConnected(gomock.Any()).Return().AnyTimes()
-	s.hubReader.EXPECT().RemoteSKIDisconnected(gomock.Any()).Return().AnyTimes()
+	s.hubReader.EXPECT().RemoteSKIConnected(gomock.Any(), gomock.Any()).Return().AnyTimes()
+	s.hubReader.EXPECT().RemoteSKIDisconnected(gomock.Any(), gomock.Any(), gomock.Any()).Return().AnyTimes()
 	s.hubReader.EXPECT().ServiceShipIDUpdate(gomock.Any(), gomock.Any()).Return().AnyTimes()
 	s.hubReader.EXPECT().ServicePairingDetailUpdate(gomock.Any(), gomock.Any()).Return().AnyTimes()
 	s.hubReader.EXPECT().AllowWaitingForTrust(gomock.Any()).Return(false).AnyTimes()
@@ -89,6 +92,7 @@ func (s *HubSuite) BeforeTest(suiteName, testName string) {
 	s.mdnsService.EXPECT().AnnounceMdnsEntry().Return(nil).AnyTimes()
 	s.mdnsService.EXPECT().UnannounceMdnsEntry().Return().AnyTimes()
 	s.mdnsService.EXPECT().RequestMdnsEntries().Return().AnyTimes()
+	s.mdnsService.EXPECT().SetWebsocketEndpoint(gomock.Any(), gomock.Any()).Return().AnyTimes()
 
 	s.wsDataWriter = mocks.NewWebsocketDataWriterInterface(s.T())
 
@@ -99,6 +103,7 @@ func (s *HubSuite) BeforeTest(suiteName, testName string) {
 	s.shipConnection.EXPECT().AbortPendingHandshake().Return().Maybe()
 	s.shipConnection.EXPECT().DataHandler().Return(s.wsDataWriter).Maybe()
 	s.shipConnection.EXPECT().ShipHandshakeState().Return(model.SmeStateComplete, nil).Maybe()
+	s.shipConnection.EXPECT().StateHistory().Return(api.ShipConnectionHistory{RemoteSKI: s.remoteSki}).Maybe()
 
 	localService := api.NewServiceDetails("localSKI")
 
@@ -109,6 +114,8 @@ func (s *HubSuite) BeforeTest(suiteName, testName string) {
 func (s *HubSuite) AfterTest(suiteName, testName string) {
 	s.mdnsService.EXPECT().Shutdown().AnyTimes()
 
+	// deliver the pending callbacks before the mocks are verified
+	s.sut.callbacks.wait()
 	s.sut.Shutdown()
 }
 
@@ -128,6 +135,322 @@ func (s *HubSuite) Test_NewConnectionsHub() {
 	hub.Shutdown()
 }
 
+func (s *HubSuite) Test_WebsocketWriteQueueSize() {
+	assert.Equal(s.T(), ws.DefaultWriteQueueSize, s.sut.websocketWriteQueueSize())
+
+	s.sut.SetWebsocketWriteQueueSize(10)
+	assert.Equal(s.T(), 10, s.sut.websocketWriteQueueSize())
+}
+
+func (s *HubSuite) Test_WebsocketListener() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(s.T(), err)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	ctrl := gomock.NewController(s.T())
+	mdnsService := mocks.NewMockMdnsInterface(ctrl)
+	mdnsService.EXPECT().SetWebsocketEndpoint(port, "/eebus/").Times(1)
+	mdnsService.EXPECT().Start(gomock.Any()).Return(nil).Times(1)
+	mdnsService.EXPECT().Shutdown().Times(1)
+
+	hub := NewHub(s.hubReader, mdnsService, 4567, tls.Certificate{}, api.NewServiceDetails("localSKI"))
+	hub.SetWebsocketListener(listener)
+	hub.SetWebsocketPath("/eebus/")
+	hub.Start()
+	assert.NotNil(s.T(), hub.httpServer)
+	assert.Equal(s.T(), port, hub.port)
+
+	hub.Shutdown()
+
+	// the listener is closed on shutdown
+	_, err = net.Dial("tcp", listener.Addr().String())
+	assert.NotNil(s.T(), err)
+}
+
+func (s *HubSuite) Test_WebsocketServer_Port0() {
+	var announcedPort int
+
+	ctrl := gomock.NewController(s.T())
+	mdnsService := mocks.NewMockMdnsInterface(ctrl)
+	mdnsService.EXPECT().SetWebsocketEndpoint(gomock.Any(), "/ship/").Do(func(port int, path string) {
+		announcedPort = port
+	}).Times(1)
+	mdnsService.EXPECT().Start(gomock.Any()).Return(nil).Times(1)
+	mdnsService.EXPECT().Shutdown().Times(1)
+
+	hub := NewHub(s.hubReader, mdnsService, 0, tls.Certificate{}, api.NewServiceDetails("localSKI"))
+	hub.Start()
+	assert.NotEqual(s.T(), 0, announcedPort)
+	assert.Equal(s.T(), announcedPort, hub.port)
+
+	hub.Shutdown()
+}
+
+func (s *HubSuite) Test_ExternalWebsocketServer() {
+	ctrl := gomock.NewController(s.T())
+	mdnsService := mocks.NewMockMdnsInterface(ctrl)
+	mdnsService.EXPECT().SetWebsocketEndpoint(443, "/ship/").Times(1)
+	mdnsService.EXPECT().Start(gomock.Any()).Return(nil).Times(1)
+	mdnsService.EXPECT().Shutdown().Times(1)
+
+	hub := NewHub(s.hubReader, mdnsService, 4567, tls.Certificate{}, api.NewServiceDetails("localSKI"))
+	hub.SetExternalWebsocketServer(443)
+	hub.Start()
+	assert.Nil(s.T(), hub.httpServer)
+
+	config := hub.TLSConfig()
+	assert.Equal(s.T(), tls.RequireAnyClientCert, config.ClientAuth)
+	assert.NotNil(s.T(), config.VerifyPeerCertificate)
+
+	hub.Shutdown()
+}
+
+func (s *HubSuite) Test_SpineBufferConfig() {
+	config := api.SpineBufferConfig{
+		Policy:      api.SpineBufferPolicyReject,
+		MaxMessages: 10,
+	}
+	s.sut.SetSpineBufferConfig(config)
+	assert.Equal(s.T(), config, s.sut.getSpineBufferConfig())
+}
+
+func (s *HubSuite) Test_ConnectionLimits() {
+	limits := api.ConnectionLimits{
+		MaxPendingHandshakes: 2,
+		MaxConnectionsPerIP:  1,
+		MaxAttemptsPerSKI:    2,
+		AttemptWindow:        time.Minute,
+		BanDuration:          time.Minute,
+	}
+	s.sut.SetConnectionLimits(limits)
+	assert.Equal(s.T(), limits, s.sut.connectionLimits())
+
+	conn1, err := s.sut.reserveIncomingConnection("192.168.1.1", "ski1")
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), conn1)
+
+	// per IP limit
+	_, err = s.sut.reserveIncomingConnection("192.168.1.1", "ski1")
+	assert.NotNil(s.T(), err)
+
+	conn2, err := s.sut.reserveIncomingConnection("192.168.1.2", "ski2")
+	assert.Nil(s.T(), err)
+
+	// pending handshakes limit
+	_, err = s.sut.reserveIncomingConnection("192.168.1.3", "ski3")
+	assert.NotNil(s.T(), err)
+
+	s.sut.trackIncomingConnection(s.wsDataWriter, conn2)
+	s.sut.incomingHandshakeCompleted(s.wsDataWriter)
+	assert.Equal(s.T(), 1, s.sut.pendingHandshakes)
+
+	s.sut.releaseIncomingConnection(conn1)
+	assert.Equal(s.T(), 0, s.sut.pendingHandshakes)
+	assert.Equal(s.T(), 1, len(s.sut.connectionsPerIP))
+
+	s.sut.untrackIncomingConnection(s.wsDataWriter)
+	assert.Equal(s.T(), 0, len(s.sut.connectionsPerIP))
+	assert.Equal(s.T(), 0, len(s.sut.incomingConnections))
+
+	// rejected attempts because of other limits are not counted
+	conn, err := s.sut.reserveIncomingConnection("192.168.1.1", "ski1")
+	assert.Nil(s.T(), err)
+	s.sut.releaseIncomingConnection(conn)
+
+	// the third attempt of ski1 within the window results in a ban
+	_, err = s.sut.reserveIncomingConnection("192.168.1.1", "ski1")
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 1, len(s.sut.skiBans))
+
+	_, err = s.sut.reserveIncomingConnection("192.168.1.4", "ski1")
+	assert.NotNil(s.T(), err)
+
+	// the ban expired
+	s.sut.skiBans["ski1"] = time.Now().Add(-time.Second)
+	conn, err = s.sut.reserveIncomingConnection("192.168.1.1", "ski1")
+	assert.Nil(s.T(), err)
+	s.sut.releaseIncomingConnection(conn)
+
+	assert.Equal(s.T(), "192.168.1.1", remoteIPFromAddr("192.168.1.1:1234"))
+	assert.Equal(s.T(), "fe80::1", remoteIPFromAddr("[fe80::1]:1234"))
+	assert.Equal(s.T(), "invalid", remoteIPFromAddr("invalid"))
+}
+
+func (s *HubSuite) Test_HandshakeCompleted_ReleasesPendingSlot() {
+	completing, err := s.sut.reserveIncomingConnection("192.168.1.1", s.remoteSki)
+	assert.Nil(s.T(), err)
+	completingWriter := mocks.NewWebsocketDataWriterInterface(s.T())
+	s.sut.trackIncomingConnection(completingWriter, completing)
+
+	other, err := s.sut.reserveIncomingConnection("192.168.1.2", s.remoteSki)
+	assert.Nil(s.T(), err)
+	s.sut.trackIncomingConnection(s.wsDataWriter, other)
+	assert.Equal(s.T(), 2, s.sut.pendingHandshakes)
+
+	// the other connection of the SKI is the registered one
+	s.sut.registerConnection(s.shipConnection)
+
+	connection := mocks.NewShipConnectionInterface(s.T())
+	connection.EXPECT().RemoteSKI().Return(s.remoteSki).Maybe()
+	connection.EXPECT().DataHandler().Return(completingWriter).Maybe()
+
+	s.sut.HandleShipHandshakeCompleted(connection)
+	assert.Equal(s.T(), 1, s.sut.pendingHandshakes)
+	assert.False(s.T(), completing.pending)
+	assert.True(s.T(), other.pending)
+
+	// remove the connection, so the test doesn't try to close it
+	delete(s.sut.connections, s.remoteSki)
+}
+
+func (s *HubSuite) Test_SkiAttemptLimit_Bounded() {
+	clock := clocktest.NewFakeClock(time.Now())
+	s.sut.SetClock(clock)
+
+	limits := api.ConnectionLimits{
+		MaxAttemptsPerSKI: 1,
+		AttemptWindow:     time.Minute,
+		BanDuration:       time.Hour,
+	}
+
+	// SKIs of random certificates don't grow the tracked attempts and bans without limit
+	for i := 0; i < maxTrackedSKIs+10; i++ {
+		ski := fmt.Sprintf("ski%d", i)
+		assert.Nil(s.T(), s.sut.checkSkiAttemptLimit(ski, limits))
+		assert.NotNil(s.T(), s.sut.checkSkiAttemptLimit(ski, limits))
+		assert.LessOrEqual(s.T(), len(s.sut.skiAttempts), maxTrackedSKIs)
+		assert.LessOrEqual(s.T(), len(s.sut.skiBans), maxTrackedSKIs)
+	}
+	assert.Equal(s.T(), maxTrackedSKIs, len(s.sut.skiBans))
+
+	// the attempts outside the window and the expired bans are removed
+	assert.Nil(s.T(), s.sut.checkSkiAttemptLimit("other", limits))
+	clock.Advance(time.Hour)
+	assert.Nil(s.T(), s.sut.checkSkiAttemptLimit("new", limits))
+	assert.Equal(s.T(), 1, len(s.sut.skiAttempts))
+	assert.Equal(s.T(), 0, len(s.sut.skiBans))
+}
+
+func (s *HubSuite) Test_ServeHTTP_Limits() {
+	s.sut.SetConnectionLimits(api.ConnectionLimits{
+		MaxConnectionsPerIP: 1,
+	})
+	s.sut.connectionsPerIP["192.0.2.1"] = 1
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	w := httptest.NewRecorder()
+	s.sut.ServeHTTP(w, req)
+	assert.Equal(s.T(), http.StatusTooManyRequests, w.Code)
+}
+
+func (s *HubSuite) Test_PairingPolicy() {
+	entry := &api.MdnsEntry{
+		Ski:        "abcd",
+		Categories: []api.DeviceCategoryType{api.DeviceCategoryTypeEMobility},
+	}
+	s.sut.knownMdnsEntries = []*api.MdnsEntry{entry}
+
+	assert.Equal(s.T(), api.PairingDecisionDefault, s.sut.PairingDecisionForSKI("abcd"))
+
+	s.sut.SetPairingPolicy(NewCategoryPairingPolicy(api.DeviceCategoryTypeEMobility))
+	assert.Equal(s.T(), api.PairingDecisionAccept, s.sut.PairingDecisionForSKI("abcd"))
+	assert.Equal(s.T(), api.PairingDecisionDefault, s.sut.PairingDecisionForSKI("ef"))
+
+	s.sut.SetPairingPolicy(nil)
+	assert.Equal(s.T(), api.PairingDecisionDefault, s.sut.PairingDecisionForSKI("abcd"))
+}
+
+func (s *HubSuite) Test_PairingPolicy_CommissioningWindow() {
+	clock := clocktest.NewFakeClock(time.Now())
+	s.sut.SetClock(clock)
+
+	window := NewCommissioningWindowPairingPolicy(nil)
+	s.sut.SetPairingPolicy(window)
+	window.Open(time.Minute, 1)
+
+	// checking the decision doesn't use up the window
+	assert.Equal(s.T(), api.PairingDecisionAccept, s.sut.PairingDecisionForSKI(s.remoteSki))
+	assert.True(s.T(), window.IsOpen())
+
+	// a pairing is counted once the handshake reached the trusted hello state
+	s.sut.HandleShipHandshakeStateUpdate(s.remoteSki, model.ShipState{State: model.SmeHelloStateOk})
+	assert.False(s.T(), window.IsOpen())
+	assert.Equal(s.T(), api.PairingDecisionAccept, s.sut.PairingDecisionForSKI(s.remoteSki))
+
+	// the window uses the clock of the hub
+	clock.Advance(time.Minute)
+	assert.Equal(s.T(), api.PairingDecisionDefault, s.sut.PairingDecisionForSKI(s.remoteSki))
+}
+
+func (s *HubSuite) Test_ServeHTTP_PairingPolicyDeny() {
+	validCert, _ := cert.CreateCertificate("unit", "org", "DE", "CN")
+	leaf, _ := x509.ParseCertificate(validCert.Certificate[0])
+	ski, _ := cert.SkiFromCertificate(leaf)
+
+	s.sut.SetPairingPolicy(NewSkiDenyListPairingPolicy(ski))
+
+	req := httptest.NewRequest("GET", "https://example.com/foo", nil)
+	req.TLS.PeerCertificates = []*x509.Certificate{leaf}
+	w := httptest.NewRecorder()
+	s.sut.ServeHTTP(w, req)
+	assert.Equal(s.T(), http.StatusForbidden, w.Code)
+}
+
+func (s *HubSuite) Test_ReportMdnsEntries_PairingPolicy() {
+	testski1 := "test1"
+	testski2 := "test2"
+
+	s.sut.SetPairingPolicy(NewPairingPolicyChain(
+		NewSkiDenyListPairingPolicy(testski1),
+		NewSkiAllowListPairingPolicy(testski2),
+	))
+
+	s.hubReader.EXPECT().VisibleRemoteServicesUpdated(gomock.Any()).AnyTimes()
+	s.hubReader.EXPECT().ServicePairingDetailUpdate(testski2, gomock.Any()).AnyTimes()
+
+	entries := map[string]*api.MdnsEntry{
+		testski1: {Ski: testski1},
+		testski2: {Ski: testski2},
+	}
+	service1 := s.sut.ServiceForSKI(testski1)
+	service1.SetTrusted(true)
+
+	s.sut.ReportMdnsEntries(entries, true)
+
+	assert.Equal(s.T(), api.ConnectionStateNone, service1.ConnectionStateDetail().State())
+	service2 := s.sut.ServiceForSKI(testski2)
+	assert.Equal(s.T(), api.ConnectionStateQueued, service2.ConnectionStateDetail().State())
+}
+
+func (s *HubSuite) Test_PreAuthorizeRemoteService() {
+	qr, err := api.ParseQRCodeText("SHIP;SKI:0123456789ABCDEF0123456789ABCDEF01234567;ID:shipid;ENDSHIP;")
+	assert.Nil(s.T(), err)
+
+	s.sut.PreAuthorizeRemoteService(qr.Ski, qr.Identifier)
+	assert.Equal(s.T(), "shipid", s.sut.ServiceForSKI(qr.Ski).ShipID())
+	assert.Equal(s.T(), api.PairingDecisionAccept, s.sut.PairingDecisionForSKI(qr.Ski))
+
+	s.hubReader.EXPECT().VisibleRemoteServicesUpdated(gomock.Any()).AnyTimes()
+	s.hubReader.EXPECT().ServicePairingDetailUpdate(qr.Ski, gomock.Any()).AnyTimes()
+
+	// the ID of the mDNS entry does not match
+	entries := map[string]*api.MdnsEntry{
+		qr.Ski: {Ski: qr.Ski, Identifier: "otherid"},
+	}
+	s.sut.ReportMdnsEntries(entries, true)
+	service := s.sut.ServiceForSKI(qr.Ski)
+	assert.Equal(s.T(), api.ConnectionStateNone, service.ConnectionStateDetail().State())
+	assert.Equal(s.T(), api.PairingDecisionDefault, s.sut.PairingDecisionForSKI(qr.Ski))
+
+	entries[qr.Ski].Identifier = qr.Identifier
+	s.sut.ReportMdnsEntries(entries, true)
+	assert.Equal(s.T(), api.ConnectionStateQueued, service.ConnectionStateDetail().State())
+	assert.Equal(s.T(), api.PairingDecisionAccept, s.sut.PairingDecisionForSKI(qr.Ski))
+
+	s.sut.RemovePreAuthorizedRemoteService(qr.Ski)
+	assert.Equal(s.T(), api.PairingDecisionDefault, s.sut.PairingDecisionForSKI(qr.Ski))
+}
+
 func (s *HubSuite) Test_AutoAccept() {
 	s.mdnsService.EXPECT().SetAutoAccept(gomock.Any()).Return().AnyTimes()
 
@@ -150,9 +473,42 @@ func (s *HubSuite) Test_SetupRemoteDevice() {
 	readerI := mocks.NewShipConnectionDataReaderInterface(s.T())
 	s.hubReader.EXPECT().SetupRemoteDevice(gomock.Any(), gomock.Any()).Return(readerI)
 
-	reader := hub.SetupRemoteDevice(ski, nil)
-
+	reader, err := hub.SetupRemoteDevice(ski, nil)
+	assert.Nil(s.T(), err)
 	assert.NotNil(s.T(), reader)
+
+	// an application without a data reader for the remote device fails the setup
+	s.hubReader.EXPECT().SetupRemoteDevice(gomock.Any(), gomock.Any()).Return(nil)
+
+	reader, err = hub.SetupRemoteDevice(ski, nil)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), reader)
+}
+
+func (s *HubSuite) Test_SetupRemoteDevice_Timeout() {
+	clock := clocktest.NewFakeClock(time.Now())
+	s.sut.SetClock(clock)
+
+	release := make(chan struct{})
+	readerI := mocks.NewShipConnectionDataReaderInterface(s.T())
+	s.hubReader.EXPECT().SetupRemoteDevice(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ski string, writeI api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface {
+			<-release
+			return readerI
+		})
+
+	// the timeout of the caller, the warning and the timeout of the delivery
+	go func() {
+		clock.BlockUntil(3)
+		clock.Advance(api.DefaultCallbackTimeout)
+	}()
+
+	// the blocking application callback is no longer waited for
+	reader, err := s.sut.SetupRemoteDevice(s.remoteSki, nil)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), reader)
+
+	close(release)
 }
 
 func (s *HubSuite) Test_SendWSCloseMessage() {
@@ -216,6 +572,54 @@ func (s *HubSuite) Test_IsRemoteSKIPaired() {
 	hub.Shutdown()
 }
 
+func (s *HubSuite) Test_UnregisterRemoteSKI_AnnouncesRemoval() {
+	s.sut.RegisterRemoteSKI(s.remoteSki)
+
+	connection := mocks.NewShipConnectionInterface(s.T())
+	connection.EXPECT().RemoteSKI().Return(s.remoteSki).Maybe()
+	connection.EXPECT().CloseConnectionPairingRemoved().Return().Once()
+	s.sut.registerConnection(connection)
+
+	s.sut.UnregisterRemoteSKI(s.remoteSki)
+	assert.Equal(s.T(), false, s.sut.IsRemoteServiceForSKIPaired(s.remoteSki))
+
+	// remove the connection, so the test doesn't try to close it
+	delete(s.sut.connections, s.remoteSki)
+}
+
+func (s *HubSuite) Test_HandleRemotePairingRemoved() {
+	s.hubReader.EXPECT().RemoteSKIPairingRemoved(s.remoteSki).Times(1)
+
+	s.sut.RegisterRemoteSKI(s.remoteSki)
+	s.sut.HandleRemotePairingRemoved(s.remoteSki)
+
+	// the pairing is kept, but no connections are initiated anymore
+	assert.Equal(s.T(), true, s.sut.IsRemoteServiceForSKIPaired(s.remoteSki))
+	assert.Equal(s.T(), true, s.sut.isRemotePairingRemoved(s.remoteSki))
+
+	s.hubReader.EXPECT().VisibleRemoteServicesUpdated(gomock.Any()).Times(1)
+	entries := map[string]*api.MdnsEntry{
+		s.remoteSki: {Ski: s.remoteSki},
+	}
+	s.sut.ReportMdnsEntries(entries, true)
+	assert.Equal(s.T(), false, s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	// pairing the SKI again allows connections again
+	s.sut.RegisterRemoteSKI(s.remoteSki)
+	assert.Equal(s.T(), false, s.sut.isRemotePairingRemoved(s.remoteSki))
+}
+
+func (s *HubSuite) Test_HandleRemotePairingRemoved_Unpair() {
+	s.hubReader.EXPECT().RemoteSKIPairingRemoved(s.remoteSki).Times(1)
+
+	s.sut.SetUnpairOnRemoteRemoval(true)
+	s.sut.RegisterRemoteSKI(s.remoteSki)
+	s.sut.HandleRemotePairingRemoved(s.remoteSki)
+
+	assert.Equal(s.T(), false, s.sut.IsRemoteServiceForSKIPaired(s.remoteSki))
+	assert.Equal(s.T(), api.ConnectionStateNone, s.sut.ServiceForSKI(s.remoteSki).ConnectionStateDetail().State())
+}
+
 func (s *HubSuite) Test_RegisterRemoteSKI_AfterStart() {
 	s.sut.hasStarted = true
 
@@ -237,6 +641,101 @@ func (s *HubSuite) Test_HandleConnectionClosed() {
 	assert.Equal(s.T(), 0, len(s.sut.connections))
 }
 
+func (s *HubSuite) Test_ConnectionGenerations() {
+	ctrl := gomock.NewController(s.T())
+	hubReader := mocks.NewMockHubReaderInterface(ctrl)
+	hubReader.EXPECT().ServicePairingDetailUpdate(gomock.Any(), gomock.Any()).Return().AnyTimes()
+
+	sut := NewHub(hubReader, s.mdnsService, 4567, tls.Certificate{}, api.NewServiceDetails("localSKI"))
+
+	closeDetails := api.ShipCloseDetails{
+		Remote: true,
+		Code:   api.ShipCloseCodeNormal,
+	}
+
+	oldConnection := mocks.NewShipConnectionInterface(s.T())
+	oldConnection.EXPECT().RemoteSKI().Return(s.remoteSki).Maybe()
+	oldConnection.EXPECT().DataHandler().Return(mocks.NewWebsocketDataWriterInterface(s.T())).Maybe()
+	oldConnection.EXPECT().StateHistory().Return(api.ShipConnectionHistory{RemoteSKI: s.remoteSki}).Maybe()
+	oldConnection.EXPECT().CloseDetails().Return(closeDetails).Once()
+
+	newConnection := mocks.NewShipConnectionInterface(s.T())
+	newConnection.EXPECT().RemoteSKI().Return(s.remoteSki).Maybe()
+	newConnection.EXPECT().DataHandler().Return(mocks.NewWebsocketDataWriterInterface(s.T())).Maybe()
+	newConnection.EXPECT().StateHistory().Return(api.ShipConnectionHistory{RemoteSKI: s.remoteSki}).Maybe()
+	newConnection.EXPECT().ConnectionInfo().Return(api.ShipConnectionInfo{RemoteSKI: s.remoteSki}).Once()
+
+	// a connection without a completed handshake is never reported
+	pendingConnection := mocks.NewShipConnectionInterface(s.T())
+	pendingConnection.EXPECT().RemoteSKI().Return(s.remoteSki).Maybe()
+	pendingConnection.EXPECT().DataHandler().Return(mocks.NewWebsocketDataWriterInterface(s.T())).Maybe()
+	pendingConnection.EXPECT().StateHistory().Return(api.ShipConnectionHistory{RemoteSKI: s.remoteSki}).Maybe()
+
+	gomock.InOrder(
+		hubReader.EXPECT().RemoteSKIConnected(s.remoteSki, uint64(1)).Times(1),
+		hubReader.EXPECT().RemoteSKIConnected(s.remoteSki, uint64(2)).Times(1),
+		hubReader.EXPECT().RemoteSKIDisconnected(s.remoteSki, uint64(1), closeDetails).Times(1),
+	)
+
+	sut.registerConnection(oldConnection)
+	sut.HandleShipHandshakeCompleted(oldConnection)
+
+	// the new connection replaces the old one, which is closed afterwards
+	sut.registerConnection(newConnection)
+	sut.HandleShipHandshakeCompleted(newConnection)
+
+	sut.HandleConnectionClosed(oldConnection, true)
+	sut.HandleConnectionClosed(oldConnection, true)
+	sut.HandleConnectionClosed(pendingConnection, false)
+	sut.callbacks.wait()
+
+	connections := sut.Connections()
+	if assert.Equal(s.T(), 1, len(connections)) {
+		assert.Equal(s.T(), uint64(2), connections[0].Generation)
+	}
+}
+
+func (s *HubSuite) Test_ConnectionHistoryForSKI() {
+	assert.Equal(s.T(), 0, len(s.sut.ConnectionHistoryForSKI(s.remoteSki)))
+
+	s.sut.HandleConnectionClosed(s.shipConnection, false)
+	assert.Equal(s.T(), 1, len(s.sut.ConnectionHistoryForSKI(s.remoteSki)))
+
+	// only the histories of the last connections are kept
+	start := time.Now()
+	for i := 0; i < connectionHistorySize+2; i++ {
+		s.sut.recordConnectionHistory(api.ShipConnectionHistory{
+			RemoteSKI:      s.remoteSki,
+			ConnectedSince: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	histories := s.sut.ConnectionHistoryForSKI(strings.ToUpper(s.remoteSki))
+	if assert.Equal(s.T(), connectionHistorySize, len(histories)) {
+		assert.Equal(s.T(), start.Add(2*time.Second), histories[0].ConnectedSince)
+		assert.Equal(s.T(), start.Add((connectionHistorySize+1)*time.Second), histories[connectionHistorySize-1].ConnectedSince)
+	}
+}
+
+func (s *HubSuite) Test_ConnectionHistory_BoundedSKIs() {
+	// remote services using random certificates don't grow the histories without limit
+	for i := 0; i < connectionHistorySKIs+10; i++ {
+		s.sut.recordConnectionHistory(api.ShipConnectionHistory{RemoteSKI: fmt.Sprintf("ski%d", i)})
+	}
+	assert.Equal(s.T(), connectionHistorySKIs, len(s.sut.connectionHistories))
+	assert.Equal(s.T(), connectionHistorySKIs, len(s.sut.connectionHistorySKIs))
+
+	// the SKIs with the least recently closed connections are dropped
+	assert.Equal(s.T(), 0, len(s.sut.ConnectionHistoryForSKI("ski0")))
+	assert.Equal(s.T(), 1, len(s.sut.ConnectionHistoryForSKI("ski10")))
+
+	s.sut.recordConnectionHistory(api.ShipConnectionHistory{RemoteSKI: "ski10"})
+	s.sut.recordConnectionHistory(api.ShipConnectionHistory{RemoteSKI: "new"})
+	assert.Equal(s.T(), 2, len(s.sut.ConnectionHistoryForSKI("ski10")))
+	assert.Equal(s.T(), 0, len(s.sut.ConnectionHistoryForSKI("ski11")))
+	assert.Equal(s.T(), connectionHistorySKIs, len(s.sut.connectionHistories))
+}
+
 func (s *HubSuite) Test_Mdns() {
 	s.sut.checkAutoReannounce()
 
@@ -329,6 +828,35 @@ func (s *HubSuite) Test_RegisterConnection() {
 	assert.NotNil(s.T(), con)
 }
 
+func (s *HubSuite) Test_Connections() {
+	assert.Equal(s.T(), 0, len(s.sut.Connections()))
+
+	info := api.ShipConnectionInfo{
+		RemoteSKI: s.remoteSki,
+		Role:      api.ShipRoleClient,
+	}
+	s.shipConnection.EXPECT().ConnectionInfo().Return(info).Once()
+
+	s.sut.registerConnection(s.shipConnection)
+	connections := s.sut.Connections()
+	assert.Equal(s.T(), []api.ShipConnectionInfo{info}, connections)
+}
+
+func (s *HubSuite) Test_KnownServices() {
+	assert.Equal(s.T(), 0, len(s.sut.KnownServices()))
+
+	s.sut.RegisterRemoteSKI("bski")
+	s.sut.ServiceForSKI("aski")
+
+	services := s.sut.KnownServices()
+	if assert.Equal(s.T(), 2, len(services)) {
+		assert.Equal(s.T(), "aski", services[0].SKI())
+		assert.False(s.T(), services[0].Trusted())
+		assert.Equal(s.T(), "bski", services[1].SKI())
+		assert.True(s.T(), services[1].Trusted())
+	}
+}
+
 func (s *HubSuite) Test_VerifyPeerCertificate() {
 	testCert, _ := cert.CreateCertificate("unit", "org", "DE", "CN")
 	var rawCerts [][]byte
@@ -346,7 +874,19 @@ func (s *HubSuite) Test_VerifyPeerCertificate() {
 	rawCerts = append(rawCerts, invalidCert.Certificate...)
 
 	err = s.sut.verifyPeerCertificate(rawCerts, nil)
-	assert.NotNil(s.T(), err)
+	assert.ErrorIs(s.T(), err, api.ErrCertificateVerification)
+}
+
+func (s *HubSuite) Test_CheckCertificateVerificationError() {
+	service := s.sut.ServiceForSKI(s.remoteSki)
+
+	s.sut.checkCertificateVerificationError(service, errors.New("connection refused"))
+	assert.Equal(s.T(), api.ConnectionStateNone, service.ConnectionStateDetail().State())
+
+	err := &api.CertificateVerificationError{Ski: s.remoteSki, Err: errors.New("SKI does not match")}
+	s.sut.checkCertificateVerificationError(service, err)
+	assert.Equal(s.T(), api.ConnectionStateError, service.ConnectionStateDetail().State())
+	assert.ErrorIs(s.T(), service.ConnectionStateDetail().Error(), api.ErrCertificateVerification)
 }
 
 func (s *HubSuite) Test_ServeHTTP_01() {
@@ -427,6 +967,56 @@ func (s *HubSuite) Test_ServeHTTP_02() {
 	time.Sleep(time.Second)
 }
 
+func (s *HubSuite) Test_ServeHTTP_ClosedConnectionReleasesLimits() {
+	s.sut.SetConnectionLimits(api.ConnectionLimits{
+		MaxPendingHandshakes: 1,
+		MaxConnectionsPerIP:  1,
+	})
+
+	server := httptest.NewUnstartedServer(s.sut)
+	server.TLS = &tls.Config{
+		Certificates:       []tls.Certificate{s.sut.certifciate},
+		ClientAuth:         tls.RequireAnyClientCert,
+		CipherSuites:       cert.CipherSuites, // #nosec G402
+		InsecureSkipVerify: true,              // #nosec G402
+	}
+	server.StartTLS()
+	defer server.Close()
+	wsURL := strings.Replace(server.URL, "https://", "wss://", -1)
+
+	validCert, _ := cert.CreateCertificate("unit", "org", "DE", "CN")
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: 5 * time.Second,
+		TLSClientConfig: &tls.Config{
+			Certificates:       []tls.Certificate{validCert},
+			InsecureSkipVerify: true,              // #nosec G402
+			CipherSuites:       cert.CipherSuites, // #nosec G402
+		},
+		Subprotocols: []string{api.ShipWebsocketSubProtocol},
+	}
+
+	limitsReleased := func() bool {
+		s.sut.muxLimits.Lock()
+		defer s.sut.muxLimits.Unlock()
+
+		return s.sut.pendingHandshakes == 0 &&
+			len(s.sut.connectionsPerIP) == 0 &&
+			len(s.sut.incomingConnections) == 0
+	}
+
+	// connections closed right after the upgrade don't keep their slots
+	for i := 0; i < 3; i++ {
+		con, resp, err := dialer.Dial(wsURL, nil)
+		if !assert.Nil(s.T(), err) {
+			return
+		}
+		resp.Body.Close()
+		_ = con.Close()
+
+		assert.Eventually(s.T(), limitsReleased, 5*time.Second, 10*time.Millisecond)
+	}
+}
+
 func (s *HubSuite) Test_ConnectFoundService_01() {
 	service := s.sut.ServiceForSKI(s.remoteSki)
 
@@ -510,6 +1100,31 @@ func (s *HubSuite) Test_KeepThisConnection() {
 	assert.Equal(s.T(), true, result)
 }
 
+func (s *HubSuite) Test_CoordinateConnectionInitations_Delay() {
+	clock := clocktest.NewFakeClock(time.Now())
+	s.sut.SetClock(clock)
+
+	entry := &api.MdnsEntry{
+		Ski:  s.remoteSki,
+		Host: "somehost",
+	}
+
+	// the first attempt is delayed by up to 3 seconds
+	s.sut.coordinateConnectionInitations(s.remoteSki, entry)
+	clock.Advance(time.Second * 3)
+	assert.Equal(s.T(), false, s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	// the second attempt is delayed by 3 to 10 seconds
+	s.sut.coordinateConnectionInitations(s.remoteSki, entry)
+	assert.Equal(s.T(), true, s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	clock.Advance(time.Second*3 - time.Millisecond)
+	assert.Equal(s.T(), true, s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	clock.Advance(time.Second * 7)
+	assert.Equal(s.T(), false, s.sut.isConnectionAttemptRunning(s.remoteSki))
+}
+
 func (s *HubSuite) Test_prepareConnectionInitiation() {
 	entry := &api.MdnsEntry{
 		Ski:  s.remoteSki,
@@ -651,6 +1266,62 @@ func (s *HubSuite) Test_ReportMdnsEntries() {
 	s.sut.ReportMdnsEntries(entries, true)
 }
 
+func (s *HubSuite) Test_ReportMdnsEntryUpdate() {
+	s.sut.ReportMdnsEntryUpdate(api.MdnsEntryUpdate{})
+
+	previous := &api.MdnsEntry{Ski: s.remoteSki, Port: 4711}
+	current := &api.MdnsEntry{Ski: s.remoteSki, Port: 4711, Brand: "brand", Register: true}
+
+	s.sut.increaseConnectionAttemptCounter(s.remoteSki)
+	s.sut.setConnectionAttemptRunning(s.remoteSki, true)
+
+	// changes not affecting the endpoint keep the connection attempts
+	s.sut.ReportMdnsEntryUpdate(api.MdnsEntryUpdate{
+		Previous: previous,
+		Current:  current,
+		Changes:  api.MdnsEntryChangeBrand | api.MdnsEntryChangeRegister,
+	})
+	_, exists := s.sut.getCurrentConnectionAttemptCounter(s.remoteSki)
+	assert.True(s.T(), exists)
+	assert.True(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	// a changed port resets the connection attempts
+	current.Port = 4712
+	s.sut.ReportMdnsEntryUpdate(api.MdnsEntryUpdate{
+		Previous: previous,
+		Current:  current,
+		Changes:  api.MdnsEntryChangePort,
+	})
+	_, exists = s.sut.getCurrentConnectionAttemptCounter(s.remoteSki)
+	assert.False(s.T(), exists)
+	assert.False(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+}
+
+func (s *HubSuite) Test_ReportMdnsEntryUpdate_CancelsDelayedAttempt() {
+	clock := clocktest.NewFakeClock(time.Now())
+	s.sut.SetClock(clock)
+
+	previous := &api.MdnsEntry{Ski: s.remoteSki, Host: "somehost", Port: 4711}
+	current := &api.MdnsEntry{Ski: s.remoteSki, Host: "somehost", Port: 4712}
+
+	s.sut.coordinateConnectionInitations(s.remoteSki, previous)
+	assert.Equal(s.T(), 1, clock.Waiters())
+
+	// the delayed attempt for the previous endpoint is stopped,
+	// so only the attempt for the updated endpoint is initiated
+	s.sut.ReportMdnsEntryUpdate(api.MdnsEntryUpdate{
+		Previous: previous,
+		Current:  current,
+		Changes:  api.MdnsEntryChangePort,
+	})
+	assert.Equal(s.T(), 0, clock.Waiters())
+	assert.False(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+
+	s.sut.coordinateConnectionInitations(s.remoteSki, current)
+	assert.Equal(s.T(), 1, clock.Waiters())
+	assert.True(s.T(), s.sut.isConnectionAttemptRunning(s.remoteSki))
+}
+
 func createInvalidCertificate(organizationalUnit, organization, country, commonName string) (tls.Certificate, error) {
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
@@ -0,0 +1,293 @@
+package hub
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock"
+	"github.com/enbility/ship-go/util"
+)
+
+// a pairing policy or mDNS manager using the clock of the hub
+type clockSetter interface {
+	SetClock(clock api.ClockInterface)
+}
+
+// Pairing policy returning a fixed decision for a list of SKIs
+//
+// Can be used to accept preconfigured SKIs, e.g. scanned from QR codes,
+// or to deny blocked SKIs
+type SkiListPairingPolicy struct {
+	// the decision for all listed SKIs
+	decision api.PairingDecision
+
+	skis map[string]bool
+
+	mux sync.Mutex
+}
+
+var _ api.PairingPolicyInterface = (*SkiListPairingPolicy)(nil)
+
+// Create a pairing policy accepting the provided SKIs
+func NewSkiAllowListPairingPolicy(skis ...string) *SkiListPairingPolicy {
+	return newSkiListPairingPolicy(api.PairingDecisionAccept, skis)
+}
+
+// Create a pairing policy denying the provided SKIs
+func NewSkiDenyListPairingPolicy(skis ...string) *SkiListPairingPolicy {
+	return newSkiListPairingPolicy(api.PairingDecisionDeny, skis)
+}
+
+func newSkiListPairingPolicy(decision api.PairingDecision, skis []string) *SkiListPairingPolicy {
+	policy := &SkiListPairingPolicy{
+		decision: decision,
+		skis:     make(map[string]bool),
+	}
+
+	for _, ski := range skis {
+		policy.AddSKI(ski)
+	}
+
+	return policy
+}
+
+// Add a SKI to the list
+func (p *SkiListPairingPolicy) AddSKI(ski string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.skis[util.NormalizeSKI(ski)] = true
+}
+
+// Remove a SKI from the list
+func (p *SkiListPairingPolicy) RemoveSKI(ski string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	delete(p.skis, util.NormalizeSKI(ski))
+}
+
+func (p *SkiListPairingPolicy) PairingDecision(ski string, _ *api.MdnsEntry) api.PairingDecision {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.skis[util.NormalizeSKI(ski)] {
+		return p.decision
+	}
+
+	return api.PairingDecisionDefault
+}
+
+// Pairing policy accepting remote services announcing one of the given
+// device categories in their mDNS entry
+type CategoryPairingPolicy struct {
+	categories []api.DeviceCategoryType
+}
+
+var _ api.PairingPolicyInterface = (*CategoryPairingPolicy)(nil)
+
+// Create a pairing policy accepting remote services with one of the provided device categories
+func NewCategoryPairingPolicy(categories ...api.DeviceCategoryType) *CategoryPairingPolicy {
+	return &CategoryPairingPolicy{
+		categories: categories,
+	}
+}
+
+func (p *CategoryPairingPolicy) PairingDecision(_ string, entry *api.MdnsEntry) api.PairingDecision {
+	if entry == nil {
+		return api.PairingDecisionDefault
+	}
+
+	for _, category := range entry.Categories {
+		if slices.Contains(p.categories, category) {
+			return api.PairingDecisionAccept
+		}
+	}
+
+	return api.PairingDecisionDefault
+}
+
+// Pairing policy which only applies another policy during a time limited commissioning window
+//
+// e.g. accept the next wallbox within the next 5 minutes:
+//
+//	policy := NewCommissioningWindowPairingPolicy(NewCategoryPairingPolicy(api.DeviceCategoryTypeEMobility))
+//	policy.Open(5*time.Minute, 1)
+type CommissioningWindowPairingPolicy struct {
+	// the policy applied while the window is open, accepts all services if nil
+	policy api.PairingPolicyInterface
+
+	// the time the window closes
+	closesAt time.Time
+
+	// the maximum number of remote services paired in the window, 0 for no limit
+	maxAccepted int
+
+	// the services accepted by the policy in the current window, which are not paired yet
+	offered map[string]bool
+
+	// the services paired in the current window
+	accepted map[string]bool
+
+	// the source of the current time, set to the hub clock when the policy is used by a hub
+	clock api.ClockInterface
+
+	mux sync.Mutex
+}
+
+var _ api.PairingPolicyInterface = (*CommissioningWindowPairingPolicy)(nil)
+var _ api.PairingPolicyCompletionInterface = (*CommissioningWindowPairingPolicy)(nil)
+
+// Create a commissioning window policy applying the provided policy while it is open
+//
+// if policy is nil, all remote services are accepted while the window is open
+func NewCommissioningWindowPairingPolicy(policy api.PairingPolicyInterface) *CommissioningWindowPairingPolicy {
+	return &CommissioningWindowPairingPolicy{
+		policy:   policy,
+		offered:  make(map[string]bool),
+		accepted: make(map[string]bool),
+		clock:    clock.NewSystemClock(),
+	}
+}
+
+// Sets the clock used for the commissioning window
+//
+// Is set by the hub when the policy is used as its pairing policy
+func (p *CommissioningWindowPairingPolicy) SetClock(clock api.ClockInterface) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.clock = clock
+}
+
+// Open the commissioning window for the given duration
+//
+// maxAccepted limits the number of remote services paired in this window, 0 for no limit
+func (p *CommissioningWindowPairingPolicy) Open(duration time.Duration, maxAccepted int) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.closesAt = p.clock.Now().Add(duration)
+	p.maxAccepted = maxAccepted
+	p.offered = make(map[string]bool)
+	p.accepted = make(map[string]bool)
+}
+
+// Close the commissioning window
+func (p *CommissioningWindowPairingPolicy) Close() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.closesAt = time.Time{}
+}
+
+// Return if the commissioning window is currently open
+func (p *CommissioningWindowPairingPolicy) IsOpen() bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return p.isOpen()
+}
+
+// needs to be called with mux locked
+func (p *CommissioningWindowPairingPolicy) isOpen() bool {
+	if !p.clock.Now().Before(p.closesAt) {
+		return false
+	}
+
+	return p.maxAccepted <= 0 || len(p.accepted) < p.maxAccepted
+}
+
+// Return the decision for a remote service, without counting it as paired
+func (p *CommissioningWindowPairingPolicy) PairingDecision(ski string, entry *api.MdnsEntry) api.PairingDecision {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	ski = util.NormalizeSKI(ski)
+
+	// a service paired in this window will be asked again on reconnects
+	if p.accepted[ski] && p.clock.Now().Before(p.closesAt) {
+		return api.PairingDecisionAccept
+	}
+
+	if !p.isOpen() {
+		return api.PairingDecisionDefault
+	}
+
+	decision := api.PairingDecisionAccept
+	if p.policy != nil {
+		decision = p.policy.PairingDecision(ski, entry)
+	}
+
+	if decision == api.PairingDecisionAccept {
+		p.offered[ski] = true
+	}
+
+	return decision
+}
+
+// Count a remote service accepted in the current window as paired
+func (p *CommissioningWindowPairingPolicy) PairingCompleted(ski string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	ski = util.NormalizeSKI(ski)
+
+	if !p.offered[ski] || !p.isOpen() {
+		return
+	}
+
+	delete(p.offered, ski)
+	p.accepted[ski] = true
+}
+
+// Pairing policy combining multiple policies
+//
+// the first decision other than PairingDecisionDefault is used
+type PairingPolicyChain struct {
+	policies []api.PairingPolicyInterface
+}
+
+var _ api.PairingPolicyInterface = (*PairingPolicyChain)(nil)
+var _ api.PairingPolicyCompletionInterface = (*PairingPolicyChain)(nil)
+
+// Create a pairing policy combining the provided policies in the given order
+func NewPairingPolicyChain(policies ...api.PairingPolicyInterface) *PairingPolicyChain {
+	return &PairingPolicyChain{
+		policies: policies,
+	}
+}
+
+func (p *PairingPolicyChain) PairingDecision(ski string, entry *api.MdnsEntry) api.PairingDecision {
+	for _, policy := range p.policies {
+		if policy == nil {
+			continue
+		}
+
+		if decision := policy.PairingDecision(ski, entry); decision != api.PairingDecisionDefault {
+			return decision
+		}
+	}
+
+	return api.PairingDecisionDefault
+}
+
+// Report a paired remote service to the policies needing to know about it
+func (p *PairingPolicyChain) PairingCompleted(ski string) {
+	for _, policy := range p.policies {
+		if completion, ok := policy.(api.PairingPolicyCompletionInterface); ok {
+			completion.PairingCompleted(ski)
+		}
+	}
+}
+
+// Sets the clock of the policies using one
+func (p *PairingPolicyChain) SetClock(clock api.ClockInterface) {
+	for _, policy := range p.policies {
+		if setter, ok := policy.(clockSetter); ok {
+			setter.SetClock(clock)
+		}
+	}
+}
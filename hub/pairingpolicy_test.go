@@ -0,0 +1,120 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPairingPolicySuite(t *testing.T) {
+	suite.Run(t, new(PairingPolicySuite))
+}
+
+type PairingPolicySuite struct {
+	suite.Suite
+}
+
+func (s *PairingPolicySuite) Test_SkiList() {
+	allow := NewSkiAllowListPairingPolicy("AB CD")
+	assert.Equal(s.T(), api.PairingDecisionAccept, allow.PairingDecision("abcd", nil))
+	assert.Equal(s.T(), api.PairingDecisionDefault, allow.PairingDecision("ef", nil))
+
+	allow.AddSKI("ef")
+	assert.Equal(s.T(), api.PairingDecisionAccept, allow.PairingDecision("ef", nil))
+
+	allow.RemoveSKI("abcd")
+	assert.Equal(s.T(), api.PairingDecisionDefault, allow.PairingDecision("abcd", nil))
+
+	deny := NewSkiDenyListPairingPolicy("abcd")
+	assert.Equal(s.T(), api.PairingDecisionDeny, deny.PairingDecision("abcd", nil))
+	assert.Equal(s.T(), api.PairingDecisionDefault, deny.PairingDecision("ef", nil))
+}
+
+func (s *PairingPolicySuite) Test_Category() {
+	policy := NewCategoryPairingPolicy(api.DeviceCategoryTypeEMobility)
+	assert.Equal(s.T(), api.PairingDecisionDefault, policy.PairingDecision("abcd", nil))
+
+	entry := &api.MdnsEntry{
+		Categories: []api.DeviceCategoryType{api.DeviceCategoryTypeInverter},
+	}
+	assert.Equal(s.T(), api.PairingDecisionDefault, policy.PairingDecision("abcd", entry))
+
+	entry.Categories = append(entry.Categories, api.DeviceCategoryTypeEMobility)
+	assert.Equal(s.T(), api.PairingDecisionAccept, policy.PairingDecision("abcd", entry))
+}
+
+func (s *PairingPolicySuite) Test_CommissioningWindow() {
+	clock := clocktest.NewFakeClock(time.Now())
+	policy := NewCommissioningWindowPairingPolicy(nil)
+	policy.SetClock(clock)
+	assert.False(s.T(), policy.IsOpen())
+	assert.Equal(s.T(), api.PairingDecisionDefault, policy.PairingDecision("abcd", nil))
+
+	policy.Open(time.Minute, 1)
+	assert.True(s.T(), policy.IsOpen())
+	assert.Equal(s.T(), api.PairingDecisionAccept, policy.PairingDecision("abcd", nil))
+	assert.Equal(s.T(), api.PairingDecisionAccept, policy.PairingDecision("ef", nil))
+
+	// a decision alone doesn't use up the window
+	assert.True(s.T(), policy.IsOpen())
+
+	// services which were not accepted by the policy are not counted
+	policy.PairingCompleted("12")
+	assert.True(s.T(), policy.IsOpen())
+
+	policy.PairingCompleted("ABCD")
+	assert.False(s.T(), policy.IsOpen())
+
+	// the paired service is still accepted, others are not
+	assert.Equal(s.T(), api.PairingDecisionAccept, policy.PairingDecision("abcd", nil))
+	assert.Equal(s.T(), api.PairingDecisionDefault, policy.PairingDecision("ef", nil))
+
+	policy.Close()
+	assert.Equal(s.T(), api.PairingDecisionDefault, policy.PairingDecision("abcd", nil))
+
+	policy.Open(time.Millisecond*10, 0)
+	clock.Advance(time.Millisecond * 10)
+	assert.False(s.T(), policy.IsOpen())
+	assert.Equal(s.T(), api.PairingDecisionDefault, policy.PairingDecision("abcd", nil))
+
+	inner := NewCategoryPairingPolicy(api.DeviceCategoryTypeEMobility)
+	policy = NewCommissioningWindowPairingPolicy(inner)
+	policy.Open(time.Minute, 0)
+	entry := &api.MdnsEntry{
+		Categories: []api.DeviceCategoryType{api.DeviceCategoryTypeEMobility},
+	}
+	assert.Equal(s.T(), api.PairingDecisionDefault, policy.PairingDecision("abcd", nil))
+	assert.Equal(s.T(), api.PairingDecisionAccept, policy.PairingDecision("abcd", entry))
+}
+
+func (s *PairingPolicySuite) Test_CommissioningWindow_Chain() {
+	clock := clocktest.NewFakeClock(time.Now())
+	window := NewCommissioningWindowPairingPolicy(nil)
+	chain := NewPairingPolicyChain(NewSkiDenyListPairingPolicy("ef"), window)
+
+	// the chain passes on the clock and the completed pairings
+	chain.SetClock(clock)
+	window.Open(time.Minute, 1)
+	assert.Equal(s.T(), api.PairingDecisionAccept, chain.PairingDecision("abcd", nil))
+
+	chain.PairingCompleted("abcd")
+	assert.False(s.T(), window.IsOpen())
+
+	clock.Advance(time.Minute)
+	assert.Equal(s.T(), api.PairingDecisionDefault, chain.PairingDecision("abcd", nil))
+}
+
+func (s *PairingPolicySuite) Test_Chain() {
+	chain := NewPairingPolicyChain(
+		nil,
+		NewSkiDenyListPairingPolicy("abcd"),
+		NewSkiAllowListPairingPolicy("abcd", "ef"),
+	)
+	assert.Equal(s.T(), api.PairingDecisionDeny, chain.PairingDecision("abcd", nil))
+	assert.Equal(s.T(), api.PairingDecisionAccept, chain.PairingDecision("ef", nil))
+	assert.Equal(s.T(), api.PairingDecisionDefault, chain.PairingDecision("12", nil))
+}
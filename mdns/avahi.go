@@ -1,8 +1,10 @@
 package mdns
 
 import (
+	"bytes"
 	"fmt"
 	"net"
+	"slices"
 	"sync"
 	"time"
 
@@ -20,6 +22,14 @@ type mdnsServiceData struct {
 	Txt []string
 }
 
+// the data of a service combined from all interfaces and protocols avahi reports it on
+type avahiServiceData struct {
+	host string
+	port int
+	// the address reported for each unique service key
+	addresses map[string]net.IP
+}
+
 type AvahiProvider struct {
 	ifaceIndexes []int32
 
@@ -39,11 +49,14 @@ type AvahiProvider struct {
 	// Used to store the service elements for each service, so that we can recall them when a service is removed
 	serviceElements map[string]map[string]string
 
+	// the combined service data with the service name key as key
+	services map[string]*avahiServiceData
+
 	shutdownChan                      chan struct{}
 	addServiceChan, removeServiceChan chan avahi.Service
 
 	mux   sync.Mutex
-	muxEl sync.RWMutex // used for serviceElements
+	muxEl sync.RWMutex // used for serviceElements and services
 }
 
 func NewAvahiProvider(ifaceIndexes []int32) *AvahiProvider {
@@ -52,6 +65,7 @@ func NewAvahiProvider(ifaceIndexes []int32) *AvahiProvider {
 		setupSuccessful: false,
 		ifaceIndexes:    ifaceIndexes,
 		serviceElements: make(map[string]map[string]string),
+		services:        make(map[string]*avahiServiceData),
 	}
 }
 
@@ -212,6 +226,29 @@ func (a *AvahiProvider) Unannounce() {
 	a.avEntryGroup = nil
 }
 
+// Query the network for SHIP services again
+//
+// A new service browser reports all services avahi currently knows about
+func (a *AvahiProvider) Query() {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if !a.setupSuccessful || a.avBrowser == nil {
+		return
+	}
+
+	a.avServer.ServiceBrowserFree(a.avBrowser)
+	a.avBrowser = nil
+
+	avBrowser, err := a.avServer.ServiceBrowserNew(a.addServiceChan, a.removeServiceChan, avahi.InterfaceUnspec, avahi.ProtoUnspec, shipZeroConfServiceType, shipZeroConfDomain, 0)
+	if err != nil || avBrowser == nil {
+		logging.Log().Debug("mdns: avahi - error creating service browser", err)
+		return
+	}
+
+	a.avBrowser = avBrowser
+}
+
 func (a *AvahiProvider) avahiCallback(event avahi.Event) {
 	a.mux.Lock()
 	// if there is a manual shutdown, we do not want to reconnect
@@ -317,10 +354,33 @@ func (a *AvahiProvider) processService(service avahi.Service, remove bool, cb ap
 func (a *AvahiProvider) processRemovedService(service avahi.Service, cb api.MdnsResolveCB) error {
 	logging.Log().Tracef("mdns: avahi - process remove service: %v", service)
 
+	uniqueKey := getServiceUniqueKey(service)
+	nameKey := getServiceNameKey(service)
+
 	// get the elements for the service
-	a.muxEl.RLock()
-	elements := a.serviceElements[getServiceUniqueKey(service)]
-	a.muxEl.RUnlock()
+	a.muxEl.Lock()
+	elements := a.serviceElements[uniqueKey]
+	delete(a.serviceElements, uniqueKey)
+
+	var host string
+	var port int
+	var addresses []net.IP
+	if data, ok := a.services[nameKey]; ok {
+		delete(data.addresses, uniqueKey)
+		host, port = data.host, data.port
+		addresses = data.combinedAddresses()
+
+		if len(data.addresses) == 0 {
+			delete(a.services, nameKey)
+		}
+	}
+	a.muxEl.Unlock()
+
+	// the service is still available on other interfaces or protocols
+	if len(addresses) > 0 {
+		cb(elements, service.Name, host, addresses, port, false)
+		return nil
+	}
 
 	cb(elements, service.Name, service.Host, nil, -1, true)
 
@@ -343,16 +403,52 @@ func (a *AvahiProvider) processAddedService(service avahi.Service, cb api.MdnsRe
 		return fmt.Errorf("service provides unusable address: %s", service.Name)
 	}
 
-	// add the elements to the map
+	uniqueKey := getServiceUniqueKey(service)
+	nameKey := getServiceNameKey(service)
+
+	// add the elements and the address to the maps
 	a.muxEl.Lock()
-	a.serviceElements[getServiceUniqueKey(service)] = elements
+	a.serviceElements[uniqueKey] = elements
+
+	data, ok := a.services[nameKey]
+	if !ok {
+		data = &avahiServiceData{
+			addresses: make(map[string]net.IP),
+		}
+		a.services[nameKey] = data
+	}
+	data.host = service.Host
+	data.port = int(service.Port)
+	data.addresses[uniqueKey] = address
+	addresses := data.combinedAddresses()
 	a.muxEl.Unlock()
 
-	cb(elements, service.Name, service.Host, []net.IP{address}, int(service.Port), false)
+	// report all addresses the service is currently available at
+	cb(elements, service.Name, service.Host, addresses, int(service.Port), false)
 
 	return nil
 }
 
+// return the distinct addresses of the service, sorted
+func (d *avahiServiceData) combinedAddresses() []net.IP {
+	var addresses []net.IP
+	for _, address := range d.addresses {
+		if !slices.ContainsFunc(addresses, address.Equal) {
+			addresses = append(addresses, address)
+		}
+	}
+	slices.SortFunc(addresses, func(a, b net.IP) int {
+		return bytes.Compare(a.To16(), b.To16())
+	})
+
+	return addresses
+}
+
+// Create a key for a ship service, identical for all interfaces and protocols
+func getServiceNameKey(service avahi.Service) string {
+	return fmt.Sprintf("%s-%s-%s", service.Name, service.Type, service.Domain)
+}
+
 // Create a unique key for a ship service
 func getServiceUniqueKey(service avahi.Service) string {
 	return fmt.Sprintf("%s-%s-%s-%d-%d", service.Name, service.Type, service.Domain, service.Protocol, service.Interface)
@@ -173,6 +173,85 @@ func (a *AvahiSuite) Test_Avahi() {
 	a.sut.Shutdown()
 }
 
+func (a *AvahiSuite) Test_Query() {
+	// not started
+	a.sut.Query()
+
+	a.sut.setupSuccessful = true
+	a.sut.avBrowser = a.serviceBrowserMock
+
+	newBrowserMock := mocks.NewServiceBrowserInterface(a.T())
+	a.avahiMock.EXPECT().ServiceBrowserFree(a.serviceBrowserMock).Return().Once()
+	a.avahiMock.EXPECT().ServiceBrowserNew(
+		mock.Anything, mock.Anything,
+		int32(-1), int32(-1),
+		shipZeroConfServiceType, shipZeroConfDomain,
+		uint32(0)).Return(newBrowserMock, nil).Once()
+	a.sut.Query()
+	assert.Equal(a.T(), newBrowserMock, a.sut.avBrowser)
+
+	a.avahiMock.EXPECT().ServiceBrowserFree(newBrowserMock).Return().Once()
+	a.avahiMock.EXPECT().ServiceBrowserNew(
+		mock.Anything, mock.Anything,
+		int32(-1), int32(-1),
+		shipZeroConfServiceType, shipZeroConfDomain,
+		uint32(0)).Return(nil, errors.New("some error")).Once()
+	a.sut.Query()
+	assert.Nil(a.T(), a.sut.avBrowser)
+}
+
+func (a *AvahiSuite) Test_CombineAddresses() {
+	var reports []mDNSEntry
+	var removes []bool
+	cb := func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+		reports = append(reports, mDNSEntry{elements: elements, name: name, host: host, addresses: addresses, port: port})
+		removes = append(removes, remove)
+	}
+
+	service := avahi.Service{
+		Interface: 1,
+		Protocol:  avahi.ProtoInet,
+		Name:      "TestService",
+		Type:      "_ship._tcp",
+		Domain:    "local",
+		Host:      "remote.local",
+		Address:   "192.168.1.20",
+		Port:      4712,
+		Txt:       [][]byte{[]byte("ski=1234")},
+	}
+	err := a.sut.processAddedService(service, cb)
+	assert.Nil(a.T(), err)
+
+	service6 := service
+	service6.Protocol = avahi.ProtoInet6
+	service6.Address = "fd00::20"
+	err = a.sut.processAddedService(service6, cb)
+	assert.Nil(a.T(), err)
+
+	// all addresses of the service are reported
+	if assert.Equal(a.T(), 2, len(reports)) {
+		assert.Equal(a.T(), 2, len(reports[1].addresses))
+		assert.False(a.T(), removes[1])
+	}
+
+	// removing one protocol keeps the service with the remaining address
+	err = a.sut.processRemovedService(service6, cb)
+	assert.Nil(a.T(), err)
+	if assert.Equal(a.T(), 3, len(reports)) {
+		assert.False(a.T(), removes[2])
+		assert.Equal(a.T(), []net.IP{net.ParseIP("192.168.1.20")}, reports[2].addresses)
+		assert.Equal(a.T(), 4712, reports[2].port)
+		assert.Equal(a.T(), "1234", reports[2].elements["ski"])
+	}
+
+	err = a.sut.processRemovedService(service, cb)
+	assert.Nil(a.T(), err)
+	if assert.Equal(a.T(), 4, len(reports)) {
+		assert.True(a.T(), removes[3])
+	}
+	assert.Equal(a.T(), 0, len(a.sut.services))
+}
+
 func (a *AvahiSuite) Test_Shutdown() {
 	a.sut.Shutdown()
 }
@@ -0,0 +1,189 @@
+package mdns
+
+import (
+	"net"
+	"regexp"
+	"slices"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/util"
+)
+
+// Filters the discovered remote services that are reported
+//
+// All set criteria have to match for a service to be reported,
+// unset criteria match every service.
+// Static entries added via AddStaticEntry are never filtered.
+type DiscoveryFilter struct {
+	// Only report services with at least one of these device categories
+	Categories []api.DeviceCategoryType
+
+	// Only report services with a brand matching this expression
+	Brand *regexp.Regexp
+
+	// Only report services with a model matching this expression
+	Model *regexp.Regexp
+
+	// Only report services with one of these SKIs
+	Skis []string
+
+	// Only report addresses within the subnets of these network interfaces
+	//
+	// Services without a matching address are not reported
+	Interfaces []string
+
+	// Only report addresses within these subnets
+	//
+	// Services without a matching address are not reported
+	Subnets []*net.IPNet
+}
+
+// Set the filter for the discovered remote services that are reported
+//
+// Already known services are reported again with the new filter applied.
+// A nil filter reports all services.
+//
+// Default: nil
+func (m *MdnsManager) SetDiscoveryFilter(filter *DiscoveryFilter) {
+	m.mux.Lock()
+	if filter != nil {
+		// keep a copy, so later changes by the caller have no effect
+		copied := *filter
+		copied.Categories = slices.Clone(filter.Categories)
+		copied.Subnets = slices.Clone(filter.Subnets)
+		copied.Interfaces = slices.Clone(filter.Interfaces)
+		copied.Skis = nil
+		for _, ski := range filter.Skis {
+			copied.Skis = append(copied.Skis, util.NormalizeSKI(ski))
+		}
+		filter = &copied
+	}
+	m.discoveryFilter = filter
+	m.mux.Unlock()
+
+	report := m.reportCallback()
+	if report == nil {
+		return
+	}
+
+	m.queueEntriesReport(report, nil, true)
+}
+
+// return copies of the entries matching the discovery filter
+func (m *MdnsManager) reportedMdnsEntries() map[string]*api.MdnsEntry {
+	entries := m.copyMdnsEntries()
+
+	m.mux.Lock()
+	filter := m.discoveryFilter
+	m.mux.Unlock()
+
+	if filter == nil {
+		return entries
+	}
+
+	subnets := filter.subnets()
+
+	for ski, entry := range entries {
+		if !filter.matches(entry, subnets) {
+			delete(entries, ski)
+		}
+	}
+
+	return entries
+}
+
+// return if the filter is nil or the entry matches it
+func (m *MdnsManager) matchesDiscoveryFilter(entry *api.MdnsEntry) bool {
+	m.mux.Lock()
+	filter := m.discoveryFilter
+	m.mux.Unlock()
+
+	if filter == nil {
+		return true
+	}
+
+	filtered := &api.MdnsEntry{}
+	util.DeepCopy[*api.MdnsEntry](entry, filtered)
+
+	return filter.matches(filtered, filter.subnets())
+}
+
+// return the subnets set directly and via the interfaces, nil if addresses are not filtered
+func (f *DiscoveryFilter) subnets() []*net.IPNet {
+	if len(f.Subnets) == 0 && len(f.Interfaces) == 0 {
+		return nil
+	}
+
+	subnets := slices.Clone(f.Subnets)
+	for _, name := range f.Interfaces {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			logging.Log().Debug("mdns: filter - unknown interface", name, err)
+			continue
+		}
+
+		addresses, err := iface.Addrs()
+		if err != nil {
+			logging.Log().Debug("mdns: filter - interface addresses not available", name, err)
+			continue
+		}
+
+		for _, address := range addresses {
+			if subnet, ok := address.(*net.IPNet); ok {
+				subnets = append(subnets, subnet)
+			}
+		}
+	}
+
+	// make sure no address matches if no interface subnet is available
+	if subnets == nil {
+		subnets = []*net.IPNet{}
+	}
+
+	return subnets
+}
+
+// return if the entry matches the filter
+//
+// subnets are the subnets of the filter, or nil if addresses are not filtered.
+// The addresses of the entry are reduced to the ones within the subnets
+func (f *DiscoveryFilter) matches(entry *api.MdnsEntry, subnets []*net.IPNet) bool {
+	if slices.Contains(entry.Providers, MdnsProviderNameStatic) {
+		return true
+	}
+
+	if len(f.Skis) > 0 && !slices.Contains(f.Skis, util.NormalizeSKI(entry.Ski)) {
+		return false
+	}
+
+	if len(f.Categories) > 0 && !slices.ContainsFunc(entry.Categories, func(category api.DeviceCategoryType) bool {
+		return slices.Contains(f.Categories, category)
+	}) {
+		return false
+	}
+
+	if f.Brand != nil && !f.Brand.MatchString(entry.Brand) {
+		return false
+	}
+
+	if f.Model != nil && !f.Model.MatchString(entry.Model) {
+		return false
+	}
+
+	if subnets == nil {
+		return true
+	}
+
+	var addresses []net.IP
+	for _, address := range entry.Addresses {
+		if slices.ContainsFunc(subnets, func(subnet *net.IPNet) bool {
+			return subnet.Contains(address)
+		}) {
+			addresses = append(addresses, address)
+		}
+	}
+	entry.Addresses = addresses
+
+	return len(addresses) > 0
+}
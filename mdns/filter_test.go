@@ -0,0 +1,164 @@
+package mdns
+
+import (
+	"net"
+	"regexp"
+	"testing"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFilterSuite(t *testing.T) {
+	suite.Run(t, new(FilterSuite))
+}
+
+type FilterSuite struct {
+	suite.Suite
+
+	sut *MdnsManager
+
+	mdnsSearch *mocks.MdnsReportInterface
+}
+
+func (s *FilterSuite) BeforeTest(suiteName, testName string) {
+	s.mdnsSearch = mocks.NewMdnsReportInterface(s.T())
+	s.mdnsSearch.On("ReportMdnsEntries", mock.Anything, mock.Anything).Maybe().Return()
+	s.mdnsSearch.On("ReportMdnsEntryUpdate", mock.Anything).Maybe().Return()
+
+	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		4729, nil, MdnsProviderSelectionAll)
+	s.sut.report = s.mdnsSearch
+
+	s.addEntry("ski1", "Brand A", "Wallbox 1", "3", []net.IP{net.ParseIP("192.168.1.10"), net.ParseIP("10.0.0.10")})
+	s.addEntry("ski2", "Brand B", "Heatpump 2", "4", []net.IP{net.ParseIP("192.168.2.20")})
+	s.addEntry("ski3", "Brand A", "Inverter 3", "3,5", []net.IP{net.ParseIP("127.0.0.1")})
+}
+
+func (s *FilterSuite) addEntry(ski, brand, model, categories string, addresses []net.IP) {
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id-" + ski,
+		"path":     "/ship/",
+		"ski":      ski,
+		"register": "false",
+		"brand":    brand,
+		"model":    model,
+		"cat":      categories,
+	}
+	s.sut.processMdnsEntry("test", elements, ski, ski+".local.", addresses, 4711, false)
+}
+
+func (s *FilterSuite) reportedSkis() []string {
+	var skis []string
+	for _, ski := range []string{"ski1", "ski2", "ski3", "ski4"} {
+		if _, ok := s.sut.reportedMdnsEntries()[ski]; ok {
+			skis = append(skis, ski)
+		}
+	}
+	return skis
+}
+
+func (s *FilterSuite) Test_NoFilter() {
+	assert.Equal(s.T(), []string{"ski1", "ski2", "ski3"}, s.reportedSkis())
+
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{})
+	assert.Equal(s.T(), []string{"ski1", "ski2", "ski3"}, s.reportedSkis())
+}
+
+func (s *FilterSuite) Test_Categories() {
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Categories: []api.DeviceCategoryType{
+			api.DeviceCategoryTypeEMobility,
+			api.DeviceCategoryTypeInverter,
+		},
+	})
+	assert.Equal(s.T(), []string{"ski1", "ski3"}, s.reportedSkis())
+
+	s.sut.SetDiscoveryFilter(nil)
+	assert.Equal(s.T(), []string{"ski1", "ski2", "ski3"}, s.reportedSkis())
+}
+
+func (s *FilterSuite) Test_BrandModel() {
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Brand: regexp.MustCompile("^Brand A$"),
+	})
+	assert.Equal(s.T(), []string{"ski1", "ski3"}, s.reportedSkis())
+
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Brand: regexp.MustCompile("^Brand A$"),
+		Model: regexp.MustCompile("^Wallbox"),
+	})
+	assert.Equal(s.T(), []string{"ski1"}, s.reportedSkis())
+}
+
+func (s *FilterSuite) Test_Skis() {
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Skis: []string{"SKI2", "ski3"},
+	})
+	assert.Equal(s.T(), []string{"ski2", "ski3"}, s.reportedSkis())
+}
+
+func (s *FilterSuite) Test_Subnets() {
+	_, subnet, _ := net.ParseCIDR("192.168.1.0/24")
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Subnets: []*net.IPNet{subnet},
+	})
+	assert.Equal(s.T(), []string{"ski1"}, s.reportedSkis())
+
+	// only the addresses within the subnets are reported
+	entry := s.sut.reportedMdnsEntries()["ski1"]
+	if assert.Equal(s.T(), 1, len(entry.Addresses)) {
+		assert.Equal(s.T(), "192.168.1.10", entry.Addresses[0].String())
+	}
+
+	// the stored entry is not changed
+	stored, _ := s.sut.mdnsEntry("ski1")
+	assert.Equal(s.T(), 2, len(stored.Addresses))
+}
+
+func (s *FilterSuite) Test_Interfaces() {
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Interfaces: []string{"lo"},
+	})
+	assert.Equal(s.T(), []string{"ski3"}, s.reportedSkis())
+
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Interfaces: []string{"unknown"},
+	})
+	assert.Equal(s.T(), []string(nil), s.reportedSkis())
+}
+
+func (s *FilterSuite) Test_StaticEntries() {
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Skis: []string{"ski1"},
+	})
+
+	err := s.sut.AddStaticEntry(api.MdnsEntry{
+		Ski:  "ski4",
+		Host: "ski4.local.",
+		Port: 4711,
+	})
+	assert.Nil(s.T(), err)
+
+	assert.Equal(s.T(), []string{"ski1", "ski4"}, s.reportedSkis())
+}
+
+func (s *FilterSuite) Test_MatchesDiscoveryFilter() {
+	entry, _ := s.sut.mdnsEntry("ski2")
+	assert.True(s.T(), s.sut.matchesDiscoveryFilter(entry))
+
+	s.sut.SetDiscoveryFilter(&DiscoveryFilter{
+		Skis: []string{"ski1"},
+	})
+	assert.False(s.T(), s.sut.matchesDiscoveryFilter(entry))
+
+	entry, _ = s.sut.mdnsEntry("ski1")
+	assert.True(s.T(), s.sut.matchesDiscoveryFilter(entry))
+}
@@ -6,25 +6,38 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/enbility/go-avahi"
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/util"
 )
 
 const shipWebsocketPath = "/ship/"
 
+// The default duration after which an mDNS entry that was not seen again is removed
+//
+// This is three times the TTL of 2 minutes defined in SHIP chapter 7
+const DefaultStaleThreshold = 6 * time.Minute
+
+// the interval for checking the threshold when stale entry removal is disabled
+const staleCheckDisabledInterval = time.Minute
+
 type MdnsProviderSelection uint
 
 const (
-	MdnsProviderSelectionAll            MdnsProviderSelection = iota // Automatically use avahi if available, otherwise use Go native Zeroconf, default
+	MdnsProviderSelectionAll            MdnsProviderSelection = iota // Automatically use avahi if available, otherwise the built-in mDNS implementation or Go native Zeroconf, default
 	MdnsProviderSelectionAvahiOnly                                   // Only use avahi
 	MdnsProviderSelectionGoZeroConfOnly                              // Only us Go native zeroconf
+	MdnsProviderSelectionNativeOnly                                  // Only use the built-in mDNS implementation
+	MdnsProviderSelectionUnicastOnly                                 // Only use unicast DNS-SD, requires SetUnicastConfig
 )
 
 type MdnsManager struct {
@@ -59,6 +72,9 @@ type MdnsManager struct {
 	// The port address of the websocket server
 	port int
 
+	// The path of the websocket server
+	path string
+
 	// Wether remote devices should be automatically accepted
 	autoaccept bool
 
@@ -67,17 +83,59 @@ type MdnsManager struct {
 	// the currently available mDNS entries with the SKI as the key in the map
 	entries map[string]*api.MdnsEntry
 
+	// the entries reported by each provider, with the SKI and the provider name as keys
+	sources map[string]map[string]*api.MdnsEntry
+
 	// the registered callback, only connectionsHub is using this
 	report api.MdnsReportInterface
 
+	// the provider chosen by providerSelection
 	mdnsProvider api.MdnsProviderInterface
 
-	shutdownOnce sync.Once
+	// providers running concurrently to mdnsProvider
+	additionalProviders []namedMdnsProvider
+
+	// entries not seen for this duration are removed, 0 disables the removal and re-queries
+	staleThreshold time.Duration
+
+	// closed on shutdown to stop the maintenance of the entries
+	stopC chan struct{}
+
+	// wether Start was invoked without a following Shutdown
+	isStarted bool
+
+	// wether to shut down on an interrupt or SIGTERM signal
+	shutdownOnSignal bool
 
 	providerSelection MdnsProviderSelection
 
+	// the configuration for unicast DNS-SD
+	unicastConfig *UnicastConfig
+
+	// the static entries with the SKI as the key in the map
+	staticEntries map[string]api.MdnsEntry
+
+	// persists the static entries, optional
+	staticLoader api.MdnsStaticEntryLoaderInterface
+
+	// filters the reported entries, optional
+	discoveryFilter *DiscoveryFilter
+
+	// the source of the current time and the stale entry checks
+	clock api.ClockInterface
+
+	// the reports waiting to be delivered in order, protected by muxReports
+	pendingReports []func()
+
+	// true while a goroutine delivers the pending reports, protected by muxReports
+	isReporting bool
+
 	mux,
-	muxAnnounced sync.Mutex
+	muxLifecycle,
+	muxStatic,
+	muxAnnounced,
+	muxEntryChange,
+	muxReports sync.Mutex
 }
 
 func shortenString(s string, maxLen int) string {
@@ -118,9 +176,14 @@ func NewMDNS(
 		identifier:        shipIdentifier,
 		serviceName:       serviceName,
 		port:              port,
+		path:              shipWebsocketPath,
 		ifaces:            ifaces,
 		providerSelection: providerSelection,
 		entries:           make(map[string]*api.MdnsEntry),
+		sources:           make(map[string]map[string]*api.MdnsEntry),
+		staticEntries:     make(map[string]api.MdnsEntry),
+		staleThreshold:    DefaultStaleThreshold,
+		clock:             clock.NewSystemClock(),
 	}
 
 	return m
@@ -155,76 +218,207 @@ func (m *MdnsManager) interfaces() ([]net.Interface, []int32, error) {
 
 var _ api.MdnsInterface = (*MdnsManager)(nil)
 
+// Start the mDNS providers, announce the service and report the discovered services to cb
+//
+// Can be invoked again after Shutdown
 func (m *MdnsManager) Start(cb api.MdnsReportInterface) error {
-	ifaces, ifaceIndexes, err := m.interfaces()
+	m.muxLifecycle.Lock()
+	defer m.muxLifecycle.Unlock()
+
+	if m.isStarted {
+		return errors.New("mDNS is already started")
+	}
+
+	if err := m.startProviders(); err != nil {
+		m.shutdownProviders()
+		return err
+	}
+
+	// on startup always start mDNS announcement
+	if err := m.AnnounceMdnsEntry(); err != nil {
+		m.shutdownProviders()
+		return err
+	}
+
+	m.mux.Lock()
+	m.report = cb
+	m.mux.Unlock()
+
+	// report the static entries that were added before the start
+	if entries := m.reportedMdnsEntries(); len(entries) > 0 {
+		m.queueEntriesReport(cb, nil, true)
+	}
+
+	m.stopC = make(chan struct{})
+	go m.maintainEntries(m.stopC)
+
+	if m.shutdownOnSignal {
+		go m.handleSignals(m.stopC)
+	}
+
+	m.isStarted = true
+
+	return nil
+}
+
+// start the provider chosen by the provider selection and the additional providers
+func (m *MdnsManager) startProviders() error {
+	provider, err := m.startSelectedProvider()
+	m.setMdnsProvider(provider)
 	if err != nil {
 		return err
 	}
 
+	// the additional providers run concurrently
+	for _, additional := range m.additionalProviders {
+		if !additional.provider.Start(true, m.resolveCB(additional.name)) {
+			return fmt.Errorf("mDNS provider %s could not be started", additional.name)
+		}
+	}
+
+	return nil
+}
+
+// start the provider chosen by the provider selection
+//
+// the provider is also returned if it failed to start, so it can be shut down
+func (m *MdnsManager) startSelectedProvider() (api.MdnsProviderInterface, error) {
+	ifaces, ifaceIndexes, err := m.interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var provider api.MdnsProviderInterface
+
 	switch m.providerSelection {
 	case MdnsProviderSelectionAll:
-		// First try avahi, if not available use zerconf
-		provider := NewAvahiProvider(ifaceIndexes)
-		if provider.Start(false, m.processMdnsEntry) {
-			m.mdnsProvider = provider
-		} else {
-			provider.Shutdown()
-
-			// Avahi is not availble, use Zeroconf
-			m.mdnsProvider = NewZeroconfProvider(ifaces)
-			if !m.mdnsProvider.Start(false, m.processMdnsEntry) {
-				return errors.New("No mDNS provider available")
-			}
+		// First try avahi, if not available use the built-in implementation, then zeroconf
+		avahiProvider := NewAvahiProvider(ifaceIndexes)
+		if avahiProvider.Start(false, m.resolveCB(MdnsProviderNameAvahi)) {
+			provider = avahiProvider
+			break
+		}
+		avahiProvider.Shutdown()
+
+		// Avahi is not availble, use the built-in implementation
+		nativeProvider := NewNativeProvider(ifaces)
+		if nativeProvider.Start(false, m.resolveCB(MdnsProviderNameNative)) {
+			provider = nativeProvider
+			break
+		}
+		nativeProvider.Shutdown()
+
+		// No multicast socket could be opened, use Zeroconf
+		provider = NewZeroconfProvider(ifaces)
+		if !provider.Start(false, m.resolveCB(MdnsProviderNameZeroconf)) {
+			return provider, errors.New("No mDNS provider available")
 		}
 	case MdnsProviderSelectionAvahiOnly:
 		// Only use Avahi
-		m.mdnsProvider = NewAvahiProvider(ifaceIndexes)
-		_ = m.mdnsProvider.Start(true, m.processMdnsEntry)
+		provider = NewAvahiProvider(ifaceIndexes)
+		_ = provider.Start(true, m.resolveCB(MdnsProviderNameAvahi))
 	case MdnsProviderSelectionGoZeroConfOnly:
 		// Only use Zeroconf
-		m.mdnsProvider = NewZeroconfProvider(ifaces)
-		_ = m.mdnsProvider.Start(true, m.processMdnsEntry)
+		provider = NewZeroconfProvider(ifaces)
+		_ = provider.Start(true, m.resolveCB(MdnsProviderNameZeroconf))
+	case MdnsProviderSelectionNativeOnly:
+		// Only use the built-in implementation
+		provider = NewNativeProvider(ifaces)
+		_ = provider.Start(true, m.resolveCB(MdnsProviderNameNative))
+	case MdnsProviderSelectionUnicastOnly:
+		// Only use unicast DNS-SD
+		if m.unicastConfig == nil {
+			return nil, errors.New("unicast DNS-SD is not configured")
+		}
+		provider = NewUnicastProvider(*m.unicastConfig)
+		if !provider.Start(true, m.resolveCB(MdnsProviderNameUnicast)) {
+			return provider, errors.New("invalid unicast DNS-SD configuration")
+		}
 	}
 
-	// on startup always start mDNS announcement
-	if err := m.AnnounceMdnsEntry(); err != nil {
-		return err
-	}
+	return provider, nil
+}
 
-	m.report = cb
+// Shutdown all of mDNS
+//
+// The service is unannounced and all providers are stopped.
+// The entries discovered by the providers are removed, static entries are kept
+func (m *MdnsManager) Shutdown() {
+	m.muxLifecycle.Lock()
+	defer m.muxLifecycle.Unlock()
 
-	// catch signals
-	go func() {
-		signalC := make(chan os.Signal, 1)
-		signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
+	if !m.isStarted {
+		m.shutdownProviders()
+		return
+	}
 
-		<-signalC // wait for signal
+	m.isStarted = false
+	close(m.stopC)
 
-		m.Shutdown()
-	}()
+	m.UnannounceMdnsEntry()
+	m.shutdownProviders()
+	m.removeDiscoveredEntries()
+}
 
-	return nil
+// stop all providers
+func (m *MdnsManager) shutdownProviders() {
+	for _, provider := range m.providers() {
+		provider.Shutdown()
+	}
+	m.setMdnsProvider(nil)
 }
 
-// Shutdown all of mDNS
-func (m *MdnsManager) Shutdown() {
-	m.shutdownOnce.Do(func() {
-		m.UnannounceMdnsEntry()
+// remove the entries reported by the providers, keeping the static entries
+func (m *MdnsManager) removeDiscoveredEntries() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
 
-		if m.mdnsProvider == nil {
-			return
+	for ski, sources := range m.sources {
+		for provider := range sources {
+			if provider != MdnsProviderNameStatic {
+				delete(sources, provider)
+			}
 		}
 
-		m.mdnsProvider.Shutdown()
-		m.mdnsProvider = nil
-	})
+		if merged := mergeMdnsEntries(sources); merged != nil {
+			m.entries[ski] = merged
+			continue
+		}
+
+		delete(m.sources, ski)
+		delete(m.entries, ski)
+	}
+}
+
+// Set if the manager shuts down when the process receives an interrupt or SIGTERM signal
+//
+// The application should rather invoke Shutdown as part of its own shutdown sequence.
+// Needs to be invoked before Start
+//
+// Default: false
+func (m *MdnsManager) SetShutdownOnSignal(enable bool) {
+	m.shutdownOnSignal = enable
+}
+
+// shut down on an interrupt or SIGTERM signal, until stopC is closed
+func (m *MdnsManager) handleSignals(stopC chan struct{}) {
+	signalC := make(chan os.Signal, 1)
+	signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalC)
+
+	select {
+	case <-stopC:
+	case <-signalC:
+		m.Shutdown()
+	}
 }
 
 // Announces the service to the network via mDNS
 // A CEM service should always invoke this on startup
 // Any other service should only invoke this whenever it is not connected to a CEM service
 func (m *MdnsManager) AnnounceMdnsEntry() error {
-	if m.mdnsProvider == nil {
+	providers := m.providers()
+	if len(providers) == 0 {
 		return nil
 	}
 
@@ -232,7 +426,7 @@ func (m *MdnsManager) AnnounceMdnsEntry() error {
 
 	txt := []string{ // SHIP 7.3.2
 		"txtvers=1",
-		"path=" + shipWebsocketPath,
+		"path=" + m.path,
 		"id=" + serviceIdentifier,
 		"ski=" + m.ski,
 		"brand=" + m.deviceBrand,
@@ -255,9 +449,18 @@ func (m *MdnsManager) AnnounceMdnsEntry() error {
 
 	serviceName := m.serviceName
 
-	if err := m.mdnsProvider.Announce(serviceName, m.port, txt); err != nil {
-		logging.Log().Debug("mdns: failure announcing service", err)
-		return err
+	// every provider announces the service, even if another one failed
+	var announceErr error
+	for _, provider := range providers {
+		if err := provider.Announce(serviceName, m.port, txt); err != nil {
+			logging.Log().Debug("mdns: failure announcing service", err)
+			if announceErr == nil {
+				announceErr = err
+			}
+		}
+	}
+	if announceErr != nil {
+		return announceErr
 	}
 
 	m.mux.Lock()
@@ -270,11 +473,14 @@ func (m *MdnsManager) AnnounceMdnsEntry() error {
 
 // Stop the mDNS announcement on the network
 func (m *MdnsManager) UnannounceMdnsEntry() {
-	if !m.isServiceAnnounced() || m.mdnsProvider == nil {
+	providers := m.providers()
+	if !m.isServiceAnnounced() || len(providers) == 0 {
 		return
 	}
 
-	m.mdnsProvider.Unannounce()
+	for _, provider := range providers {
+		provider.Unannounce()
+	}
 	logging.Log().Debug("mdns: stop announcement")
 
 	m.setIsServiceAnnounce(false)
@@ -308,6 +514,159 @@ func (m *MdnsManager) SetAutoAccept(accept bool) {
 	}
 }
 
+// Set the port and path of the websocket server that are announced
+//
+// An empty path announces the default SHIP websocket path "/ship/"
+func (m *MdnsManager) SetWebsocketEndpoint(port int, path string) {
+	if len(path) == 0 {
+		path = shipWebsocketPath
+	}
+
+	m.port = port
+	m.path = path
+
+	// if announcement is off, don't enforce a new announcement
+	if !m.isServiceAnnounced() {
+		return
+	}
+
+	// Update the announcement as the endpoint changed
+	if err := m.AnnounceMdnsEntry(); err != nil {
+		logging.Log().Debug("mdns: changing mdns entry failed", err)
+	}
+}
+
+// Set the configuration used for unicast DNS-SD
+// Needs to be invoked before Start
+func (m *MdnsManager) SetUnicastConfig(config UnicastConfig) {
+	m.unicastConfig = &config
+}
+
+// Set the duration after which an mDNS entry is removed if it was not seen again
+//
+// The network is queried again in a third of this interval, so available services
+// are reported again before they are considered stale.
+// A value of 0 disables the removal of stale entries and the periodic queries.
+//
+// Default: DefaultStaleThreshold
+func (m *MdnsManager) SetStaleThreshold(threshold time.Duration) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.staleThreshold = threshold
+}
+
+// Set the clock used for the last seen times and the removal of stale entries
+//
+// The providers use the system time for the timers of their protocols.
+// Is set by the hub when the hub clock is set
+func (m *MdnsManager) SetClock(clock api.ClockInterface) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.clock = clock
+}
+
+func (m *MdnsManager) getClock() api.ClockInterface {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.clock
+}
+
+func (m *MdnsManager) currentStaleThreshold() time.Duration {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.staleThreshold
+}
+
+// periodically remove stale entries and query the network again, until stopC is closed
+func (m *MdnsManager) maintainEntries(stopC chan struct{}) {
+	for {
+		threshold := m.currentStaleThreshold()
+
+		interval := threshold / 3
+		if threshold <= 0 {
+			interval = staleCheckDisabledInterval
+		}
+
+		select {
+		case <-stopC:
+			return
+		case <-m.getClock().After(interval):
+		}
+
+		if threshold <= 0 {
+			continue
+		}
+
+		m.removeStaleEntries(m.getClock().Now())
+
+		for _, provider := range m.providers() {
+			provider.Query()
+		}
+	}
+}
+
+// remove all entries not seen within the stale threshold and report the changes
+//
+// the reports of each provider are checked separately, so an entry that is only
+// stale for one provider keeps the data of the other providers
+func (m *MdnsManager) removeStaleEntries(now time.Time) {
+	m.mux.Lock()
+	changed := false
+	if m.staleThreshold > 0 {
+		for ski, sources := range m.sources {
+			staleSource := false
+			for provider, source := range sources {
+				// static entries never become stale
+				if provider == MdnsProviderNameStatic || now.Sub(source.LastSeen) <= m.staleThreshold {
+					continue
+				}
+
+				delete(sources, provider)
+				staleSource = true
+
+				logging.Log().Debug("mdns: stale - ski:", ski, "name:", source.Name, "provider:", provider, "last seen:", source.LastSeen)
+			}
+
+			if !staleSource {
+				continue
+			}
+
+			changed = true
+			if merged := mergeMdnsEntries(sources); merged != nil {
+				m.entries[ski] = merged
+				continue
+			}
+
+			delete(m.sources, ski)
+			delete(m.entries, ski)
+		}
+
+		// entries that were not reported by any provider
+		for ski, entry := range m.entries {
+			if _, ok := m.sources[ski]; ok || now.Sub(entry.LastSeen) <= m.staleThreshold {
+				continue
+			}
+
+			delete(m.entries, ski)
+			changed = true
+
+			logging.Log().Debug("mdns: stale - ski:", ski, "name:", entry.Name, "last seen:", entry.LastSeen)
+		}
+	}
+	m.mux.Unlock()
+
+	report := m.reportCallback()
+	if report == nil || !changed {
+		return
+	}
+
+	m.queueEntriesReport(report, nil, true)
+}
+
 // Returns a safe to use key value pair for the QR code text in the proper format
 // according to SHIP Requirements for Installation Process V1.0.0
 func (m *MdnsManager) safeQRCodeKeyValue(key, value string) string {
@@ -365,6 +724,14 @@ func (m *MdnsManager) QRCodeText() string {
 	return qrcode
 }
 
+// return the registered callback, nil if Start was not invoked yet
+func (m *MdnsManager) reportCallback() api.MdnsReportInterface {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.report
+}
+
 func (m *MdnsManager) mdnsEntries() map[string]*api.MdnsEntry {
 	m.mux.Lock()
 	defer m.mux.Unlock()
@@ -408,8 +775,8 @@ func (m *MdnsManager) removeMdnsEntry(ski string) {
 	delete(m.entries, ski)
 }
 
-// process an mDNS entry and manage mDNS entries map
-func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+// process an mDNS entry reported by a provider and manage mDNS entries map
+func (m *MdnsManager) processMdnsEntry(provider string, elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
 	// check for mandatory text elements
 	mapItems := []string{"txtvers", "id", "path", "ski", "register"}
 	for _, item := range mapItems {
@@ -482,79 +849,240 @@ func (m *MdnsManager) processMdnsEntry(elements map[string]string, name, host st
 		}
 	}
 
-	updated := false
+	sourceEntry := &api.MdnsEntry{
+		Name:       name,
+		Ski:        ski,
+		Identifier: identifier,
+		Path:       path,
+		Register:   register == "true",
+		Brand:      brand,
+		Type:       deviceType,
+		Model:      model,
+		Serial:     serial,
+		Categories: categories,
+		Host:       host,
+		Port:       port,
+		Addresses:  addresses,
+		LastSeen:   m.getClock().Now(),
+		Providers:  []string{provider},
+	}
 
-	entry, exists := m.mdnsEntry(ski)
+	if remove {
+		sourceEntry = nil
+	}
+	entry, newEntry, changed := m.changeSourceEntry(ski, provider, sourceEntry)
+	if !changed {
+		return
+	}
+
+	switch {
+	case newEntry == nil:
+		logging.Log().Debug("mdns: remove - ski:", ski, "name:", name, "provider:", provider, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
+	case entry != nil:
+		logging.Log().Debug("mdns: update - ski:", ski, "name:", name, "provider:", provider, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
+	default:
+		logging.Log().Debug("mdns: new - ski:", ski, "name:", name, "provider:", provider, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
+	}
+}
 
-	if remove && exists {
-		updated = true
-		// remove
-		// there will be a remove for each address with avahi, but we'll delete it right away
-		m.removeMdnsEntry(ski)
+// set the entry reported by a provider for a SKI, or remove it if sourceEntry is nil
+//
+// returns the previous and the new merged entry, which are nil if there was or is no entry
+func (m *MdnsManager) setSourceEntry(ski, provider string, sourceEntry *api.MdnsEntry) (*api.MdnsEntry, *api.MdnsEntry) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
 
-		logging.Log().Debug("mdns: remove - ski:", ski, "name:", name, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
-	} else if exists {
-		// avahi sends an item for each network address, merge them
+	// the entry combines the reports of all providers
+	sources, ok := m.sources[ski]
+	if !ok {
+		sources = make(map[string]*api.MdnsEntry)
+	}
+	if sourceEntry == nil {
+		delete(sources, provider)
+	} else {
+		sources[provider] = sourceEntry
+	}
+	if len(sources) > 0 {
+		m.sources[ski] = sources
+	} else {
+		delete(m.sources, ski)
+	}
+	newEntry := mergeMdnsEntries(sources)
 
-		// we assume only network addresses are added
-		for _, address := range addresses {
-			// only add if it is not added yet
-			isNewElement := true
+	entry := m.entries[ski]
+	if newEntry != nil {
+		m.entries[ski] = newEntry
+	} else {
+		delete(m.entries, ski)
+	}
 
-			for _, item := range entry.Addresses {
-				if item.String() == address.String() {
-					isNewElement = false
-					break
-				}
-			}
+	return entry, newEntry
+}
 
-			if isNewElement {
-				entry.Addresses = append(entry.Addresses, address)
-				updated = true
-			}
+// set the entry reported by a provider for a SKI, or remove it if sourceEntry is nil, and report the change
+//
+// returns the previous and the new merged entry, which are nil if there was or is no entry,
+// and false if nothing changed
+func (m *MdnsManager) changeSourceEntry(ski, provider string, sourceEntry *api.MdnsEntry) (*api.MdnsEntry, *api.MdnsEntry, bool) {
+	// the reports are queued in the order of the changes
+	m.muxEntryChange.Lock()
+	defer m.muxEntryChange.Unlock()
+
+	previous, current := m.setSourceEntry(ski, provider, sourceEntry)
+
+	return previous, current, m.reportEntryChange(previous, current)
+}
+
+// report the change from the previous to the current entry, which are nil if there was or is no entry
+//
+// returns false if nothing changed
+func (m *MdnsManager) reportEntryChange(previous, current *api.MdnsEntry) bool {
+	var update *api.MdnsEntryUpdate
+
+	if previous == nil && current == nil {
+		return false
+	}
+
+	if previous != nil && current != nil {
+		// the entry is replaced in any case, to refresh the last seen time
+		changes, added, removed := mdnsEntryChanges(previous, current)
+		if changes == 0 {
+			return false
 		}
 
-		if updated {
-			m.setMdnsEntry(ski, entry)
+		update = &api.MdnsEntryUpdate{
+			Previous:         &api.MdnsEntry{},
+			Current:          &api.MdnsEntry{},
+			Changes:          changes,
+			AddedAddresses:   added,
+			RemovedAddresses: removed,
+		}
+		util.DeepCopy[*api.MdnsEntry](previous, update.Previous)
+		util.DeepCopy[*api.MdnsEntry](current, update.Current)
+	}
+
+	report := m.reportCallback()
+	if report == nil {
+		return true
+	}
+
+	// updates are only reported for entries that were and are matching the filter
+	if update != nil && (!m.matchesDiscoveryFilter(previous) || !m.matchesDiscoveryFilter(current)) {
+		update = nil
+	}
+
+	m.queueEntriesReport(report, update, true)
 
-			logging.Log().Debug("mdns: update - ski:", ski, "name:", name, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
+	return true
+}
+
+// queue the report of an optional update and the current entries
+//
+// The reports are delivered by a single goroutine in the order they were queued,
+// so an older list of entries is never reported after a newer one
+func (m *MdnsManager) queueEntriesReport(report api.MdnsReportInterface, update *api.MdnsEntryUpdate, newEntries bool) {
+	m.muxReports.Lock()
+	defer m.muxReports.Unlock()
+
+	// the entries are copied while queueing, so the queued lists are in order
+	entries := m.reportedMdnsEntries()
+	m.pendingReports = append(m.pendingReports, func() {
+		if update != nil {
+			report.ReportMdnsEntryUpdate(*update)
 		}
-	} else if !exists && !remove {
-		updated = true
-		// new
-		newEntry := &api.MdnsEntry{
-			Name:       name,
-			Ski:        ski,
-			Identifier: identifier,
-			Path:       path,
-			Register:   register == "true",
-			Brand:      brand,
-			Type:       deviceType,
-			Model:      model,
-			Serial:     serial,
-			Categories: categories,
-			Host:       host,
-			Port:       port,
-			Addresses:  addresses,
+		report.ReportMdnsEntries(entries, newEntries)
+	})
+
+	if !m.isReporting {
+		m.isReporting = true
+		go m.deliverReports()
+	}
+}
+
+// deliver the queued reports until the queue is empty
+func (m *MdnsManager) deliverReports() {
+	for {
+		m.muxReports.Lock()
+		if len(m.pendingReports) == 0 {
+			m.isReporting = false
+			m.muxReports.Unlock()
+			return
 		}
-		m.setMdnsEntry(ski, newEntry)
 
-		logging.Log().Debug("mdns: new - ski:", ski, "name:", name, "brand:", brand, "model:", model, "typ:", deviceType, "serial:", serial, "categories:", categoriesStr, "identifier:", identifier, "register:", register, "host:", host, "port:", port, "addresses:", addresses)
+		report := m.pendingReports[0]
+		m.pendingReports[0] = nil
+		m.pendingReports = m.pendingReports[1:]
+		m.muxReports.Unlock()
+
+		report()
 	}
+}
 
-	if m.report == nil || !updated {
-		return
+// return the changed fields of an mDNS entry and the added and removed addresses
+func mdnsEntryChanges(previous, current *api.MdnsEntry) (api.MdnsEntryChanges, []net.IP, []net.IP) {
+	var changes api.MdnsEntryChanges
+
+	if previous.Name != current.Name {
+		changes |= api.MdnsEntryChangeName
+	}
+	if previous.Identifier != current.Identifier {
+		changes |= api.MdnsEntryChangeIdentifier
+	}
+	if previous.Path != current.Path {
+		changes |= api.MdnsEntryChangePath
+	}
+	if previous.Register != current.Register {
+		changes |= api.MdnsEntryChangeRegister
+	}
+	if previous.Brand != current.Brand {
+		changes |= api.MdnsEntryChangeBrand
+	}
+	if previous.Type != current.Type {
+		changes |= api.MdnsEntryChangeType
+	}
+	if previous.Model != current.Model {
+		changes |= api.MdnsEntryChangeModel
+	}
+	if previous.Serial != current.Serial {
+		changes |= api.MdnsEntryChangeSerial
+	}
+	if !slices.Equal(previous.Categories, current.Categories) {
+		changes |= api.MdnsEntryChangeCategories
+	}
+	if previous.Host != current.Host {
+		changes |= api.MdnsEntryChangeHost
+	}
+	if previous.Port != current.Port {
+		changes |= api.MdnsEntryChangePort
+	}
+
+	var added, removed []net.IP
+	for _, address := range current.Addresses {
+		if !slices.ContainsFunc(previous.Addresses, address.Equal) {
+			added = append(added, address)
+		}
+	}
+	for _, address := range previous.Addresses {
+		if !slices.ContainsFunc(current.Addresses, address.Equal) {
+			removed = append(removed, address)
+		}
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		changes |= api.MdnsEntryChangeAddresses
+	}
+
+	if !slices.Equal(previous.Providers, current.Providers) {
+		changes |= api.MdnsEntryChangeProviders
 	}
 
-	entries := m.copyMdnsEntries()
-	go m.report.ReportMdnsEntries(entries, true)
+	return changes, added, removed
 }
 
 func (m *MdnsManager) RequestMdnsEntries() {
-	if m.report == nil {
+	report := m.reportCallback()
+	if report == nil {
 		return
 	}
 
-	entries := m.copyMdnsEntries()
-	go m.report.ReportMdnsEntries(entries, false)
+	m.queueEntriesReport(report, nil, false)
 }
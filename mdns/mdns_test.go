@@ -2,10 +2,15 @@ package mdns
 
 import (
 	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/util"
 	"github.com/stretchr/testify/assert"
@@ -32,10 +37,12 @@ func (s *MdnsSuite) BeforeTest(suiteName, testName string) {
 
 	s.mdnsSearch = mocks.NewMdnsReportInterface(s.T())
 	s.mdnsSearch.On("ReportMdnsEntries", mock.Anything, mock.Anything).Maybe().Return()
+	s.mdnsSearch.On("ReportMdnsEntryUpdate", mock.Anything).Maybe().Return()
 
 	s.mdnsProvider = mocks.NewMdnsProviderInterface(s.T())
 	s.mdnsProvider.On("ResolveEntries", mock.Anything, mock.Anything).Maybe().Return()
 	s.mdnsProvider.On("Shutdown").Maybe().Return()
+	s.mdnsProvider.On("Query").Maybe().Return()
 
 	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
 		"12345",
@@ -127,6 +134,40 @@ func (s *MdnsSuite) Test_GoZeroConfOnly() {
 	assert.True(s.T(), s.sut.autoaccept)
 }
 
+func (s *MdnsSuite) Test_NativeOnly() {
+	s.sut.Shutdown()
+
+	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		4729, nil, MdnsProviderSelectionNativeOnly)
+
+	err := s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+	_, ok := s.sut.mdnsProvider.(*NativeProvider)
+	assert.True(s.T(), ok)
+}
+
+func (s *MdnsSuite) Test_UnicastOnly() {
+	s.sut.Shutdown()
+
+	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		4729, nil, MdnsProviderSelectionUnicastOnly)
+
+	err := s.sut.Start(s.mdnsSearch)
+	assert.NotNil(s.T(), err)
+
+	s.sut.SetUnicastConfig(UnicastConfig{Server: "127.0.0.1:1", Domain: "example.test", Timeout: 10 * time.Millisecond})
+	err = s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+	_, ok := s.sut.mdnsProvider.(*UnicastProvider)
+	assert.True(s.T(), ok)
+}
+
 func (s *MdnsSuite) Test_Start() {
 	err := s.sut.Start(s.mdnsSearch)
 	assert.Nil(s.T(), err)
@@ -174,6 +215,63 @@ func (s *MdnsSuite) Test_Shutdown_Start() {
 	s.sut.Shutdown()
 }
 
+func (s *MdnsSuite) Test_Restart() {
+	err := s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+
+	err = s.sut.Start(s.mdnsSearch)
+	assert.NotNil(s.T(), err)
+
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship/",
+		"ski":      "discovered",
+		"register": "false",
+	}
+	s.sut.processMdnsEntry("test", elements, "name", "host.local.", []net.IP{net.ParseIP("192.168.1.20")}, 4711, false)
+	err = s.sut.AddStaticEntry(api.MdnsEntry{Ski: "static", Host: "static.local.", Port: 4711})
+	assert.Nil(s.T(), err)
+
+	s.sut.Shutdown()
+	assert.Nil(s.T(), s.sut.mdnsProvider)
+
+	// discovered entries are removed, static entries are kept
+	_, ok := s.sut.mdnsEntry("discovered")
+	assert.False(s.T(), ok)
+	_, ok = s.sut.mdnsEntry("static")
+	assert.True(s.T(), ok)
+
+	err = s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), s.sut.mdnsProvider)
+	assert.True(s.T(), s.sut.isServiceAnnounced())
+}
+
+func (s *MdnsSuite) Test_ShutdownOnSignal() {
+	// make sure the test process is not terminated by the signal
+	signalC := make(chan os.Signal, 1)
+	signal.Notify(signalC, syscall.SIGTERM)
+	defer signal.Stop(signalC)
+
+	s.sut.SetShutdownOnSignal(true)
+	err := s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+
+	// wait for the signal handler to be installed
+	time.Sleep(time.Millisecond * 100)
+
+	err = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	assert.Nil(s.T(), err)
+
+	assert.Eventually(s.T(), func() bool {
+		s.sut.muxLifecycle.Lock()
+		defer s.sut.muxLifecycle.Unlock()
+
+		return !s.sut.isStarted
+	}, time.Second, time.Millisecond*10)
+}
+
 func (s *MdnsSuite) Test_Shutdown_NoStart() {
 	s.sut.Shutdown()
 	assert.Nil(s.T(), s.sut.mdnsProvider)
@@ -241,7 +339,7 @@ func (s *MdnsSuite) Test_ProcessMdnsEntry() {
 	ips := []net.IP{}
 	port := 4567
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["txtvers"] = "2"
@@ -251,23 +349,23 @@ func (s *MdnsSuite) Test_ProcessMdnsEntry() {
 	elements["register"] = "falsee"
 	elements["cat"] = "text"
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["txtvers"] = "1"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["ski"] = s.sut.ski
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["ski"] = "testski"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 
 	elements["register"] = "false"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
 	elements["brand"] = "brand"
@@ -275,16 +373,274 @@ func (s *MdnsSuite) Test_ProcessMdnsEntry() {
 	elements["model"] = "model"
 	elements["serial"] = "serial"
 	elements["cat"] = "2,3"
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
 	ips = []net.IP{[]byte("127.0.0.1"), []byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}}
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
+	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
+
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, false)
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, false)
+	s.sut.processMdnsEntry("test", elements, name, host, ips, port, true)
+	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
+}
+
+func (s *MdnsSuite) Test_ProcessMdnsEntry_LastSeen() {
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship",
+		"ski":      "testski",
+		"register": "false",
+	}
+
+	s.sut.processMdnsEntry("test", elements, "name", "host", nil, 4567, false)
+	entry, ok := s.sut.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+	assert.False(s.T(), entry.LastSeen.IsZero())
+
+	lastSeen := time.Now().Add(-time.Hour)
+	entry.LastSeen = lastSeen
+
+	// an unchanged entry only refreshes the last seen time
+	s.sut.processMdnsEntry("test", elements, "name", "host", nil, 4567, false)
+	entry, ok = s.sut.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+	assert.True(s.T(), entry.LastSeen.After(lastSeen))
+}
+
+func (s *MdnsSuite) Test_RemoveStaleEntries() {
+	now := time.Now()
+
+	s.sut.report = s.mdnsSearch
+	s.sut.setMdnsEntry("fresh", &api.MdnsEntry{Ski: "fresh", LastSeen: now.Add(-time.Minute)})
+	s.sut.setMdnsEntry("stale", &api.MdnsEntry{Ski: "stale", LastSeen: now.Add(-DefaultStaleThreshold - time.Second)})
+
+	s.sut.removeStaleEntries(now)
+	entries := s.sut.mdnsEntries()
+	assert.Equal(s.T(), 1, len(entries))
+	_, ok := entries["fresh"]
+	assert.True(s.T(), ok)
+
+	// a disabled threshold keeps all entries
+	s.sut.SetStaleThreshold(0)
+	s.sut.removeStaleEntries(now.Add(time.Hour))
 	assert.Equal(s.T(), 1, len(s.sut.mdnsEntries()))
 
-	s.sut.processMdnsEntry(elements, name, host, ips, port, true)
+	s.sut.SetStaleThreshold(30 * time.Second)
+	s.sut.removeStaleEntries(now)
 	assert.Equal(s.T(), 0, len(s.sut.mdnsEntries()))
 }
+
+func (s *MdnsSuite) Test_MaintainEntries() {
+	provider := mocks.NewMdnsProviderInterface(s.T())
+	provider.EXPECT().Query().Return()
+	provider.EXPECT().Shutdown().Return().Maybe()
+	provider.EXPECT().Unannounce().Return().Maybe()
+	s.sut.mdnsProvider = provider
+
+	clock := clocktest.NewFakeClock(time.Now())
+	s.sut.SetClock(clock)
+
+	s.sut.report = s.mdnsSearch
+	s.sut.SetStaleThreshold(60 * time.Millisecond)
+	s.sut.setMdnsEntry("stale", &api.MdnsEntry{Ski: "stale", LastSeen: clock.Now().Add(-time.Minute)})
+
+	stopC := make(chan struct{})
+	defer close(stopC)
+	go s.sut.maintainEntries(stopC)
+
+	// the entries are checked in a third of the threshold
+	clock.BlockUntil(1)
+	clock.Advance(20 * time.Millisecond)
+
+	assert.Eventually(s.T(), func() bool {
+		return len(s.sut.mdnsEntries()) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func (s *MdnsSuite) Test_ProcessMdnsEntry_Update() {
+	report := mocks.NewMdnsReportInterface(s.T())
+	updates := make(chan api.MdnsEntryUpdate, 1)
+	report.EXPECT().ReportMdnsEntryUpdate(mock.Anything).Run(func(update api.MdnsEntryUpdate) {
+		updates <- update
+	}).Return().Maybe()
+	report.EXPECT().ReportMdnsEntries(mock.Anything, true).Return().Maybe()
+	s.sut.report = report
+
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship",
+		"ski":      "testski",
+		"register": "false",
+		"brand":    "brand",
+	}
+	ipv4 := net.ParseIP("192.168.1.20")
+	ipv6 := net.ParseIP("fd00::20")
+
+	s.sut.processMdnsEntry("test", elements, "name", "host", []net.IP{ipv4, ipv6}, 4567, false)
+
+	// the same data does not report an update
+	s.sut.processMdnsEntry("test", elements, "name", "host", []net.IP{ipv4, ipv6}, 4567, false)
+
+	elements["register"] = "true"
+	elements["brand"] = "newbrand"
+	s.sut.processMdnsEntry("test", elements, "name", "host", []net.IP{ipv4}, 4568, false)
+
+	select {
+	case update := <-updates:
+		assert.True(s.T(), update.Changes.Has(api.MdnsEntryChangeRegister))
+		assert.True(s.T(), update.Changes.Has(api.MdnsEntryChangeBrand))
+		assert.True(s.T(), update.Changes.Has(api.MdnsEntryChangePort))
+		assert.True(s.T(), update.Changes.Has(api.MdnsEntryChangeAddresses))
+		assert.False(s.T(), update.Changes.Has(api.MdnsEntryChangePath|api.MdnsEntryChangeHost))
+		assert.False(s.T(), update.Previous.Register)
+		assert.True(s.T(), update.Current.Register)
+		assert.Equal(s.T(), 4567, update.Previous.Port)
+		assert.Equal(s.T(), 4568, update.Current.Port)
+		assert.Equal(s.T(), 0, len(update.AddedAddresses))
+		if assert.Equal(s.T(), 1, len(update.RemovedAddresses)) {
+			assert.True(s.T(), ipv6.Equal(update.RemovedAddresses[0]))
+		}
+	case <-time.After(time.Second):
+		s.T().Fatal("no update reported")
+	}
+	assert.Equal(s.T(), 0, len(updates))
+
+	entry, ok := s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.True(s.T(), entry.Register)
+		assert.Equal(s.T(), "newbrand", entry.Brand)
+		assert.Equal(s.T(), 1, len(entry.Addresses))
+	}
+}
+
+func (s *MdnsSuite) Test_AdditionalProviders() {
+	s.sut.Shutdown()
+
+	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		4729, nil, MdnsProviderSelectionGoZeroConfOnly)
+
+	additional := mocks.NewMdnsProviderInterface(s.T())
+	additional.EXPECT().Start(true, mock.Anything).Return(true).Once()
+	additional.EXPECT().Announce("serviceName", 4729, mock.Anything).Return(nil).Once()
+	additional.EXPECT().Unannounce().Return().Once()
+	additional.EXPECT().Shutdown().Return().Once()
+	s.sut.AddProvider("additional", additional)
+
+	err := s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(s.sut.providers()))
+
+	s.sut.Shutdown()
+	assert.Equal(s.T(), 0, len(s.sut.providers()))
+}
+
+func (s *MdnsSuite) Test_SetWebsocketEndpoint() {
+	s.sut.Shutdown()
+
+	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		0, nil, MdnsProviderSelectionGoZeroConfOnly)
+
+	hasPath := func(path string) func([]string) bool {
+		return func(txt []string) bool {
+			return slices.Contains(txt, "path="+path)
+		}
+	}
+
+	additional := mocks.NewMdnsProviderInterface(s.T())
+	additional.EXPECT().Start(true, mock.Anything).Return(true).Once()
+	additional.EXPECT().Announce("serviceName", 4729, mock.MatchedBy(hasPath("/ship/"))).Return(nil).Once()
+	additional.EXPECT().Announce("serviceName", 4800, mock.MatchedBy(hasPath("/eebus/"))).Return(nil).Once()
+	additional.EXPECT().Unannounce().Return().Once()
+	additional.EXPECT().Shutdown().Return().Once()
+	s.sut.AddProvider("additional", additional)
+
+	s.sut.SetWebsocketEndpoint(4729, "")
+
+	err := s.sut.Start(s.mdnsSearch)
+	assert.Nil(s.T(), err)
+
+	// the announcement is updated with the new endpoint
+	s.sut.SetWebsocketEndpoint(4800, "/eebus/")
+
+	s.sut.Shutdown()
+}
+
+func (s *MdnsSuite) Test_AdditionalProviders_StartFailure() {
+	s.sut.Shutdown()
+
+	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		4729, nil, MdnsProviderSelectionGoZeroConfOnly)
+
+	additional := mocks.NewMdnsProviderInterface(s.T())
+	additional.EXPECT().Start(true, mock.Anything).Return(false).Once()
+	additional.EXPECT().Unannounce().Return().Maybe()
+	additional.EXPECT().Shutdown().Return().Maybe()
+	s.sut.AddProvider("additional", additional)
+
+	err := s.sut.Start(s.mdnsSearch)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *MdnsSuite) Test_ProcessMdnsEntry_MergeProviders() {
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship",
+		"ski":      "testski",
+		"register": "false",
+	}
+	ipv4 := net.ParseIP("192.168.1.20")
+	routed := net.ParseIP("10.0.0.20")
+
+	s.sut.processMdnsEntry("multicast", elements, "name", "host.local.", []net.IP{ipv4}, 4567, false)
+	s.sut.processMdnsEntry("unicast", elements, "name", "host.example.com.", []net.IP{routed, ipv4}, 4567, false)
+
+	entry, ok := s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []string{"multicast", "unicast"}, entry.Providers)
+		assert.Equal(s.T(), 2, len(entry.Addresses))
+		// the most recent report provides the service data
+		assert.Equal(s.T(), "host.example.com.", entry.Host)
+	}
+
+	// a removal by one provider keeps the data of the other provider
+	s.sut.processMdnsEntry("multicast", elements, "name", "host.local.", nil, -1, true)
+	entry, ok = s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []string{"unicast"}, entry.Providers)
+		assert.Equal(s.T(), 2, len(entry.Addresses))
+	}
+
+	// the report of one provider becomes stale
+	s.sut.processMdnsEntry("multicast", elements, "name", "host.local.", []net.IP{ipv4}, 4567, false)
+	s.sut.mux.Lock()
+	s.sut.sources["testski"]["unicast"].LastSeen = time.Now().Add(-time.Hour)
+	s.sut.mux.Unlock()
+
+	s.sut.removeStaleEntries(time.Now())
+	entry, ok = s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []string{"multicast"}, entry.Providers)
+		assert.Equal(s.T(), 1, len(entry.Addresses))
+		assert.Equal(s.T(), "host.local.", entry.Host)
+	}
+
+	s.sut.processMdnsEntry("multicast", elements, "name", "host.local.", nil, -1, true)
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+	assert.Equal(s.T(), 0, len(s.sut.sources))
+}
@@ -0,0 +1,1317 @@
+package mdns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/miekg/dns"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	mdnsPort = 5353
+
+	// the TTL of announced records, 2 minutes as defined in SHIP chapter 7
+	nativeRecordTTL = 120
+
+	// the maximum interval between browse queries, RFC 6762 5.2
+	nativeMaxQueryInterval = time.Hour
+
+	// the minimum interval between queries for resolving the same service instance
+	nativeResolveInterval = 2 * time.Second
+
+	// the interval for checking interface changes in addition to change notifications
+	nativeInterfacePollInterval = 30 * time.Second
+
+	// the interval of the provider maintenance tasks
+	nativeTickInterval = 250 * time.Millisecond
+
+	// the number of unsolicited announcements, RFC 6762 8.3
+	nativeAnnouncementCount = 2
+
+	// the top bit of the class is used as cache flush bit in responses
+	// and as unicast response bit in questions, RFC 6762 10.2 and 5.4
+	classTopBit = 1 << 15
+)
+
+var (
+	mdnsGroupIPv4 = net.IPv4(224, 0, 0, 251)
+	mdnsGroupIPv6 = net.ParseIP("ff02::fb")
+
+	mdnsAddrIPv4 = &net.UDPAddr{IP: mdnsGroupIPv4, Port: mdnsPort}
+	mdnsAddrIPv6 = &net.UDPAddr{IP: mdnsGroupIPv6, Port: mdnsPort}
+
+	// the fully qualified SHIP service type
+	nativeServiceType = shipZeroConfServiceType + "." + shipZeroConfDomain
+
+	// the DNS-SD service type enumeration name, RFC 6763 9
+	nativeServiceEnumeration = "_services._dns-sd._udp." + shipZeroConfDomain
+
+	// the fractions of the TTL at which cached records are refreshed, RFC 6762 5.2
+	nativeRefreshFractions = []float64{0.80, 0.85, 0.90, 0.95}
+)
+
+// a network interface used for mDNS with its current addresses
+type nativeInterface struct {
+	iface net.Interface
+	ipv4  []net.IP
+	ipv6  []net.IP
+}
+
+// a received record in the cache
+type cachedRecord struct {
+	rr       dns.RR
+	received time.Time
+	expires  time.Time
+
+	// the number of refresh queries sent for this record
+	refreshes int
+}
+
+// a resolved remote service
+type nativeService struct {
+	instance  string // the escaped fully qualified instance name
+	host      string
+	port      int
+	txt       []string
+	addresses []net.IP
+}
+
+func (s *nativeService) equal(other *nativeService) bool {
+	return s.host == other.host &&
+		s.port == other.port &&
+		slices.Equal(s.txt, other.txt) &&
+		slices.EqualFunc(s.addresses, other.addresses, func(a, b net.IP) bool { return a.Equal(b) })
+}
+
+// the announced local service
+type nativeAnnouncement struct {
+	instance string // the escaped fully qualified instance name
+	port     int
+	txt      []string
+
+	// the remaining number of unsolicited announcements and when the next one is sent
+	remaining int
+	next      time.Time
+}
+
+// mDNS provider implemented in Go without external dependencies,
+// supporting IPv4 and IPv6 and reacting to network interface changes
+type NativeProvider struct {
+	// the configured interfaces, all multicast capable interfaces are used if empty
+	ifaces []net.Interface
+
+	// the host name used for the announcement, e.g. "myhost.local."
+	hostname string
+
+	autoReconnect bool
+	cb            api.MdnsResolveCB
+
+	conn4 *ipv4.PacketConn
+	conn6 *ipv6.PacketConn
+
+	// the currently used interfaces with the index as key
+	interfaces map[int]*nativeInterface
+
+	// the received records with a key identifying the record data
+	cache map[string]*cachedRecord
+
+	// the reported services with the lower case instance name as key
+	services map[string]*nativeService
+
+	// the time of the last query for resolving a service instance
+	resolveQueries map[string]time.Time
+
+	announcement *nativeAnnouncement
+
+	queryInterval time.Duration
+	nextQuery     time.Time
+
+	interfacesChanged bool
+	nextInterfacePoll time.Time
+
+	// callbacks collected while the mutex is locked, invoked after unlocking
+	pendingCallbacks []func()
+
+	// sends a message to the multicast group on the interface if addr is nil, otherwise to addr
+	// ifIndex 0 sends the message on all interfaces
+	send func(msg *dns.Msg, ifIndex int, addr *net.UDPAddr)
+
+	isStarted bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mux sync.Mutex
+}
+
+// Create a new native mDNS provider
+//
+// Parameters:
+//   - ifaces: the network interfaces to use, or empty if all multicast capable interfaces should be used
+func NewNativeProvider(ifaces []net.Interface) *NativeProvider {
+	p := &NativeProvider{
+		ifaces:         ifaces,
+		hostname:       nativeHostname(),
+		interfaces:     make(map[int]*nativeInterface),
+		cache:          make(map[string]*cachedRecord),
+		services:       make(map[string]*nativeService),
+		resolveQueries: make(map[string]time.Time),
+	}
+	p.send = p.sendMessage
+
+	return p
+}
+
+var _ api.MdnsProviderInterface = (*NativeProvider)(nil)
+
+// Start browsing for SHIP services
+//
+// Returns false if no multicast socket could be opened and autoReconnect is false.
+// With autoReconnect the provider keeps trying to open the sockets.
+func (p *NativeProvider) Start(autoReconnect bool, cb api.MdnsResolveCB) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.isStarted {
+		return true
+	}
+
+	p.autoReconnect = autoReconnect
+	p.cb = cb
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	p.openConnections()
+	if p.conn4 == nil && p.conn6 == nil && !autoReconnect {
+		logging.Log().Debug("mdns: native provider could not open any multicast socket")
+		p.cancel()
+		return false
+	}
+
+	p.isStarted = true
+
+	now := time.Now()
+	p.refreshInterfaces(now)
+	p.restartQueries(now)
+
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		watchInterfaceChanges(p.ctx, p.setInterfacesChanged)
+	}()
+	go p.run()
+
+	return true
+}
+
+// Stop the provider, sending goodbye packets for an announced service
+func (p *NativeProvider) Shutdown() {
+	p.Unannounce()
+
+	p.mux.Lock()
+	if !p.isStarted {
+		p.mux.Unlock()
+		return
+	}
+
+	p.isStarted = false
+	p.cancel()
+	p.closeConnections()
+
+	// a restart begins with an empty state
+	p.interfaces = make(map[int]*nativeInterface)
+	p.cache = make(map[string]*cachedRecord)
+	p.services = make(map[string]*nativeService)
+	p.resolveQueries = make(map[string]time.Time)
+	p.mux.Unlock()
+
+	p.wg.Wait()
+}
+
+// Announce the local SHIP service
+func (p *NativeProvider) Announce(serviceName string, port int, txt []string) error {
+	if len(serviceName) == 0 {
+		return errors.New("mdns: service name is missing")
+	}
+
+	logging.Log().Debug("mdns: using native provider")
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	instance := escapeLabel(serviceName) + "." + nativeServiceType
+
+	// goodbye for a previous announcement with a different name,
+	// otherwise the new records replace the previous ones via the cache flush bit
+	if p.announcement != nil && p.announcement.instance != instance && p.isStarted {
+		p.sendGoodbye()
+	}
+
+	p.announcement = &nativeAnnouncement{
+		instance: instance,
+		port:     port,
+		txt:      txt,
+	}
+	p.scheduleAnnouncement(time.Now())
+
+	return nil
+}
+
+// Stop announcing the local SHIP service, sending goodbye packets
+func (p *NativeProvider) Unannounce() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.announcement == nil {
+		return
+	}
+
+	if p.isStarted {
+		p.sendGoodbye()
+	}
+	p.announcement = nil
+}
+
+// Query the network for SHIP services again and report all currently available services
+func (p *NativeProvider) Query() {
+	p.withLock(func() {
+		if !p.isStarted {
+			return
+		}
+
+		// the cache honours the record TTLs, so the known services are still available
+		for _, service := range p.services {
+			p.report(service, false)
+		}
+
+		p.restartQueries(time.Now())
+	})
+}
+
+func (p *NativeProvider) setInterfacesChanged() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.interfacesChanged = true
+}
+
+// lock the mutex, run fn and invoke the collected callbacks after unlocking
+func (p *NativeProvider) withLock(fn func()) {
+	p.mux.Lock()
+	fn()
+	callbacks := p.pendingCallbacks
+	p.pendingCallbacks = nil
+	p.mux.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// the maintenance loop
+func (p *NativeProvider) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(nativeTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.withLock(func() {
+				p.tick(now)
+			})
+		}
+	}
+}
+
+// run all time based tasks, needs to be called with mux locked
+func (p *NativeProvider) tick(now time.Time) {
+	if !p.isStarted {
+		return
+	}
+
+	if p.interfacesChanged || !now.Before(p.nextInterfacePoll) {
+		p.refreshInterfaces(now)
+	}
+
+	p.expireCache(now)
+	p.updateServices(now)
+	p.refreshRecords(now)
+
+	if !now.Before(p.nextQuery) {
+		p.sendBrowseQuery(now)
+		p.nextQuery = now.Add(p.queryInterval)
+		p.queryInterval = min(2*p.queryInterval, nativeMaxQueryInterval)
+	}
+
+	if p.announcement != nil && p.announcement.remaining > 0 && !now.Before(p.announcement.next) {
+		p.send(p.announcementMessage(), 0, nil)
+		p.announcement.remaining--
+		// the interval between announcements doubles, RFC 6762 8.3
+		p.announcement.next = now.Add(time.Second << (nativeAnnouncementCount - p.announcement.remaining - 1))
+	}
+}
+
+// restart the browse queries with the initial interval, needs to be called with mux locked
+func (p *NativeProvider) restartQueries(now time.Time) {
+	p.queryInterval = time.Second
+	p.nextQuery = now
+}
+
+// send the announcement again, needs to be called with mux locked
+func (p *NativeProvider) scheduleAnnouncement(now time.Time) {
+	if p.announcement == nil {
+		return
+	}
+
+	p.announcement.remaining = nativeAnnouncementCount
+	p.announcement.next = now
+}
+
+/* Sockets */
+
+// open the multicast sockets which are not open yet, needs to be called with mux locked
+func (p *NativeProvider) openConnections() {
+	if p.conn4 == nil {
+		if udpConn, err := net.ListenUDP("udp4", mdnsAddrIPv4); err != nil {
+			logging.Log().Debug("mdns: native provider failed to open IPv4 socket:", err)
+		} else {
+			conn := ipv4.NewPacketConn(udpConn)
+			_ = conn.SetControlMessage(ipv4.FlagInterface, true)
+			_ = conn.SetMulticastTTL(255)
+			_ = conn.SetMulticastLoopback(true)
+
+			for _, ni := range p.interfaces {
+				_ = conn.JoinGroup(&ni.iface, &net.UDPAddr{IP: mdnsGroupIPv4})
+			}
+
+			p.conn4 = conn
+			p.wg.Add(1)
+			go p.readIPv4(conn)
+		}
+	}
+
+	if p.conn6 == nil {
+		if udpConn, err := net.ListenUDP("udp6", mdnsAddrIPv6); err != nil {
+			logging.Log().Debug("mdns: native provider failed to open IPv6 socket:", err)
+		} else {
+			conn := ipv6.NewPacketConn(udpConn)
+			_ = conn.SetControlMessage(ipv6.FlagInterface, true)
+			_ = conn.SetMulticastHopLimit(255)
+			_ = conn.SetMulticastLoopback(true)
+
+			for _, ni := range p.interfaces {
+				_ = conn.JoinGroup(&ni.iface, &net.UDPAddr{IP: mdnsGroupIPv6})
+			}
+
+			p.conn6 = conn
+			p.wg.Add(1)
+			go p.readIPv6(conn)
+		}
+	}
+}
+
+// close all sockets, needs to be called with mux locked
+func (p *NativeProvider) closeConnections() {
+	if p.conn4 != nil {
+		_ = p.conn4.Close()
+		p.conn4 = nil
+	}
+
+	if p.conn6 != nil {
+		_ = p.conn6.Close()
+		p.conn6 = nil
+	}
+}
+
+func (p *NativeProvider) readIPv4(conn *ipv4.PacketConn) {
+	defer p.wg.Done()
+
+	buf := make([]byte, 65536)
+	for {
+		n, cm, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			p.handleReadError(err, func() bool { return p.conn4 == conn }, func() { p.conn4 = nil })
+			_ = conn.Close()
+			return
+		}
+
+		ifIndex := 0
+		if cm != nil {
+			ifIndex = cm.IfIndex
+		}
+		p.handlePacket(buf[:n], ifIndex, src)
+	}
+}
+
+func (p *NativeProvider) readIPv6(conn *ipv6.PacketConn) {
+	defer p.wg.Done()
+
+	buf := make([]byte, 65536)
+	for {
+		n, cm, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			p.handleReadError(err, func() bool { return p.conn6 == conn }, func() { p.conn6 = nil })
+			_ = conn.Close()
+			return
+		}
+
+		ifIndex := 0
+		if cm != nil {
+			ifIndex = cm.IfIndex
+		}
+		p.handlePacket(buf[:n], ifIndex, src)
+	}
+}
+
+// handle a failed socket, which is reopened with the next interface check if autoReconnect is enabled
+func (p *NativeProvider) handleReadError(err error, isCurrent func() bool, reset func()) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if !p.isStarted || !isCurrent() {
+		return
+	}
+
+	logging.Log().Debug("mdns: native provider socket failed:", err)
+	reset()
+	p.interfacesChanged = true
+}
+
+// send a message via the multicast sockets
+func (p *NativeProvider) sendMessage(msg *dns.Msg, ifIndex int, addr *net.UDPAddr) {
+	buf, err := msg.Pack()
+	if err != nil {
+		logging.Log().Debug("mdns: failed to pack message:", err)
+		return
+	}
+
+	// unicast response
+	if addr != nil {
+		if addr.IP.To4() != nil && p.conn4 != nil {
+			_, _ = p.conn4.WriteTo(buf, p.controlMessage4(ifIndex), addr)
+		} else if addr.IP.To4() == nil && p.conn6 != nil {
+			_, _ = p.conn6.WriteTo(buf, p.controlMessage6(ifIndex), addr)
+		}
+		return
+	}
+
+	for index, ni := range p.interfaces {
+		if ifIndex != 0 && index != ifIndex {
+			continue
+		}
+
+		if p.conn4 != nil && len(ni.ipv4) > 0 {
+			_, _ = p.conn4.WriteTo(buf, p.controlMessage4(index), mdnsAddrIPv4)
+		}
+		if p.conn6 != nil && len(ni.ipv6) > 0 {
+			_, _ = p.conn6.WriteTo(buf, p.controlMessage6(index), mdnsAddrIPv6)
+		}
+	}
+}
+
+// returns the control message for sending on an interface
+//
+// the control message is not supported on all platforms, use the multicast interface there
+func (p *NativeProvider) controlMessage4(ifIndex int) *ipv4.ControlMessage {
+	if ifIndex == 0 {
+		return nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin", "ios", "linux":
+		return &ipv4.ControlMessage{IfIndex: ifIndex}
+	}
+
+	if ni, ok := p.interfaces[ifIndex]; ok {
+		_ = p.conn4.SetMulticastInterface(&ni.iface)
+	}
+	return nil
+}
+
+// returns the control message for sending on an interface
+//
+// the control message is not supported on all platforms, use the multicast interface there
+func (p *NativeProvider) controlMessage6(ifIndex int) *ipv6.ControlMessage {
+	if ifIndex == 0 {
+		return nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin", "ios", "linux":
+		return &ipv6.ControlMessage{IfIndex: ifIndex}
+	}
+
+	if ni, ok := p.interfaces[ifIndex]; ok {
+		_ = p.conn6.SetMulticastInterface(&ni.iface)
+	}
+	return nil
+}
+
+/* Interfaces */
+
+// returns the interfaces which should currently be used
+func (p *NativeProvider) candidateInterfaces() []net.Interface {
+	var result []net.Interface
+
+	if len(p.ifaces) > 0 {
+		for _, configured := range p.ifaces {
+			// get the current state of the interface
+			iface, err := net.InterfaceByName(configured.Name)
+			if err != nil || iface.Flags&net.FlagUp == 0 {
+				continue
+			}
+			result = append(result, *iface)
+		}
+
+		return result
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 ||
+			iface.Flags&net.FlagMulticast == 0 ||
+			iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		result = append(result, iface)
+	}
+
+	return result
+}
+
+// returns the interface with its current IPv4 and IPv6 addresses
+func newNativeInterface(iface net.Interface) *nativeInterface {
+	ni := &nativeInterface{
+		iface: iface,
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return ni
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			ni.ipv4 = append(ni.ipv4, ip4)
+		} else {
+			ni.ipv6 = append(ni.ipv6, ipNet.IP)
+		}
+	}
+
+	return ni
+}
+
+// returns the addresses of a which are not in b
+func missingAddresses(a, b []net.IP) []net.IP {
+	var result []net.IP
+
+	for _, ip := range a {
+		if !slices.ContainsFunc(b, ip.Equal) {
+			result = append(result, ip)
+		}
+	}
+
+	return result
+}
+
+// update the used interfaces and their addresses, needs to be called with mux locked
+func (p *NativeProvider) refreshInterfaces(now time.Time) {
+	p.interfacesChanged = false
+	p.nextInterfacePoll = now.Add(nativeInterfacePollInterval)
+
+	if p.autoReconnect {
+		p.openConnections()
+	}
+
+	changed := false
+	interfaces := make(map[int]*nativeInterface)
+
+	for _, iface := range p.candidateInterfaces() {
+		ni := newNativeInterface(iface)
+		interfaces[iface.Index] = ni
+
+		old, exists := p.interfaces[iface.Index]
+		if !exists {
+			p.joinGroups(&ni.iface)
+			changed = true
+			continue
+		}
+
+		removed4 := missingAddresses(old.ipv4, ni.ipv4)
+		removed6 := missingAddresses(old.ipv6, ni.ipv6)
+		if len(removed4) > 0 || len(removed6) > 0 {
+			p.sendAddressGoodbye(iface.Index, removed4, removed6)
+			changed = true
+		}
+
+		if len(missingAddresses(ni.ipv4, old.ipv4)) > 0 || len(missingAddresses(ni.ipv6, old.ipv6)) > 0 {
+			changed = true
+		}
+	}
+
+	for index, old := range p.interfaces {
+		if _, exists := interfaces[index]; !exists {
+			p.leaveGroups(&old.iface)
+			changed = true
+		}
+	}
+
+	p.interfaces = interfaces
+
+	if !changed {
+		return
+	}
+
+	logging.Log().Debug("mdns: native provider network interfaces changed")
+
+	// query and announce again on the changed interfaces
+	p.restartQueries(now)
+	p.scheduleAnnouncement(now)
+}
+
+func (p *NativeProvider) joinGroups(iface *net.Interface) {
+	if p.conn4 != nil {
+		_ = p.conn4.JoinGroup(iface, &net.UDPAddr{IP: mdnsGroupIPv4})
+	}
+	if p.conn6 != nil {
+		_ = p.conn6.JoinGroup(iface, &net.UDPAddr{IP: mdnsGroupIPv6})
+	}
+}
+
+func (p *NativeProvider) leaveGroups(iface *net.Interface) {
+	if p.conn4 != nil {
+		_ = p.conn4.LeaveGroup(iface, &net.UDPAddr{IP: mdnsGroupIPv4})
+	}
+	if p.conn6 != nil {
+		_ = p.conn6.LeaveGroup(iface, &net.UDPAddr{IP: mdnsGroupIPv6})
+	}
+}
+
+/* Receiving */
+
+// process a received packet
+func (p *NativeProvider) handlePacket(buf []byte, ifIndex int, src net.Addr) {
+	msg := new(dns.Msg)
+	if err := msg.Unpack(buf); err != nil {
+		return
+	}
+
+	// RFC 6762 18.3: messages with non zero opcode or rcode are ignored
+	if msg.Opcode != dns.OpcodeQuery || msg.Rcode != dns.RcodeSuccess {
+		return
+	}
+
+	from, _ := src.(*net.UDPAddr)
+
+	p.withLock(func() {
+		if !p.isStarted {
+			return
+		}
+
+		// ignore packets from interfaces which are not used
+		if _, ok := p.interfaces[ifIndex]; ifIndex != 0 && !ok {
+			return
+		}
+
+		now := time.Now()
+		if msg.Response {
+			p.handleResponse(msg, now)
+		} else {
+			p.handleQuery(msg, ifIndex, from)
+		}
+	})
+}
+
+// returns true if the record is relevant for browsing SHIP services
+//
+// address records are only relevant for the hosts in hosts, the lowercase targets of the SHIP SRV records
+func isRelevantRecord(rr dns.RR, hosts map[string]bool) bool {
+	name := strings.ToLower(rr.Header().Name)
+
+	switch rr.(type) {
+	case *dns.PTR:
+		return name == nativeServiceType
+	case *dns.SRV, *dns.TXT:
+		return strings.HasSuffix(name, "."+nativeServiceType)
+	case *dns.A, *dns.AAAA:
+		return hosts[name]
+	}
+
+	return false
+}
+
+// return the lowercase targets of the cached SHIP SRV records and those in records,
+// needs to be called with mux locked
+func (p *NativeProvider) serviceHosts(records []dns.RR) map[string]bool {
+	hosts := make(map[string]bool)
+
+	for _, record := range p.cache {
+		if srv, ok := record.rr.(*dns.SRV); ok {
+			hosts[strings.ToLower(srv.Target)] = true
+		}
+	}
+
+	for _, rr := range records {
+		if srv, ok := rr.(*dns.SRV); ok && isRelevantRecord(rr, nil) {
+			hosts[strings.ToLower(srv.Target)] = true
+		}
+	}
+
+	return hosts
+}
+
+// returns a key identifying the record name, type and data
+func recordKey(rr dns.RR) string {
+	hdr := rr.Header()
+	data := strings.TrimPrefix(rr.String(), hdr.String())
+
+	return strings.ToLower(hdr.Name) + "/" + dns.TypeToString[hdr.Rrtype] + "/" + data
+}
+
+// add the records of a response to the cache, needs to be called with mux locked
+func (p *NativeProvider) handleResponse(msg *dns.Msg, now time.Time) {
+	records := slices.Concat(msg.Answer, msg.Ns, msg.Extra)
+	hosts := p.serviceHosts(records)
+
+	for _, rr := range records {
+		hdr := rr.Header()
+		cacheFlush := hdr.Class&classTopBit != 0
+		hdr.Class &^= classTopBit
+
+		if hdr.Class != dns.ClassINET || !isRelevantRecord(rr, hosts) {
+			continue
+		}
+
+		// RFC 6762 10.2: records with the cache flush bit replace older records
+		if cacheFlush {
+			p.flushCache(hdr.Name, hdr.Rrtype, now)
+		}
+
+		key := recordKey(rr)
+
+		// RFC 6762 10.1: goodbye packets remove the record after one second
+		if hdr.Ttl == 0 {
+			if record, ok := p.cache[key]; ok {
+				record.expires = now.Add(time.Second)
+			}
+			continue
+		}
+
+		p.cache[key] = &cachedRecord{
+			rr:       rr,
+			received: now,
+			expires:  now.Add(time.Duration(hdr.Ttl) * time.Second),
+		}
+	}
+
+	p.updateServices(now)
+}
+
+// let records with the name and type expire, which were received more than a second ago
+func (p *NativeProvider) flushCache(name string, rrtype uint16, now time.Time) {
+	for _, record := range p.cachedRecords(name, rrtype) {
+		if now.Sub(record.received) > time.Second && record.expires.After(now.Add(time.Second)) {
+			record.expires = now.Add(time.Second)
+		}
+	}
+}
+
+// remove expired records, needs to be called with mux locked
+func (p *NativeProvider) expireCache(now time.Time) {
+	for key, record := range p.cache {
+		if !record.expires.After(now) {
+			delete(p.cache, key)
+		}
+	}
+}
+
+// returns the cached records with the name and type, most recently received first
+func (p *NativeProvider) cachedRecords(name string, rrtype uint16) []*cachedRecord {
+	var result []*cachedRecord
+
+	for _, record := range p.cache {
+		hdr := record.rr.Header()
+		if hdr.Rrtype == rrtype && strings.EqualFold(hdr.Name, name) {
+			result = append(result, record)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b *cachedRecord) int {
+		return b.received.Compare(a.received)
+	})
+
+	return result
+}
+
+// resolve a service instance from the cache
+//
+// returns false if the service is incomplete
+func (p *NativeProvider) resolveService(instance string) (*nativeService, bool) {
+	service := &nativeService{
+		instance: instance,
+	}
+
+	srvs := p.cachedRecords(instance, dns.TypeSRV)
+	if len(srvs) > 0 {
+		srv := srvs[0].rr.(*dns.SRV)
+		service.host = srv.Target
+		service.port = int(srv.Port)
+	}
+
+	txts := p.cachedRecords(instance, dns.TypeTXT)
+	if len(txts) > 0 {
+		for _, item := range txts[0].rr.(*dns.TXT).Txt {
+			service.txt = append(service.txt, unescapeText(item))
+		}
+	}
+
+	if len(service.host) > 0 {
+		for _, record := range p.cachedRecords(service.host, dns.TypeA) {
+			service.addresses = append(service.addresses, record.rr.(*dns.A).A)
+		}
+		for _, record := range p.cachedRecords(service.host, dns.TypeAAAA) {
+			service.addresses = append(service.addresses, record.rr.(*dns.AAAA).AAAA)
+		}
+		slices.SortFunc(service.addresses, func(a, b net.IP) int {
+			return bytes.Compare(a.To16(), b.To16())
+		})
+	}
+
+	return service, len(srvs) > 0 && len(txts) > 0 && len(service.addresses) > 0
+}
+
+// resolve all browsed services and report changes, needs to be called with mux locked
+func (p *NativeProvider) updateServices(now time.Time) {
+	current := make(map[string]*nativeService)
+	browsed := make(map[string]bool)
+
+	for _, record := range p.cachedRecords(nativeServiceType, dns.TypePTR) {
+		instance := record.rr.(*dns.PTR).Ptr
+		key := strings.ToLower(instance)
+		browsed[key] = true
+
+		service, complete := p.resolveService(instance)
+		if !complete {
+			p.queryService(key, service, now)
+			continue
+		}
+
+		delete(p.resolveQueries, key)
+		current[key] = service
+	}
+
+	for key, service := range current {
+		if reported, ok := p.services[key]; ok && reported.equal(service) {
+			continue
+		}
+
+		p.services[key] = service
+		p.report(service, false)
+	}
+
+	for key, reported := range p.services {
+		if _, ok := current[key]; ok {
+			continue
+		}
+
+		delete(p.services, key)
+		p.report(reported, true)
+	}
+
+	// forget resolve queries of services which are no longer browsed
+	for key := range p.resolveQueries {
+		if !browsed[key] {
+			delete(p.resolveQueries, key)
+		}
+	}
+}
+
+// queue the callback for a service, needs to be called with mux locked
+func (p *NativeProvider) report(service *nativeService, remove bool) {
+	if p.cb == nil {
+		return
+	}
+
+	cb := p.cb
+	elements := parseTxt(service.txt)
+	name := instanceName(service.instance)
+	addresses := slices.Clone(service.addresses)
+
+	p.pendingCallbacks = append(p.pendingCallbacks, func() {
+		cb(elements, name, service.host, addresses, service.port, remove)
+	})
+}
+
+/* Querying */
+
+// send the browse query for SHIP services including known answers, RFC 6762 7.1
+func (p *NativeProvider) sendBrowseQuery(now time.Time) {
+	msg := new(dns.Msg)
+	msg.Question = []dns.Question{{Name: nativeServiceType, Qtype: dns.TypePTR, Qclass: dns.ClassINET}}
+
+	for _, record := range p.cachedRecords(nativeServiceType, dns.TypePTR) {
+		remaining := record.expires.Sub(now)
+		if remaining*2 <= record.expires.Sub(record.received) {
+			continue
+		}
+
+		rr := dns.Copy(record.rr)
+		rr.Header().Ttl = uint32(remaining.Seconds()) // #nosec G115
+		msg.Answer = append(msg.Answer, rr)
+	}
+
+	p.send(msg, 0, nil)
+}
+
+// send a query for the missing records of an incomplete service, needs to be called with mux locked
+func (p *NativeProvider) queryService(key string, service *nativeService, now time.Time) {
+	if last, ok := p.resolveQueries[key]; ok && now.Sub(last) < nativeResolveInterval {
+		return
+	}
+	p.resolveQueries[key] = now
+
+	msg := new(dns.Msg)
+	msg.Question = []dns.Question{
+		{Name: service.instance, Qtype: dns.TypeSRV, Qclass: dns.ClassINET},
+		{Name: service.instance, Qtype: dns.TypeTXT, Qclass: dns.ClassINET},
+	}
+	if len(service.host) > 0 {
+		msg.Question = append(msg.Question,
+			dns.Question{Name: service.host, Qtype: dns.TypeA, Qclass: dns.ClassINET},
+			dns.Question{Name: service.host, Qtype: dns.TypeAAAA, Qclass: dns.ClassINET},
+		)
+	}
+
+	p.send(msg, 0, nil)
+}
+
+// query records of browsed services before they expire, RFC 6762 5.2
+func (p *NativeProvider) refreshRecords(now time.Time) {
+	names := make(map[string]bool)
+	names[nativeServiceType] = true
+	for _, service := range p.services {
+		names[strings.ToLower(service.instance)] = true
+		names[strings.ToLower(service.host)] = true
+	}
+
+	msg := new(dns.Msg)
+	for _, record := range p.cache {
+		hdr := record.rr.Header()
+		if !names[strings.ToLower(hdr.Name)] || record.refreshes >= len(nativeRefreshFractions) {
+			continue
+		}
+
+		lifetime := record.expires.Sub(record.received)
+		if now.Sub(record.received) < time.Duration(float64(lifetime)*nativeRefreshFractions[record.refreshes]) {
+			continue
+		}
+		record.refreshes++
+
+		question := dns.Question{Name: hdr.Name, Qtype: hdr.Rrtype, Qclass: dns.ClassINET}
+		if !slices.Contains(msg.Question, question) {
+			msg.Question = append(msg.Question, question)
+		}
+	}
+
+	if len(msg.Question) > 0 {
+		p.send(msg, 0, nil)
+	}
+}
+
+/* Responding */
+
+// returns the records of the announced service for an interface
+func (p *NativeProvider) announcementRecords(ifIndex int, ttl uint32) (ptr, srv, txt dns.RR, addresses []dns.RR) {
+	a := p.announcement
+
+	header := func(name string, rrtype uint16, unique bool) dns.RR_Header {
+		class := uint16(dns.ClassINET)
+		if unique {
+			class |= classTopBit
+		}
+		return dns.RR_Header{Name: name, Rrtype: rrtype, Class: class, Ttl: ttl}
+	}
+
+	ptr = &dns.PTR{Hdr: header(nativeServiceType, dns.TypePTR, false), Ptr: a.instance}
+	srv = &dns.SRV{Hdr: header(a.instance, dns.TypeSRV, true), Port: uint16(a.port), Target: p.hostname} // #nosec G115
+
+	var txtItems []string
+	for _, item := range a.txt {
+		txtItems = append(txtItems, strings.ReplaceAll(item, `\`, `\\`))
+	}
+	txt = &dns.TXT{Hdr: header(a.instance, dns.TypeTXT, true), Txt: txtItems}
+
+	for index, ni := range p.interfaces {
+		if ifIndex != 0 && index != ifIndex {
+			continue
+		}
+
+		for _, ip := range ni.ipv4 {
+			addresses = append(addresses, &dns.A{Hdr: header(p.hostname, dns.TypeA, true), A: ip})
+		}
+		for _, ip := range ni.ipv6 {
+			addresses = append(addresses, &dns.AAAA{Hdr: header(p.hostname, dns.TypeAAAA, true), AAAA: ip})
+		}
+	}
+
+	return ptr, srv, txt, addresses
+}
+
+// returns the unsolicited announcement message
+func (p *NativeProvider) announcementMessage() *dns.Msg {
+	ptr, srv, txt, addresses := p.announcementRecords(0, nativeRecordTTL)
+
+	msg := new(dns.Msg)
+	msg.Response = true
+	msg.Authoritative = true
+	msg.Answer = append([]dns.RR{ptr, srv, txt}, addresses...)
+
+	return msg
+}
+
+// send goodbye packets for the announced service, needs to be called with mux locked
+func (p *NativeProvider) sendGoodbye() {
+	ptr, srv, txt, _ := p.announcementRecords(0, 0)
+
+	msg := new(dns.Msg)
+	msg.Response = true
+	msg.Authoritative = true
+	msg.Answer = []dns.RR{ptr, srv, txt}
+
+	p.send(msg, 0, nil)
+}
+
+// send goodbye packets for removed addresses of an interface, needs to be called with mux locked
+func (p *NativeProvider) sendAddressGoodbye(ifIndex int, ipv4s, ipv6s []net.IP) {
+	if p.announcement == nil {
+		return
+	}
+
+	msg := new(dns.Msg)
+	msg.Response = true
+	msg.Authoritative = true
+
+	for _, ip := range ipv4s {
+		msg.Answer = append(msg.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: p.hostname, Rrtype: dns.TypeA, Class: dns.ClassINET}, A: ip})
+	}
+	for _, ip := range ipv6s {
+		msg.Answer = append(msg.Answer, &dns.AAAA{
+			Hdr: dns.RR_Header{Name: p.hostname, Rrtype: dns.TypeAAAA, Class: dns.ClassINET}, AAAA: ip})
+	}
+
+	p.send(msg, ifIndex, nil)
+}
+
+// answer a query for the announced service, needs to be called with mux locked
+func (p *NativeProvider) handleQuery(query *dns.Msg, ifIndex int, from *net.UDPAddr) {
+	if p.announcement == nil {
+		return
+	}
+
+	ptr, srv, txt, addresses := p.announcementRecords(ifIndex, nativeRecordTTL)
+	enumeration := &dns.PTR{
+		Hdr: dns.RR_Header{Name: nativeServiceEnumeration, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: nativeRecordTTL},
+		Ptr: nativeServiceType,
+	}
+
+	var answers, extras []dns.RR
+	unicast := false
+
+	add := func(list *[]dns.RR, rrs ...dns.RR) {
+		for _, rr := range rrs {
+			if !slices.Contains(answers, rr) && !slices.Contains(extras, rr) && !isKnownAnswer(query, rr) {
+				*list = append(*list, rr)
+			}
+		}
+	}
+
+	for _, question := range query.Question {
+		if question.Qclass&classTopBit != 0 {
+			unicast = true
+		}
+
+		matches := func(rr dns.RR) bool {
+			hdr := rr.Header()
+			return strings.EqualFold(hdr.Name, question.Name) &&
+				(question.Qtype == dns.TypeANY || question.Qtype == hdr.Rrtype)
+		}
+
+		if matches(enumeration) {
+			add(&answers, enumeration)
+		}
+		if matches(ptr) {
+			add(&answers, ptr)
+			add(&extras, srv, txt)
+			add(&extras, addresses...)
+		}
+		if matches(srv) {
+			add(&answers, srv)
+			add(&extras, addresses...)
+		}
+		if matches(txt) {
+			add(&answers, txt)
+		}
+		for _, address := range addresses {
+			if matches(address) {
+				add(&answers, address)
+			}
+		}
+	}
+
+	if len(answers) == 0 {
+		return
+	}
+
+	msg := new(dns.Msg)
+	msg.Response = true
+	msg.Authoritative = true
+	msg.Answer = answers
+	msg.Extra = extras
+
+	// RFC 6762 6.7: legacy unicast queries from a port other than 5353
+	if from != nil && from.Port != mdnsPort {
+		msg.Id = query.Id
+		msg.Question = query.Question
+		for _, rr := range slices.Concat(msg.Answer, msg.Extra) {
+			rr.Header().Class &^= classTopBit
+			rr.Header().Ttl = min(rr.Header().Ttl, 10)
+		}
+		p.send(msg, ifIndex, from)
+		return
+	}
+
+	// RFC 6762 5.4: the unicast response bit requests a unicast response
+	if unicast && from != nil {
+		p.send(msg, ifIndex, from)
+		return
+	}
+
+	p.send(msg, ifIndex, nil)
+}
+
+// returns true if the query contains the record as known answer with at least half the TTL, RFC 6762 7.1
+func isKnownAnswer(query *dns.Msg, rr dns.RR) bool {
+	key := recordKey(withoutTopBit(rr))
+
+	for _, known := range query.Answer {
+		if known.Header().Ttl*2 >= rr.Header().Ttl && recordKey(withoutTopBit(known)) == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns a copy of the record without the cache flush bit and the TTL, used for comparing records
+func withoutTopBit(rr dns.RR) dns.RR {
+	result := dns.Copy(rr)
+	result.Header().Class &^= classTopBit
+	result.Header().Ttl = 0
+
+	return result
+}
+
+/* Names */
+
+// returns the local host name in the .local domain
+func nativeHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil || len(hostname) == 0 {
+		hostname = "ship"
+	}
+
+	// only use the first label of the host name
+	hostname, _, _ = strings.Cut(hostname, ".")
+
+	return escapeLabel(hostname) + "." + shipZeroConfDomain
+}
+
+// escape a DNS label in the presentation format used by the dns package
+func escapeLabel(label string) string {
+	var result strings.Builder
+
+	for i := 0; i < len(label); i++ {
+		b := label[i]
+		switch {
+		case b == '.' || b == ' ' || b == '\'' || b == '@' || b == ';' || b == '(' || b == ')' || b == '"' || b == '\\':
+			result.WriteByte('\\')
+			result.WriteByte(b)
+		case b < ' ' || b > '~':
+			result.WriteByte('\\')
+			result.WriteByte('0' + b/100)
+			result.WriteByte('0' + b/10%10)
+			result.WriteByte('0' + b%10)
+		default:
+			result.WriteByte(b)
+		}
+	}
+
+	return result.String()
+}
+
+// unescape a label or text in the presentation format used by the dns package
+func unescapeText(text string) string {
+	var result []byte
+
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		if b != '\\' || i+1 >= len(text) {
+			result = append(result, b)
+			continue
+		}
+
+		if i+3 < len(text) && isDigit(text[i+1]) && isDigit(text[i+2]) && isDigit(text[i+3]) {
+			value := int(text[i+1]-'0')*100 + int(text[i+2]-'0')*10 + int(text[i+3]-'0')
+			if value <= 255 {
+				result = append(result, byte(value))
+				i += 3
+				continue
+			}
+		}
+
+		result = append(result, text[i+1])
+		i++
+	}
+
+	return string(result)
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// returns the unescaped instance name of a fully qualified service instance name
+func instanceName(instance string) string {
+	suffix := "." + nativeServiceType
+	if len(instance) > len(suffix) && strings.EqualFold(instance[len(instance)-len(suffix):], suffix) {
+		instance = instance[:len(instance)-len(suffix)]
+	}
+
+	return unescapeText(instance)
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package mdns
+
+import (
+	"context"
+	"errors"
+
+	"github.com/enbility/ship-go/logging"
+	"golang.org/x/sys/unix"
+)
+
+// watch for network interface and address changes via netlink
+//
+// changed is invoked for every change notification until ctx is done
+func watchInterfaceChanges(ctx context.Context, changed func()) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		logging.Log().Debug("mdns: netlink socket failed, polling interface changes:", err)
+		return
+	}
+	defer unix.Close(fd)
+
+	addr := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: unix.RTMGRP_LINK | unix.RTMGRP_IPV4_IFADDR | unix.RTMGRP_IPV6_IFADDR,
+	}
+	if err := unix.Bind(fd, addr); err != nil {
+		logging.Log().Debug("mdns: netlink bind failed, polling interface changes:", err)
+		return
+	}
+
+	// use a receive timeout to check for the end of the context
+	timeout := unix.Timeval{Sec: 1}
+	_ = unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &timeout)
+
+	buf := make([]byte, 8192)
+	for ctx.Err() == nil {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+
+			logging.Log().Debug("mdns: netlink receive failed, polling interface changes:", err)
+			return
+		}
+
+		if n > 0 {
+			changed()
+		}
+	}
+}
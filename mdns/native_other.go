@@ -0,0 +1,8 @@
+//go:build !linux
+
+package mdns
+
+import "context"
+
+// interface changes are only detected by polling on this platform
+func watchInterfaceChanges(_ context.Context, _ func()) {}
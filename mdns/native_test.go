@@ -0,0 +1,473 @@
+package mdns
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestNativeSuite(t *testing.T) {
+	suite.Run(t, new(NativeSuite))
+}
+
+type nativeReport struct {
+	entry  mDNSEntry
+	remove bool
+}
+
+type nativeSent struct {
+	msg     *dns.Msg
+	ifIndex int
+	addr    *net.UDPAddr
+}
+
+type NativeSuite struct {
+	suite.Suite
+
+	sut *NativeProvider
+
+	reports []nativeReport
+	sent    []nativeSent
+
+	mux sync.Mutex
+}
+
+const nativeTestInstance = `Test\ Device._ship._tcp.local.`
+
+func (s *NativeSuite) BeforeTest(suiteName, testName string) {
+	s.reports = nil
+	s.sent = nil
+
+	s.sut = NewNativeProvider(nil)
+	s.sut.hostname = "local-host.local."
+	s.sut.cb = func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+
+		s.reports = append(s.reports, nativeReport{
+			entry: mDNSEntry{
+				elements:  elements,
+				name:      name,
+				host:      host,
+				addresses: addresses,
+				port:      port,
+			},
+			remove: remove,
+		})
+	}
+	s.sut.send = func(msg *dns.Msg, ifIndex int, addr *net.UDPAddr) {
+		s.sent = append(s.sent, nativeSent{msg: msg, ifIndex: ifIndex, addr: addr})
+	}
+
+	// simulate a started provider without sockets
+	s.sut.isStarted = true
+	s.sut.ctx, s.sut.cancel = context.WithCancel(context.Background())
+	s.sut.interfaces[1] = &nativeInterface{
+		iface: net.Interface{Index: 1, Name: "test0"},
+		ipv4:  []net.IP{net.ParseIP("192.168.1.10").To4()},
+		ipv6:  []net.IP{net.ParseIP("fd00::10")},
+	}
+	s.sut.nextInterfacePoll = time.Now().Add(time.Hour)
+}
+
+func (s *NativeSuite) AfterTest(suiteName, testName string) {
+	s.sut.Shutdown()
+}
+
+func (s *NativeSuite) lastReports() []nativeReport {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.reports
+}
+
+func (s *NativeSuite) response(rrs ...dns.RR) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.Response = true
+	msg.Answer = rrs
+
+	return msg
+}
+
+func (s *NativeSuite) handle(msg *dns.Msg, ifIndex int, from *net.UDPAddr) {
+	buf, err := msg.Pack()
+	assert.Nil(s.T(), err)
+
+	if from == nil {
+		from = &net.UDPAddr{IP: net.ParseIP("192.168.1.20"), Port: mdnsPort}
+	}
+	s.sut.handlePacket(buf, ifIndex, from)
+}
+
+func nativeHeader(name string, rrtype uint16, ttl uint32) dns.RR_Header {
+	return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+}
+
+func servicePTR(ttl uint32) dns.RR {
+	return &dns.PTR{Hdr: nativeHeader(nativeServiceType, dns.TypePTR, ttl), Ptr: nativeTestInstance}
+}
+
+func serviceSRV(ttl uint32, port uint16) dns.RR {
+	return &dns.SRV{Hdr: nativeHeader(nativeTestInstance, dns.TypeSRV, ttl), Port: port, Target: "remote.local."}
+}
+
+func serviceTXT(ttl uint32, txt ...string) dns.RR {
+	return &dns.TXT{Hdr: nativeHeader(nativeTestInstance, dns.TypeTXT, ttl), Txt: txt}
+}
+
+func hostA(ttl uint32, ip string) dns.RR {
+	return &dns.A{Hdr: nativeHeader("remote.local.", dns.TypeA, ttl), A: net.ParseIP(ip).To4()}
+}
+
+func hostAAAA(ttl uint32, ip string) dns.RR {
+	return &dns.AAAA{Hdr: nativeHeader("remote.local.", dns.TypeAAAA, ttl), AAAA: net.ParseIP(ip)}
+}
+
+func (s *NativeSuite) Test_Names() {
+	assert.Equal(s.T(), `My\ Device\.1\\\195\164`, escapeLabel("My Device.1\\ä"))
+	assert.Equal(s.T(), "My Device.1\\ä", unescapeText(escapeLabel("My Device.1\\ä")))
+	assert.Equal(s.T(), "Test Device", instanceName(nativeTestInstance))
+	assert.Equal(s.T(), "other", instanceName("other"))
+}
+
+func (s *NativeSuite) Test_Resolve() {
+	s.handle(s.response(servicePTR(120), serviceSRV(120, 4712), serviceTXT(120, "txtvers=1", "brand=Bä"),
+		hostA(120, "192.168.1.20"), hostAAAA(120, "fd00::20")), 1, nil)
+
+	reports := s.lastReports()
+	if assert.Equal(s.T(), 1, len(reports)) {
+		assert.False(s.T(), reports[0].remove)
+		assert.Equal(s.T(), "Test Device", reports[0].entry.name)
+		assert.Equal(s.T(), "remote.local.", reports[0].entry.host)
+		assert.Equal(s.T(), 4712, reports[0].entry.port)
+		assert.Equal(s.T(), "Bä", reports[0].entry.elements["brand"])
+		assert.Equal(s.T(), 2, len(reports[0].entry.addresses))
+	}
+
+	// the same records again do not cause a report
+	s.handle(s.response(servicePTR(120), serviceSRV(120, 4712)), 1, nil)
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+
+	// packets from unknown interfaces are ignored
+	s.handle(s.response(serviceSRV(120, 4713)), 2, nil)
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+}
+
+func (s *NativeSuite) Test_AddressRecords() {
+	// address records of hosts without a SHIP service are not cached
+	other := &dns.A{Hdr: nativeHeader("printer.local.", dns.TypeA, 120), A: net.ParseIP("192.168.1.30").To4()}
+	s.handle(s.response(other, hostA(120, "192.168.1.20")), 1, nil)
+	assert.Equal(s.T(), 0, len(s.sut.cache))
+
+	s.handle(s.response(serviceSRV(120, 4712)), 1, nil)
+	s.handle(s.response(other, hostA(120, "192.168.1.20")), 1, nil)
+	assert.Equal(s.T(), 2, len(s.sut.cache))
+	assert.Equal(s.T(), 1, len(s.sut.cachedRecords("remote.local.", dns.TypeA)))
+}
+
+func (s *NativeSuite) Test_Resolve_Incomplete() {
+	s.handle(s.response(servicePTR(120)), 1, nil)
+	assert.Equal(s.T(), 0, len(s.lastReports()))
+
+	// the missing records are queried
+	if assert.Equal(s.T(), 1, len(s.sent)) {
+		assert.Equal(s.T(), 2, len(s.sent[0].msg.Question))
+		assert.Equal(s.T(), dns.TypeSRV, s.sent[0].msg.Question[0].Qtype)
+	}
+
+	// queries are rate limited
+	s.handle(s.response(serviceSRV(120, 4712), serviceTXT(120, "txtvers=1")), 1, nil)
+	assert.Equal(s.T(), 1, len(s.sent))
+	assert.Equal(s.T(), 0, len(s.lastReports()))
+
+	s.sut.withLock(func() {
+		s.sut.tick(time.Now().Add(nativeResolveInterval))
+	})
+	var addressQuery bool
+	for _, sent := range s.sent {
+		for _, question := range sent.msg.Question {
+			if question.Qtype == dns.TypeAAAA && question.Name == "remote.local." {
+				addressQuery = true
+			}
+		}
+	}
+	assert.True(s.T(), addressQuery)
+
+	// IPv6 only
+	s.handle(s.response(hostAAAA(120, "fd00::20")), 1, nil)
+	reports := s.lastReports()
+	if assert.Equal(s.T(), 1, len(reports)) {
+		assert.Equal(s.T(), []net.IP{net.ParseIP("fd00::20")}, reports[0].entry.addresses)
+	}
+}
+
+func (s *NativeSuite) Test_Goodbye() {
+	s.handle(s.response(servicePTR(120), serviceSRV(120, 4712), serviceTXT(120, "txtvers=1"),
+		hostA(120, "192.168.1.20")), 1, nil)
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+
+	s.handle(s.response(servicePTR(0)), 1, nil)
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+
+	// the goodbye takes effect after one second
+	s.sut.withLock(func() {
+		s.sut.tick(time.Now().Add(2 * time.Second))
+	})
+
+	reports := s.lastReports()
+	if assert.Equal(s.T(), 2, len(reports)) {
+		assert.True(s.T(), reports[1].remove)
+		assert.Equal(s.T(), "Test Device", reports[1].entry.name)
+		assert.Equal(s.T(), "1", reports[1].entry.elements["txtvers"])
+	}
+}
+
+func (s *NativeSuite) Test_TTLExpiry() {
+	s.handle(s.response(servicePTR(120), serviceSRV(120, 4712), serviceTXT(120, "txtvers=1"),
+		hostA(10, "192.168.1.20"), hostAAAA(120, "fd00::20")), 1, nil)
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+
+	// the A record expires, the service is updated
+	s.sut.withLock(func() {
+		s.sut.tick(time.Now().Add(11 * time.Second))
+	})
+	reports := s.lastReports()
+	if assert.Equal(s.T(), 2, len(reports)) {
+		assert.False(s.T(), reports[1].remove)
+		assert.Equal(s.T(), []net.IP{net.ParseIP("fd00::20")}, reports[1].entry.addresses)
+	}
+
+	// all records expire
+	s.sut.withLock(func() {
+		s.sut.tick(time.Now().Add(121 * time.Second))
+	})
+	reports = s.lastReports()
+	if assert.Equal(s.T(), 3, len(reports)) {
+		assert.True(s.T(), reports[2].remove)
+	}
+}
+
+func (s *NativeSuite) Test_CacheFlush() {
+	s.handle(s.response(servicePTR(120), serviceSRV(120, 4712), serviceTXT(120, "txtvers=1", "register=false"),
+		hostA(120, "192.168.1.20")), 1, nil)
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+
+	// make the records older than one second
+	for _, record := range s.sut.cache {
+		record.received = record.received.Add(-2 * time.Second)
+	}
+
+	txt := serviceTXT(120, "txtvers=1", "register=true")
+	txt.Header().Class |= classTopBit
+	s.handle(s.response(txt), 1, nil)
+
+	s.sut.withLock(func() {
+		s.sut.tick(time.Now().Add(2 * time.Second))
+	})
+
+	reports := s.lastReports()
+	if assert.Equal(s.T(), 2, len(reports)) {
+		assert.Equal(s.T(), "true", reports[1].entry.elements["register"])
+	}
+}
+
+func (s *NativeSuite) Test_Refresh() {
+	s.handle(s.response(servicePTR(100), serviceSRV(100, 4712), serviceTXT(100, "txtvers=1"),
+		hostA(100, "192.168.1.20")), 1, nil)
+	s.sent = nil
+
+	s.sut.withLock(func() {
+		s.sut.nextQuery = time.Now().Add(time.Hour)
+		s.sut.tick(time.Now().Add(81 * time.Second))
+	})
+
+	if assert.Equal(s.T(), 1, len(s.sent)) {
+		assert.Equal(s.T(), 4, len(s.sent[0].msg.Question))
+	}
+
+	// each refresh stage is only queried once
+	s.sut.withLock(func() {
+		s.sut.tick(time.Now().Add(82 * time.Second))
+	})
+	assert.Equal(s.T(), 1, len(s.sent))
+}
+
+func (s *NativeSuite) Test_BrowseQuery() {
+	s.handle(s.response(servicePTR(120)), 1, nil)
+	s.sent = nil
+
+	s.sut.withLock(func() {
+		s.sut.restartQueries(time.Now())
+		s.sut.tick(time.Now())
+	})
+
+	var browse *dns.Msg
+	for _, sent := range s.sent {
+		if sent.msg.Question[0].Qtype == dns.TypePTR {
+			browse = sent.msg
+		}
+	}
+	if assert.NotNil(s.T(), browse) {
+		// the known answer is included
+		assert.Equal(s.T(), 1, len(browse.Answer))
+	}
+	assert.Equal(s.T(), 2*time.Second, s.sut.queryInterval)
+}
+
+func (s *NativeSuite) Test_RequeryServices() {
+	s.handle(s.response(servicePTR(120), serviceSRV(120, 4712), serviceTXT(120, "txtvers=1"),
+		hostA(120, "192.168.1.20")), 1, nil)
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+
+	s.sut.withLock(func() {
+		s.sut.queryInterval = time.Minute
+		s.sut.nextQuery = time.Now().Add(time.Minute)
+	})
+
+	// the known service is reported again and the queries are restarted
+	s.sut.Query()
+
+	reports := s.lastReports()
+	if assert.Equal(s.T(), 2, len(reports)) {
+		assert.False(s.T(), reports[1].remove)
+		assert.Equal(s.T(), "Test Device", reports[1].entry.name)
+	}
+	assert.Equal(s.T(), time.Second, s.sut.queryInterval)
+
+	s.sut.Shutdown()
+	s.sut.Query()
+	assert.Equal(s.T(), 2, len(s.lastReports()))
+}
+
+func (s *NativeSuite) Test_Announce() {
+	err := s.sut.Announce("", 4711, nil)
+	assert.NotNil(s.T(), err)
+
+	err = s.sut.Announce("Local Device", 4711, []string{"txtvers=1"})
+	assert.Nil(s.T(), err)
+
+	s.sut.withLock(func() {
+		s.sut.nextQuery = time.Now().Add(time.Hour)
+		s.sut.tick(time.Now())
+	})
+	if assert.Equal(s.T(), 1, len(s.sent)) {
+		// PTR, SRV, TXT, A and AAAA
+		assert.Equal(s.T(), 5, len(s.sent[0].msg.Answer))
+		assert.True(s.T(), s.sent[0].msg.Response)
+	}
+
+	// the announcement is repeated once
+	s.sut.withLock(func() {
+		s.sut.tick(time.Now().Add(time.Second))
+		s.sut.tick(time.Now().Add(10 * time.Second))
+	})
+	assert.Equal(s.T(), 2, len(s.sent))
+
+	// the same service name does not send a goodbye
+	err = s.sut.Announce("Local Device", 4711, []string{"txtvers=1", "register=true"})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(s.sent))
+
+	s.sut.Unannounce()
+	if assert.Equal(s.T(), 3, len(s.sent)) {
+		for _, rr := range s.sent[2].msg.Answer {
+			assert.Equal(s.T(), uint32(0), rr.Header().Ttl)
+		}
+	}
+
+	s.sut.Unannounce()
+	assert.Equal(s.T(), 3, len(s.sent))
+}
+
+func (s *NativeSuite) Test_Query() {
+	query := new(dns.Msg)
+	query.SetQuestion(nativeServiceType, dns.TypePTR)
+	query.Id = 0
+
+	// no response without announcement
+	s.handle(query, 1, nil)
+	assert.Equal(s.T(), 0, len(s.sent))
+
+	_ = s.sut.Announce("Local Device", 4711, []string{"txtvers=1"})
+
+	s.handle(query, 1, nil)
+	if assert.Equal(s.T(), 1, len(s.sent)) {
+		assert.Nil(s.T(), s.sent[0].addr)
+		assert.Equal(s.T(), 1, s.sent[0].ifIndex)
+		assert.Equal(s.T(), 1, len(s.sent[0].msg.Answer))
+		assert.Equal(s.T(), 4, len(s.sent[0].msg.Extra))
+	}
+
+	// known answer suppression
+	known := new(dns.Msg)
+	known.SetQuestion(nativeServiceType, dns.TypePTR)
+	known.Answer = []dns.RR{&dns.PTR{Hdr: nativeHeader(nativeServiceType, dns.TypePTR, 100), Ptr: `Local\ Device._ship._tcp.local.`}}
+	s.handle(known, 1, nil)
+	assert.Equal(s.T(), 1, len(s.sent))
+
+	// unicast response requested
+	unicast := new(dns.Msg)
+	unicast.Question = []dns.Question{{Name: `local\ device._ship._tcp.local.`, Qtype: dns.TypeTXT, Qclass: dns.ClassINET | classTopBit}}
+	s.handle(unicast, 1, nil)
+	if assert.Equal(s.T(), 2, len(s.sent)) {
+		assert.NotNil(s.T(), s.sent[1].addr)
+		assert.Equal(s.T(), dns.TypeTXT, s.sent[1].msg.Answer[0].Header().Rrtype)
+	}
+
+	// legacy unicast query
+	legacy := new(dns.Msg)
+	legacy.SetQuestion("local-host.local.", dns.TypeA)
+	s.handle(legacy, 1, &net.UDPAddr{IP: net.ParseIP("192.168.1.20"), Port: 12345})
+	if assert.Equal(s.T(), 3, len(s.sent)) {
+		assert.Equal(s.T(), 12345, s.sent[2].addr.Port)
+		assert.Equal(s.T(), legacy.Id, s.sent[2].msg.Id)
+		assert.Equal(s.T(), uint32(10), s.sent[2].msg.Answer[0].Header().Ttl)
+	}
+
+	// service enumeration
+	enumeration := new(dns.Msg)
+	enumeration.SetQuestion(nativeServiceEnumeration, dns.TypePTR)
+	s.handle(enumeration, 1, nil)
+	if assert.Equal(s.T(), 4, len(s.sent)) {
+		assert.Equal(s.T(), nativeServiceType, s.sent[3].msg.Answer[0].(*dns.PTR).Ptr)
+	}
+}
+
+func (s *NativeSuite) Test_InterfaceChanges() {
+	_ = s.sut.Announce("Local Device", 4711, []string{"txtvers=1"})
+
+	s.sut.withLock(func() {
+		s.sut.announcement.remaining = 0
+		s.sut.interfaces[1].ipv4 = append(s.sut.interfaces[1].ipv4, net.ParseIP("192.168.1.11").To4())
+		s.sut.ifaces = []net.Interface{{Name: "noifacename"}}
+		s.sut.refreshInterfaces(time.Now())
+	})
+
+	// the interface is gone
+	assert.Equal(s.T(), 0, len(s.sut.interfaces))
+	assert.Equal(s.T(), nativeAnnouncementCount, s.sut.announcement.remaining)
+	assert.Equal(s.T(), time.Second, s.sut.queryInterval)
+}
+
+func (s *NativeSuite) Test_StartShutdown() {
+	sut := NewNativeProvider(nil)
+
+	// the sockets may not be available, which is handled with autoReconnect
+	assert.True(s.T(), sut.Start(true, func(map[string]string, string, string, []net.IP, int, bool) {}))
+	assert.True(s.T(), sut.Start(true, nil))
+	_ = sut.Announce("Test Start", 4711, []string{"txtvers=1"})
+
+	time.Sleep(time.Millisecond * 500)
+
+	sut.Shutdown()
+	sut.Shutdown()
+
+	assert.True(s.T(), sut.Start(true, nil))
+	sut.Shutdown()
+}
@@ -0,0 +1,109 @@
+package mdns
+
+import (
+	"net"
+	"slices"
+
+	"github.com/enbility/ship-go/api"
+)
+
+// The names of the built-in providers, as reported in api.MdnsEntry.Providers
+const (
+	MdnsProviderNameAvahi    = "avahi"
+	MdnsProviderNameZeroconf = "zeroconf"
+	MdnsProviderNameNative   = "native"
+	MdnsProviderNameUnicast  = "unicast"
+
+	// static entries added via AddStaticEntry
+	MdnsProviderNameStatic = "static"
+)
+
+// a provider with the name its results are tracked with
+type namedMdnsProvider struct {
+	name     string
+	provider api.MdnsProviderInterface
+}
+
+// Add a provider running concurrently to the provider chosen by the provider selection,
+// e.g. a UnicastProvider for discovery across routed networks.
+// The results of all providers are merged by SKI.
+//
+// The name has to be unique and is reported in api.MdnsEntry.Providers.
+// Needs to be invoked before Start
+func (m *MdnsManager) AddProvider(name string, provider api.MdnsProviderInterface) {
+	m.additionalProviders = append(m.additionalProviders, namedMdnsProvider{
+		name:     name,
+		provider: provider,
+	})
+}
+
+// set the provider chosen by the provider selection
+func (m *MdnsManager) setMdnsProvider(provider api.MdnsProviderInterface) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.mdnsProvider = provider
+}
+
+// return all running providers
+func (m *MdnsManager) providers() []api.MdnsProviderInterface {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.mdnsProvider == nil {
+		return nil
+	}
+
+	providers := []api.MdnsProviderInterface{m.mdnsProvider}
+	for _, additional := range m.additionalProviders {
+		providers = append(providers, additional.provider)
+	}
+
+	return providers
+}
+
+// return the resolve callback for a provider
+func (m *MdnsManager) resolveCB(provider string) api.MdnsResolveCB {
+	return func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+		m.processMdnsEntry(provider, elements, name, host, addresses, port, remove)
+	}
+}
+
+// merge the entries reported by the providers for a SKI
+//
+// the service data is taken from the most recently seen report,
+// the addresses of all reports are combined.
+// returns nil if there are no reports
+func mergeMdnsEntries(sources map[string]*api.MdnsEntry) *api.MdnsEntry {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var names []string
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var latest *api.MdnsEntry
+	for _, name := range names {
+		if source := sources[name]; latest == nil || source.LastSeen.After(latest.LastSeen) {
+			latest = source
+		}
+	}
+
+	merged := *latest
+	merged.Categories = slices.Clone(latest.Categories)
+	merged.Addresses = nil
+	merged.Providers = names
+
+	for _, name := range names {
+		for _, address := range sources[name].Addresses {
+			if !slices.ContainsFunc(merged.Addresses, address.Equal) {
+				merged.Addresses = append(merged.Addresses, address)
+			}
+		}
+	}
+
+	return &merged
+}
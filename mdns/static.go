@@ -0,0 +1,252 @@
+package mdns
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/enbility/ship-go/util"
+)
+
+// Set the loader used to persist the static entries
+//
+// The persisted entries are loaded immediately and every change of the static
+// entries is saved via the loader.
+// Should be invoked before Start and before any static entry is added
+func (m *MdnsManager) SetStaticEntryLoader(loader api.MdnsStaticEntryLoaderInterface) error {
+	entries, err := loader.LoadStaticEntries()
+	if err != nil {
+		return err
+	}
+
+	m.muxStatic.Lock()
+	defer m.muxStatic.Unlock()
+
+	m.staticLoader = loader
+
+	for _, entry := range entries {
+		if err := m.validateStaticEntry(&entry); err != nil {
+			logging.Log().Debug("mdns: ignoring persisted static entry", entry.Ski, err)
+			continue
+		}
+
+		m.staticEntries[entry.Ski] = entry
+		m.applyStaticEntry(entry.Ski, &entry)
+	}
+
+	return nil
+}
+
+// Add a static entry for a remote service, e.g. for a device with broken mDNS
+//
+// The entry is merged with the discovery results of the providers and reported
+// like them, it never becomes stale.
+// Host or Addresses, and Port are required, Path defaults to the SHIP websocket path.
+// Returns an error if a static entry for the SKI already exists
+func (m *MdnsManager) AddStaticEntry(entry api.MdnsEntry) error {
+	if err := m.validateStaticEntry(&entry); err != nil {
+		return err
+	}
+
+	m.muxStatic.Lock()
+	defer m.muxStatic.Unlock()
+
+	if _, ok := m.staticEntries[entry.Ski]; ok {
+		return errors.New("a static entry for the SKI already exists")
+	}
+
+	return m.setStaticEntry(entry.Ski, &entry)
+}
+
+// Update an existing static entry, which is identified by the SKI
+//
+// Returns an error if no static entry for the SKI exists
+func (m *MdnsManager) UpdateStaticEntry(entry api.MdnsEntry) error {
+	if err := m.validateStaticEntry(&entry); err != nil {
+		return err
+	}
+
+	m.muxStatic.Lock()
+	defer m.muxStatic.Unlock()
+
+	if _, ok := m.staticEntries[entry.Ski]; !ok {
+		return errors.New("no static entry for the SKI exists")
+	}
+
+	return m.setStaticEntry(entry.Ski, &entry)
+}
+
+// Remove the static entry for a SKI
+//
+// Returns an error if no static entry for the SKI exists
+func (m *MdnsManager) RemoveStaticEntry(ski string) error {
+	ski = util.NormalizeSKI(ski)
+
+	m.muxStatic.Lock()
+	defer m.muxStatic.Unlock()
+
+	if _, ok := m.staticEntries[ski]; !ok {
+		return errors.New("no static entry for the SKI exists")
+	}
+
+	return m.setStaticEntry(ski, nil)
+}
+
+// Return all static entries sorted by SKI
+func (m *MdnsManager) StaticEntries() []api.MdnsEntry {
+	m.muxStatic.Lock()
+	defer m.muxStatic.Unlock()
+
+	return m.sortedStaticEntries(m.staticEntries)
+}
+
+// check the mandatory fields of a static entry and normalize it
+func (m *MdnsManager) validateStaticEntry(entry *api.MdnsEntry) error {
+	entry.Ski = util.NormalizeSKI(entry.Ski)
+
+	if len(entry.Ski) == 0 {
+		return errors.New("the SKI of a static entry is missing")
+	}
+	if entry.Ski == m.ski {
+		return errors.New("a static entry can not use the local SKI")
+	}
+	if len(entry.Host) == 0 && len(entry.Addresses) == 0 {
+		return errors.New("the host or addresses of a static entry are missing")
+	}
+	if entry.Port <= 0 || entry.Port > 65535 {
+		return errors.New("the port of a static entry is invalid")
+	}
+
+	if len(entry.Path) == 0 {
+		entry.Path = shipWebsocketPath
+	}
+	entry.Addresses = slices.Clone(entry.Addresses)
+	entry.Categories = slices.Clone(entry.Categories)
+	entry.LastSeen = time.Time{}
+	entry.Providers = nil
+
+	return nil
+}
+
+// set or remove (entry is nil) a static entry, persist the static entries and apply the change
+//
+// m.muxStatic has to be locked
+func (m *MdnsManager) setStaticEntry(ski string, entry *api.MdnsEntry) error {
+	staticEntries := make(map[string]api.MdnsEntry, len(m.staticEntries))
+	for key, value := range m.staticEntries {
+		staticEntries[key] = value
+	}
+	if entry != nil {
+		staticEntries[ski] = *entry
+	} else {
+		delete(staticEntries, ski)
+	}
+
+	// only apply the change if it could be persisted
+	if m.staticLoader != nil {
+		if err := m.staticLoader.SaveStaticEntries(m.sortedStaticEntries(staticEntries)); err != nil {
+			return err
+		}
+	}
+
+	m.staticEntries = staticEntries
+	m.applyStaticEntry(ski, entry)
+
+	return nil
+}
+
+// merge a static entry into the mDNS entries and report the change
+func (m *MdnsManager) applyStaticEntry(ski string, entry *api.MdnsEntry) {
+	var sourceEntry *api.MdnsEntry
+	if entry != nil {
+		sourceEntry = &api.MdnsEntry{}
+		util.DeepCopy[*api.MdnsEntry](entry, sourceEntry)
+		sourceEntry.LastSeen = m.getClock().Now()
+		sourceEntry.Providers = []string{MdnsProviderNameStatic}
+	}
+
+	if _, _, changed := m.changeSourceEntry(ski, MdnsProviderNameStatic, sourceEntry); !changed {
+		return
+	}
+
+	if entry == nil {
+		logging.Log().Debug("mdns: static remove - ski:", ski)
+		return
+	}
+
+	logging.Log().Debug("mdns: static - ski:", ski, "identifier:", entry.Identifier, "host:", entry.Host, "port:", entry.Port, "addresses:", entry.Addresses)
+}
+
+func (m *MdnsManager) sortedStaticEntries(staticEntries map[string]api.MdnsEntry) []api.MdnsEntry {
+	entries := make([]api.MdnsEntry, 0, len(staticEntries))
+	for _, entry := range staticEntries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Ski < entries[j].Ski
+	})
+
+	return entries
+}
+
+// Persists static mDNS entries as JSON in a file
+type StaticEntryFileLoader struct {
+	path string
+
+	mux sync.Mutex
+}
+
+var _ api.MdnsStaticEntryLoaderInterface = (*StaticEntryFileLoader)(nil)
+
+// Create a loader persisting static mDNS entries in the file at path
+//
+// The file is created with the first saved entry
+func NewStaticEntryFileLoader(path string) *StaticEntryFileLoader {
+	return &StaticEntryFileLoader{
+		path: path,
+	}
+}
+
+func (s *StaticEntryFileLoader) LoadStaticEntries() ([]api.MdnsEntry, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []api.MdnsEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *StaticEntryFileLoader) SaveStaticEntries(entries []api.MdnsEntry) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a failure never leaves a partial file
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, s.path)
+}
@@ -0,0 +1,241 @@
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestStaticSuite(t *testing.T) {
+	suite.Run(t, new(StaticSuite))
+}
+
+type StaticSuite struct {
+	suite.Suite
+
+	sut *MdnsManager
+
+	mdnsSearch *mocks.MdnsReportInterface
+}
+
+func (s *StaticSuite) BeforeTest(suiteName, testName string) {
+	s.mdnsSearch = mocks.NewMdnsReportInterface(s.T())
+	s.mdnsSearch.On("ReportMdnsEntries", mock.Anything, mock.Anything).Maybe().Return()
+	s.mdnsSearch.On("ReportMdnsEntryUpdate", mock.Anything).Maybe().Return()
+
+	s.sut = NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		4729, nil, MdnsProviderSelectionAll)
+	s.sut.report = s.mdnsSearch
+}
+
+func (s *StaticSuite) staticEntry() api.MdnsEntry {
+	return api.MdnsEntry{
+		Ski:        "Test-SKI",
+		Identifier: "id",
+		Host:       "device.local.",
+		Port:       4711,
+		Addresses:  []net.IP{net.ParseIP("192.168.1.30")},
+	}
+}
+
+func (s *StaticSuite) Test_Validation() {
+	entry := s.staticEntry()
+	entry.Ski = ""
+	assert.NotNil(s.T(), s.sut.AddStaticEntry(entry))
+
+	entry = s.staticEntry()
+	entry.Ski = "test"
+	assert.NotNil(s.T(), s.sut.AddStaticEntry(entry))
+
+	entry = s.staticEntry()
+	entry.Host = ""
+	entry.Addresses = nil
+	assert.NotNil(s.T(), s.sut.AddStaticEntry(entry))
+
+	entry = s.staticEntry()
+	entry.Port = 0
+	assert.NotNil(s.T(), s.sut.AddStaticEntry(entry))
+
+	assert.Equal(s.T(), 0, len(s.sut.StaticEntries()))
+}
+
+func (s *StaticSuite) Test_AddUpdateRemove() {
+	assert.NotNil(s.T(), s.sut.UpdateStaticEntry(s.staticEntry()))
+	assert.NotNil(s.T(), s.sut.RemoveStaticEntry("testski"))
+
+	err := s.sut.AddStaticEntry(s.staticEntry())
+	assert.Nil(s.T(), err)
+	err = s.sut.AddStaticEntry(s.staticEntry())
+	assert.NotNil(s.T(), err)
+
+	entries := s.sut.StaticEntries()
+	if assert.Equal(s.T(), 1, len(entries)) {
+		assert.Equal(s.T(), "testski", entries[0].Ski)
+		assert.Equal(s.T(), shipWebsocketPath, entries[0].Path)
+	}
+
+	entry, ok := s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []string{MdnsProviderNameStatic}, entry.Providers)
+		assert.Equal(s.T(), 4711, entry.Port)
+	}
+
+	updated := s.staticEntry()
+	updated.Port = 4712
+	err = s.sut.UpdateStaticEntry(updated)
+	assert.Nil(s.T(), err)
+
+	entry, ok = s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), 4712, entry.Port)
+	}
+
+	err = s.sut.RemoveStaticEntry("TEST-SKI")
+	assert.Nil(s.T(), err)
+
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+	assert.Equal(s.T(), 0, len(s.sut.StaticEntries()))
+}
+
+func (s *StaticSuite) Test_ReportsInOrder() {
+	release := make(chan struct{})
+	reported := make(chan int, 10)
+
+	report := mocks.NewMdnsReportInterface(s.T())
+	report.On("ReportMdnsEntries", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		// the first report blocks, so the following ones are queued
+		<-release
+		reported <- len(args.Get(0).(map[string]*api.MdnsEntry))
+	}).Return()
+	s.sut.report = report
+
+	for i := 0; i < 5; i++ {
+		entry := s.staticEntry()
+		entry.Ski = fmt.Sprintf("ski%d", i)
+		assert.Nil(s.T(), s.sut.AddStaticEntry(entry))
+	}
+	close(release)
+
+	// a list of entries is never reported after a newer one
+	for i := 1; i <= 5; i++ {
+		select {
+		case count := <-reported:
+			assert.Equal(s.T(), i, count)
+		case <-time.After(time.Second):
+			s.T().Fatal("entries were not reported")
+		}
+	}
+}
+
+func (s *StaticSuite) Test_MergeWithDiscovery() {
+	err := s.sut.AddStaticEntry(s.staticEntry())
+	assert.Nil(s.T(), err)
+
+	elements := map[string]string{
+		"txtvers":  "1",
+		"id":       "id",
+		"path":     "/ship/",
+		"ski":      "testski",
+		"register": "false",
+	}
+	s.sut.processMdnsEntry("multicast", elements, "name", "device.local.", []net.IP{net.ParseIP("192.168.1.31")}, 4711, false)
+
+	entry, ok := s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []string{"multicast", MdnsProviderNameStatic}, entry.Providers)
+		assert.Equal(s.T(), 2, len(entry.Addresses))
+	}
+
+	// the static entry never becomes stale
+	s.sut.removeStaleEntries(time.Now().Add(time.Hour))
+
+	entry, ok = s.sut.mdnsEntry("testski")
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), []string{MdnsProviderNameStatic}, entry.Providers)
+		assert.Equal(s.T(), 1, len(entry.Addresses))
+	}
+
+	// a removal by a provider keeps the static entry
+	s.sut.processMdnsEntry("multicast", elements, "name", "device.local.", nil, -1, true)
+	_, ok = s.sut.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+}
+
+func (s *StaticSuite) Test_FileLoader() {
+	path := filepath.Join(s.T().TempDir(), "static.json")
+
+	err := s.sut.SetStaticEntryLoader(NewStaticEntryFileLoader(path))
+	assert.Nil(s.T(), err)
+
+	err = s.sut.AddStaticEntry(s.staticEntry())
+	assert.Nil(s.T(), err)
+
+	_, err = os.Stat(path)
+	assert.Nil(s.T(), err)
+
+	// a restarted manager loads the persisted entries
+	restarted := NewMDNS("test", "brand", "model", "EnergyManagementSystem",
+		"12345",
+		[]api.DeviceCategoryType{api.DeviceCategoryTypeEnergyManagementSystem},
+		"shipid", "serviceName",
+		4729, nil, MdnsProviderSelectionAll)
+	err = restarted.SetStaticEntryLoader(NewStaticEntryFileLoader(path))
+	assert.Nil(s.T(), err)
+
+	entries := restarted.StaticEntries()
+	if assert.Equal(s.T(), 1, len(entries)) {
+		assert.Equal(s.T(), "testski", entries[0].Ski)
+		assert.Equal(s.T(), 4711, entries[0].Port)
+		assert.True(s.T(), entries[0].Addresses[0].Equal(net.ParseIP("192.168.1.30")))
+	}
+
+	_, ok := restarted.mdnsEntry("testski")
+	assert.True(s.T(), ok)
+
+	err = restarted.RemoveStaticEntry("testski")
+	assert.Nil(s.T(), err)
+
+	entries, err = NewStaticEntryFileLoader(path).LoadStaticEntries()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, len(entries))
+}
+
+func (s *StaticSuite) Test_FileLoader_Invalid() {
+	path := filepath.Join(s.T().TempDir(), "static.json")
+	err := os.WriteFile(path, []byte("invalid"), 0600)
+	assert.Nil(s.T(), err)
+
+	err = s.sut.SetStaticEntryLoader(NewStaticEntryFileLoader(path))
+	assert.NotNil(s.T(), err)
+}
+
+func (s *StaticSuite) Test_SaveFailure() {
+	loader := mocks.NewMdnsStaticEntryLoaderInterface(s.T())
+	loader.EXPECT().LoadStaticEntries().Return(nil, nil).Once()
+	loader.EXPECT().SaveStaticEntries(mock.Anything).Return(errors.New("failure")).Once()
+
+	err := s.sut.SetStaticEntryLoader(loader)
+	assert.Nil(s.T(), err)
+
+	// the entry is not added if it could not be persisted
+	err = s.sut.AddStaticEntry(s.staticEntry())
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(s.sut.StaticEntries()))
+
+	_, ok := s.sut.mdnsEntry("testski")
+	assert.False(s.T(), ok)
+}
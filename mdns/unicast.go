@@ -0,0 +1,691 @@
+package mdns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/logging"
+	"github.com/miekg/dns"
+)
+
+const (
+	// the default interval between browses for SHIP services
+	unicastDefaultBrowseInterval = 30 * time.Second
+
+	// the default timeout of a single DNS request
+	unicastDefaultTimeout = 5 * time.Second
+
+	// the validity of the TSIG signature of dynamic updates, RFC 8945 5.2.3
+	unicastTsigFudge = 300
+
+	// the UDP message size announced via EDNS0 for queries, RFC 6891
+	unicastUDPSize = 1232
+)
+
+// Configuration of the unicast DNS-SD provider
+type UnicastConfig struct {
+	// The address of the DNS server, e.g. "192.0.2.53:53"
+	// The port defaults to 53
+	Server string
+
+	// The DNS-SD domain to browse and register the service in, e.g. "site.example.com"
+	Domain string
+
+	// The interval between browses for SHIP services
+	//
+	// Default: 30 seconds
+	BrowseInterval time.Duration
+
+	// The timeout of a single DNS request
+	//
+	// Default: 5 seconds
+	Timeout time.Duration
+
+	// Wether the local service is registered via dynamic updates, RFC 2136
+	Register bool
+
+	// The host name used as target of the registered service
+	//
+	// Default: the local host name within Domain
+	Hostname string
+
+	// The addresses registered for Hostname
+	//
+	// Default: the global unicast addresses of all interfaces
+	Addresses []net.IP
+
+	// Optional TSIG key used to sign the dynamic updates, RFC 8945
+	TsigKeyName string
+	TsigSecret  string // base64 encoded
+
+	// The TSIG algorithm, e.g. dns.HmacSHA512
+	//
+	// Default: dns.HmacSHA256
+	TsigAlgorithm string
+}
+
+// the local service registered via dynamic updates
+type unicastRegistration struct {
+	instance  string // the escaped fully qualified instance name
+	port      int
+	txt       []string
+	addresses []net.IP
+
+	// wether the records are currently registered at the server
+	registered bool
+}
+
+// DNS-SD provider browsing for SHIP services via a unicast DNS server, RFC 6763,
+// and optionally registering the local service via dynamic updates, RFC 2136
+type UnicastProvider struct {
+	config UnicastConfig
+
+	// the fully qualified names used for the configured domain
+	zone, serviceType, hostname string
+
+	client *dns.Client
+
+	cb api.MdnsResolveCB
+
+	// the reported services with the lower case instance name as key
+	services map[string]*nativeService
+
+	registration *unicastRegistration
+
+	// report all services with the next browse, not only the changed ones
+	reportAll bool
+
+	// triggers an immediate browse
+	queryC chan struct{}
+
+	isStarted bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mux sync.Mutex
+	// serializes the dynamic updates
+	muxUpdate sync.Mutex
+}
+
+// Create a new unicast DNS-SD provider
+func NewUnicastProvider(config UnicastConfig) *UnicastProvider {
+	if config.BrowseInterval <= 0 {
+		config.BrowseInterval = unicastDefaultBrowseInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = unicastDefaultTimeout
+	}
+	if len(config.TsigAlgorithm) == 0 {
+		config.TsigAlgorithm = dns.HmacSHA256
+	}
+	if len(config.Server) > 0 {
+		if _, _, err := net.SplitHostPort(config.Server); err != nil {
+			config.Server = net.JoinHostPort(config.Server, "53")
+		}
+	}
+
+	zone := dns.Fqdn(config.Domain)
+
+	hostname := dns.Fqdn(config.Hostname)
+	if len(config.Hostname) == 0 {
+		label, _, _ := strings.Cut(nativeHostname(), ".")
+		hostname = label + "." + zone
+	}
+
+	client := &dns.Client{
+		Timeout: config.Timeout,
+	}
+	if len(config.TsigKeyName) > 0 {
+		client.TsigSecret = map[string]string{dns.Fqdn(config.TsigKeyName): config.TsigSecret}
+	}
+
+	return &UnicastProvider{
+		config:      config,
+		zone:        zone,
+		serviceType: shipZeroConfServiceType + "." + zone,
+		hostname:    hostname,
+		client:      client,
+		services:    make(map[string]*nativeService),
+		queryC:      make(chan struct{}, 1),
+	}
+}
+
+var _ api.MdnsProviderInterface = (*UnicastProvider)(nil)
+
+// Start browsing for SHIP services
+//
+// Returns false if the server or the domain is not configured.
+// An unreachable server is queried again with the next browse
+func (u *UnicastProvider) Start(autoReconnect bool, cb api.MdnsResolveCB) bool {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	if u.isStarted {
+		return true
+	}
+
+	if len(u.config.Server) == 0 || len(u.config.Domain) == 0 {
+		logging.Log().Debug("mdns: unicast DNS-SD requires a server and a domain")
+		return false
+	}
+
+	u.cb = cb
+	u.ctx, u.cancel = context.WithCancel(context.Background())
+	u.isStarted = true
+
+	u.wg.Add(1)
+	go u.run()
+
+	return true
+}
+
+// Stop the provider, removing a registered service from the server
+func (u *UnicastProvider) Shutdown() {
+	u.Unannounce()
+
+	u.mux.Lock()
+	if !u.isStarted {
+		u.mux.Unlock()
+		return
+	}
+
+	u.isStarted = false
+	u.cancel()
+	u.services = make(map[string]*nativeService)
+	u.mux.Unlock()
+
+	u.wg.Wait()
+}
+
+// Announce the local SHIP service
+//
+// The service is only registered at the server if registration is enabled
+func (u *UnicastProvider) Announce(serviceName string, port int, txt []string) error {
+	if len(serviceName) == 0 {
+		return errors.New("mdns: service name is missing")
+	}
+
+	if !u.config.Register {
+		return nil
+	}
+
+	logging.Log().Debug("mdns: using unicast DNS-SD registration")
+
+	u.muxUpdate.Lock()
+	defer u.muxUpdate.Unlock()
+
+	registration := &unicastRegistration{
+		instance:  escapeLabel(serviceName) + "." + u.serviceType,
+		port:      port,
+		txt:       txt,
+		addresses: u.config.Addresses,
+	}
+	if len(registration.addresses) == 0 {
+		registration.addresses = globalUnicastAddresses()
+	}
+
+	u.mux.Lock()
+	previous := u.registration
+	previousRegistered := previous != nil && previous.registered
+	u.registration = registration
+	isStarted := u.isStarted
+	u.mux.Unlock()
+
+	// the registration is sent once the provider is started
+	if !isStarted {
+		return nil
+	}
+
+	// a previous registration with a different name has to be removed
+	if previousRegistered && previous.instance != registration.instance {
+		if err := u.deregister(previous); err != nil {
+			logging.Log().Debug("mdns: unicast DNS-SD - error removing previous registration:", err)
+		}
+	}
+
+	return u.register(registration)
+}
+
+// Stop announcing the local SHIP service, removing it from the server
+func (u *UnicastProvider) Unannounce() {
+	u.muxUpdate.Lock()
+	defer u.muxUpdate.Unlock()
+
+	u.mux.Lock()
+	registration := u.registration
+	registered := registration != nil && registration.registered
+	u.registration = nil
+	u.mux.Unlock()
+
+	if !registered {
+		return
+	}
+
+	if err := u.deregister(registration); err != nil {
+		logging.Log().Debug("mdns: unicast DNS-SD - error removing registration:", err)
+	}
+}
+
+// Browse for SHIP services now and report all currently available services
+func (u *UnicastProvider) Query() {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	if !u.isStarted {
+		return
+	}
+
+	u.reportAll = true
+
+	select {
+	case u.queryC <- struct{}{}:
+	default:
+	}
+}
+
+// the browse loop
+func (u *UnicastProvider) run() {
+	defer u.wg.Done()
+
+	for {
+		u.ensureRegistration()
+		u.browse()
+
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-u.queryC:
+		case <-time.After(u.config.BrowseInterval):
+		}
+	}
+}
+
+// register the announced service, if it is not registered yet
+func (u *UnicastProvider) ensureRegistration() {
+	u.muxUpdate.Lock()
+	defer u.muxUpdate.Unlock()
+
+	u.mux.Lock()
+	registration := u.registration
+	registered := registration != nil && registration.registered
+	u.mux.Unlock()
+
+	if registration == nil || registered {
+		return
+	}
+
+	if err := u.register(registration); err != nil {
+		logging.Log().Debug("mdns: unicast DNS-SD - error registering service:", err)
+	}
+}
+
+/* Browsing */
+
+// browse for SHIP services and report the changes
+func (u *UnicastProvider) browse() {
+	current, err := u.lookupServices()
+	if err != nil {
+		// keep the known services, the server may only be temporarily unreachable
+		logging.Log().Debug("mdns: unicast DNS-SD - browse failed:", err)
+		return
+	}
+
+	var callbacks []func()
+
+	u.mux.Lock()
+	if !u.isStarted {
+		u.mux.Unlock()
+		return
+	}
+
+	reportAll := u.reportAll
+	u.reportAll = false
+
+	for key, service := range current {
+		if reported, ok := u.services[key]; ok && reported.equal(service) && !reportAll {
+			continue
+		}
+
+		u.services[key] = service
+		callbacks = append(callbacks, u.report(service, false))
+	}
+
+	for key, reported := range u.services {
+		if _, ok := current[key]; ok {
+			continue
+		}
+
+		delete(u.services, key)
+		callbacks = append(callbacks, u.report(reported, true))
+	}
+
+	// the server lost the registration, e.g. after a restart
+	if u.registration != nil && u.registration.registered {
+		if _, ok := current[strings.ToLower(u.registration.instance)]; !ok {
+			u.registration.registered = false
+			select {
+			case u.queryC <- struct{}{}:
+			default:
+			}
+		}
+	}
+	u.mux.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// return the callback for a service, needs to be called with mux locked
+func (u *UnicastProvider) report(service *nativeService, remove bool) func() {
+	cb := u.cb
+	elements := parseTxt(service.txt)
+	name := u.instanceName(service.instance)
+	addresses := slices.Clone(service.addresses)
+
+	return func() {
+		if cb != nil {
+			cb(elements, name, service.host, addresses, service.port, remove)
+		}
+	}
+}
+
+// return the unescaped service name of a fully qualified instance name
+func (u *UnicastProvider) instanceName(instance string) string {
+	suffix := "." + u.serviceType
+	if len(instance) > len(suffix) && strings.EqualFold(instance[len(instance)-len(suffix):], suffix) {
+		instance = instance[:len(instance)-len(suffix)]
+	}
+
+	return unescapeText(instance)
+}
+
+// look up all complete SHIP services with the lower case instance name as key
+func (u *UnicastProvider) lookupServices() (map[string]*nativeService, error) {
+	ptrs, extra, err := u.lookup(u.serviceType, dns.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]*nativeService)
+
+	for _, rr := range ptrs {
+		ptr, ok := rr.(*dns.PTR)
+		if !ok {
+			continue
+		}
+
+		service, err := u.resolveService(ptr.Ptr, extra)
+		if err != nil {
+			return nil, err
+		}
+		if service == nil {
+			continue
+		}
+
+		services[strings.ToLower(ptr.Ptr)] = service
+	}
+
+	return services, nil
+}
+
+// resolve a service instance, using the additional records of the browse if available
+//
+// returns nil if the service is incomplete
+func (u *UnicastProvider) resolveService(instance string, extra []dns.RR) (*nativeService, error) {
+	service := &nativeService{
+		instance: instance,
+	}
+
+	srvs, err := u.records(instance, dns.TypeSRV, extra)
+	if err != nil {
+		return nil, err
+	}
+	if len(srvs) == 0 {
+		return nil, nil
+	}
+	srv := srvs[0].(*dns.SRV)
+	service.host = srv.Target
+	service.port = int(srv.Port)
+
+	txts, err := u.records(instance, dns.TypeTXT, extra)
+	if err != nil {
+		return nil, err
+	}
+	if len(txts) == 0 {
+		return nil, nil
+	}
+	for _, item := range txts[0].(*dns.TXT).Txt {
+		service.txt = append(service.txt, unescapeText(item))
+	}
+
+	for _, rrtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		records, err := u.records(service.host, rrtype, extra)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			switch rr := record.(type) {
+			case *dns.A:
+				service.addresses = append(service.addresses, rr.A)
+			case *dns.AAAA:
+				service.addresses = append(service.addresses, rr.AAAA)
+			}
+		}
+	}
+	if len(service.addresses) == 0 {
+		return nil, nil
+	}
+
+	slices.SortFunc(service.addresses, func(a, b net.IP) int {
+		return bytes.Compare(a.To16(), b.To16())
+	})
+
+	return service, nil
+}
+
+// return the records of a name and type from the additional records or by querying the server
+func (u *UnicastProvider) records(name string, rrtype uint16, extra []dns.RR) ([]dns.RR, error) {
+	var result []dns.RR
+	for _, rr := range extra {
+		if hdr := rr.Header(); hdr.Rrtype == rrtype && strings.EqualFold(hdr.Name, name) {
+			result = append(result, rr)
+		}
+	}
+	if len(result) > 0 {
+		return result, nil
+	}
+
+	result, _, err := u.lookup(name, rrtype)
+
+	return result, err
+}
+
+// query the server, returning the matching answers and all additional records
+//
+// a non existing name is no error and returns no records
+func (u *UnicastProvider) lookup(name string, rrtype uint16) ([]dns.RR, []dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, rrtype)
+	msg.SetEdns0(unicastUDPSize, false)
+
+	response, err := u.exchange(msg, "udp")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch response.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeNameError:
+		return nil, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("query for %s failed: %s", name, dns.RcodeToString[response.Rcode])
+	}
+
+	var answers []dns.RR
+	for _, rr := range response.Answer {
+		if hdr := rr.Header(); hdr.Rrtype == rrtype && strings.EqualFold(hdr.Name, name) {
+			answers = append(answers, rr)
+		}
+	}
+
+	return answers, response.Extra, nil
+}
+
+// send a message to the server, retrying via TCP if the UDP response is truncated
+func (u *UnicastProvider) exchange(msg *dns.Msg, network string) (*dns.Msg, error) {
+	client := *u.client
+	client.Net = network
+
+	response, _, err := client.Exchange(msg, u.config.Server)
+	if err == nil && response.Truncated && network == "udp" {
+		client.Net = "tcp"
+		response, _, err = client.Exchange(msg, u.config.Server)
+	}
+
+	return response, err
+}
+
+/* Registration */
+
+// register the records of the service via a dynamic update, needs to be called with muxUpdate locked
+func (u *UnicastProvider) register(registration *unicastRegistration) error {
+	ttl := uint32(nativeRecordTTL)
+
+	header := func(name string, rrtype uint16) dns.RR_Header {
+		return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+	}
+
+	var txtItems []string
+	for _, item := range registration.txt {
+		txtItems = append(txtItems, strings.ReplaceAll(item, `\`, `\\`))
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(u.zone)
+
+	// replace existing records of the instance, RFC 2136 2.5.2
+	msg.RemoveRRset([]dns.RR{
+		&dns.SRV{Hdr: header(registration.instance, dns.TypeSRV)},
+		&dns.TXT{Hdr: header(registration.instance, dns.TypeTXT)},
+	})
+	msg.Insert([]dns.RR{
+		&dns.PTR{Hdr: header(u.serviceType, dns.TypePTR), Ptr: registration.instance},
+		&dns.SRV{Hdr: header(registration.instance, dns.TypeSRV), Port: uint16(registration.port), Target: u.hostname}, // #nosec G115
+		&dns.TXT{Hdr: header(registration.instance, dns.TypeTXT), Txt: txtItems},
+	})
+
+	if len(registration.addresses) > 0 {
+		msg.RemoveRRset([]dns.RR{
+			&dns.A{Hdr: header(u.hostname, dns.TypeA)},
+			&dns.AAAA{Hdr: header(u.hostname, dns.TypeAAAA)},
+		})
+
+		var addresses []dns.RR
+		for _, address := range registration.addresses {
+			if ipv4 := address.To4(); ipv4 != nil {
+				addresses = append(addresses, &dns.A{Hdr: header(u.hostname, dns.TypeA), A: ipv4})
+			} else {
+				addresses = append(addresses, &dns.AAAA{Hdr: header(u.hostname, dns.TypeAAAA), AAAA: address})
+			}
+		}
+		msg.Insert(addresses)
+	}
+
+	if err := u.update(msg); err != nil {
+		return err
+	}
+
+	u.mux.Lock()
+	registration.registered = true
+	u.mux.Unlock()
+
+	return nil
+}
+
+// remove the records of the service via a dynamic update, needs to be called with muxUpdate locked
+func (u *UnicastProvider) deregister(registration *unicastRegistration) error {
+	msg := new(dns.Msg)
+	msg.SetUpdate(u.zone)
+
+	// RFC 2136 2.5.4 and 2.5.3
+	msg.Remove([]dns.RR{
+		&dns.PTR{Hdr: dns.RR_Header{Name: u.serviceType, Rrtype: dns.TypePTR, Class: dns.ClassINET}, Ptr: registration.instance},
+	})
+	msg.RemoveName([]dns.RR{
+		&dns.ANY{Hdr: dns.RR_Header{Name: registration.instance}},
+	})
+	if len(registration.addresses) > 0 {
+		msg.RemoveRRset([]dns.RR{
+			&dns.A{Hdr: dns.RR_Header{Name: u.hostname, Rrtype: dns.TypeA}},
+			&dns.AAAA{Hdr: dns.RR_Header{Name: u.hostname, Rrtype: dns.TypeAAAA}},
+		})
+	}
+
+	u.mux.Lock()
+	registration.registered = false
+	u.mux.Unlock()
+
+	return u.update(msg)
+}
+
+// send a dynamic update, signed if a TSIG key is configured
+func (u *UnicastProvider) update(msg *dns.Msg) error {
+	if len(u.config.TsigKeyName) > 0 {
+		msg.SetTsig(dns.Fqdn(u.config.TsigKeyName), dns.Fqdn(u.config.TsigAlgorithm), unicastTsigFudge, time.Now().Unix())
+	}
+
+	// updates easily exceed the UDP message size and are not retried on truncation
+	response, err := u.exchange(msg, "tcp")
+	if err != nil {
+		return err
+	}
+
+	if response.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("mdns: dynamic update failed: %s", dns.RcodeToString[response.Rcode])
+	}
+
+	return nil
+}
+
+// return the global unicast addresses of all interfaces that are up
+func globalUnicastAddresses() []net.IP {
+	var result []net.IP
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !ipNet.IP.IsGlobalUnicast() {
+				continue
+			}
+			result = append(result, ipNet.IP)
+		}
+	}
+
+	return result
+}
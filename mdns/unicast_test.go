@@ -0,0 +1,425 @@
+package mdns
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestUnicastSuite(t *testing.T) {
+	suite.Run(t, new(UnicastSuite))
+}
+
+const (
+	unicastTestDomain    = "example.test."
+	unicastTestKeyName   = "update.example.test."
+	unicastTestKeySecret = "c2hpcC1nby10ZXN0LXNlY3JldA=="
+)
+
+// a minimal authoritative DNS server for a single zone, accepting dynamic updates
+type testDNSServer struct {
+	servers []*dns.Server
+	addr    string
+
+	// the records with the lower case name as key
+	records map[string][]dns.RR
+
+	requireTsig bool
+	updates     int
+
+	mux sync.Mutex
+}
+
+func newTestDNSServer(t *testing.T, requireTsig bool) *testDNSServer {
+	s := &testDNSServer{
+		records:     make(map[string][]dns.RR),
+		requireTsig: requireTsig,
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s.addr = conn.LocalAddr().String()
+
+	listener, err := net.Listen("tcp", s.addr)
+	assert.Nil(t, err)
+
+	for _, server := range []*dns.Server{{PacketConn: conn}, {Listener: listener}} {
+		started := make(chan struct{})
+		server.Handler = s
+		server.TsigSecret = map[string]string{unicastTestKeyName: unicastTestKeySecret}
+		server.NotifyStartedFunc = func() { close(started) }
+		server.MsgAcceptFunc = func(dh dns.Header) dns.MsgAcceptAction {
+			// the default rejects dynamic updates
+			if int(dh.Bits>>11)&0xF == dns.OpcodeUpdate {
+				return dns.MsgAccept
+			}
+			return dns.DefaultMsgAcceptFunc(dh)
+		}
+
+		go func() {
+			_ = server.ActivateAndServe()
+		}()
+		<-started
+
+		s.servers = append(s.servers, server)
+	}
+
+	return s
+}
+
+func (s *testDNSServer) shutdown() {
+	for _, server := range s.servers {
+		_ = server.Shutdown()
+	}
+}
+
+func (s *testDNSServer) add(rrs ...dns.RR) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, rr := range rrs {
+		name := strings.ToLower(rr.Header().Name)
+		s.records[name] = append(s.records[name], rr)
+	}
+}
+
+func (s *testDNSServer) lookup(name string, rrtype uint16) []dns.RR {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var result []dns.RR
+	for _, rr := range s.records[strings.ToLower(name)] {
+		if rr.Header().Rrtype == rrtype {
+			result = append(result, rr)
+		}
+	}
+
+	return result
+}
+
+func (s *testDNSServer) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	response := new(dns.Msg)
+	response.SetReply(req)
+
+	if req.Opcode == dns.OpcodeUpdate {
+		switch {
+		case s.requireTsig && (req.IsTsig() == nil || w.TsigStatus() != nil):
+			response.Rcode = dns.RcodeRefused
+		default:
+			s.applyUpdate(req.Ns)
+		}
+		if tsig := req.IsTsig(); tsig != nil {
+			response.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+		}
+		_ = w.WriteMsg(response)
+		return
+	}
+
+	question := req.Question[0]
+	s.mux.Lock()
+	records, exists := s.records[strings.ToLower(question.Name)]
+	s.mux.Unlock()
+
+	if !exists {
+		response.Rcode = dns.RcodeNameError
+	}
+	for _, rr := range records {
+		if rr.Header().Rrtype == question.Qtype {
+			response.Answer = append(response.Answer, dns.Copy(rr))
+		}
+	}
+
+	_ = w.WriteMsg(response)
+}
+
+// apply the update section of a dynamic update, RFC 2136 3.4.2
+func (s *testDNSServer) applyUpdate(rrs []dns.RR) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.updates++
+
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		name := strings.ToLower(hdr.Name)
+
+		switch hdr.Class {
+		case dns.ClassANY:
+			if hdr.Rrtype == dns.TypeANY {
+				delete(s.records, name)
+				continue
+			}
+
+			var kept []dns.RR
+			for _, existing := range s.records[name] {
+				if existing.Header().Rrtype != hdr.Rrtype {
+					kept = append(kept, existing)
+				}
+			}
+			s.records[name] = kept
+		case dns.ClassNONE:
+			var kept []dns.RR
+			for _, existing := range s.records[name] {
+				if existing.Header().Rrtype != hdr.Rrtype || !dns.IsDuplicate(existing, withClass(rr, dns.ClassINET)) {
+					kept = append(kept, existing)
+				}
+			}
+			s.records[name] = kept
+		default:
+			isDuplicate := false
+			for _, existing := range s.records[name] {
+				if dns.IsDuplicate(existing, rr) {
+					isDuplicate = true
+				}
+			}
+			if !isDuplicate {
+				s.records[name] = append(s.records[name], dns.Copy(rr))
+			}
+		}
+
+		if len(s.records[name]) == 0 {
+			delete(s.records, name)
+		}
+	}
+}
+
+func withClass(rr dns.RR, class uint16) dns.RR {
+	result := dns.Copy(rr)
+	result.Header().Class = class
+
+	return result
+}
+
+type UnicastSuite struct {
+	suite.Suite
+
+	server *testDNSServer
+	sut    *UnicastProvider
+
+	reports []nativeReport
+
+	mux sync.Mutex
+}
+
+func (s *UnicastSuite) BeforeTest(suiteName, testName string) {
+	s.reports = nil
+	s.server = newTestDNSServer(s.T(), false)
+	s.sut = NewUnicastProvider(UnicastConfig{
+		Server:         s.server.addr,
+		Domain:         "example.test",
+		BrowseInterval: time.Hour,
+		Timeout:        time.Second,
+	})
+}
+
+func (s *UnicastSuite) AfterTest(suiteName, testName string) {
+	s.sut.Shutdown()
+	s.server.shutdown()
+}
+
+func (s *UnicastSuite) cb(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.reports = append(s.reports, nativeReport{
+		entry: mDNSEntry{
+			elements:  elements,
+			name:      name,
+			host:      host,
+			addresses: addresses,
+			port:      port,
+		},
+		remove: remove,
+	})
+}
+
+func (s *UnicastSuite) lastReports() []nativeReport {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.reports
+}
+
+func (s *UnicastSuite) addRemoteService(port uint16) {
+	instance := `Remote\ Device._ship._tcp.` + unicastTestDomain
+	hdr := func(name string, rrtype uint16) dns.RR_Header {
+		return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: 120}
+	}
+
+	s.server.add(
+		&dns.PTR{Hdr: hdr("_ship._tcp."+unicastTestDomain, dns.TypePTR), Ptr: instance},
+		&dns.SRV{Hdr: hdr(instance, dns.TypeSRV), Port: port, Target: "remote." + unicastTestDomain},
+		&dns.TXT{Hdr: hdr(instance, dns.TypeTXT), Txt: []string{"txtvers=1", "ski=1234"}},
+		&dns.A{Hdr: hdr("remote."+unicastTestDomain, dns.TypeA), A: net.ParseIP("192.0.2.20")},
+		&dns.AAAA{Hdr: hdr("remote."+unicastTestDomain, dns.TypeAAAA), AAAA: net.ParseIP("2001:db8::20")},
+	)
+}
+
+func (s *UnicastSuite) Test_Config() {
+	sut := NewUnicastProvider(UnicastConfig{Server: "192.0.2.53", Domain: "example.test", Hostname: "myhost.example.test"})
+	assert.Equal(s.T(), "192.0.2.53:53", sut.config.Server)
+	assert.Equal(s.T(), unicastDefaultBrowseInterval, sut.config.BrowseInterval)
+	assert.Equal(s.T(), dns.HmacSHA256, sut.config.TsigAlgorithm)
+	assert.Equal(s.T(), "_ship._tcp."+unicastTestDomain, sut.serviceType)
+	assert.Equal(s.T(), "myhost."+unicastTestDomain, sut.hostname)
+
+	sut = NewUnicastProvider(UnicastConfig{})
+	assert.False(s.T(), sut.Start(true, s.cb))
+	assert.True(s.T(), strings.HasSuffix(sut.hostname, ".")) // derived from the local host name
+}
+
+func (s *UnicastSuite) Test_Browse() {
+	s.addRemoteService(4712)
+
+	assert.True(s.T(), s.sut.Start(true, s.cb))
+	assert.Eventually(s.T(), func() bool { return len(s.lastReports()) == 1 }, time.Second, 10*time.Millisecond)
+
+	report := s.lastReports()[0]
+	assert.False(s.T(), report.remove)
+	assert.Equal(s.T(), "Remote Device", report.entry.name)
+	assert.Equal(s.T(), "remote."+unicastTestDomain, report.entry.host)
+	assert.Equal(s.T(), 4712, report.entry.port)
+	assert.Equal(s.T(), "1234", report.entry.elements["ski"])
+	assert.Equal(s.T(), 2, len(report.entry.addresses))
+
+	// unchanged services are not reported again by a browse
+	s.sut.browse()
+	assert.Equal(s.T(), 1, len(s.lastReports()))
+
+	// a query reports all services again
+	s.sut.Query()
+	assert.Eventually(s.T(), func() bool { return len(s.lastReports()) == 2 }, time.Second, 10*time.Millisecond)
+
+	// changes are reported
+	s.server.mux.Lock()
+	delete(s.server.records, strings.ToLower(`Remote\ Device._ship._tcp.`+unicastTestDomain))
+	s.server.mux.Unlock()
+	s.addRemoteService(4713)
+	s.sut.browse()
+	reports := s.lastReports()
+	if assert.Equal(s.T(), 3, len(reports)) {
+		assert.Equal(s.T(), 4713, reports[2].entry.port)
+	}
+
+	// removed services are reported
+	s.server.mux.Lock()
+	delete(s.server.records, "_ship._tcp."+unicastTestDomain)
+	s.server.mux.Unlock()
+	s.sut.browse()
+	reports = s.lastReports()
+	if assert.Equal(s.T(), 4, len(reports)) {
+		assert.True(s.T(), reports[3].remove)
+	}
+
+	// an unreachable server keeps the known services
+	s.addRemoteService(4713)
+	s.sut.browse()
+	assert.Equal(s.T(), 5, len(s.lastReports()))
+	s.server.shutdown()
+	s.sut.browse()
+	assert.Equal(s.T(), 5, len(s.lastReports()))
+}
+
+func (s *UnicastSuite) Test_Register() {
+	s.server.shutdown()
+	s.server = newTestDNSServer(s.T(), true)
+	s.sut = NewUnicastProvider(UnicastConfig{
+		Server:         s.server.addr,
+		Domain:         "example.test",
+		BrowseInterval: time.Hour,
+		Timeout:        time.Second,
+		Register:       true,
+		Hostname:       "local.example.test",
+		Addresses:      []net.IP{net.ParseIP("192.0.2.10"), net.ParseIP("2001:db8::10")},
+		TsigKeyName:    unicastTestKeyName,
+		TsigSecret:     unicastTestKeySecret,
+	})
+
+	// the registration is sent once started
+	err := s.sut.Announce("Local Device", 4711, []string{"txtvers=1", "ski=5678"})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, len(s.server.lookup("_ship._tcp."+unicastTestDomain, dns.TypePTR)))
+
+	assert.True(s.T(), s.sut.Start(true, s.cb))
+	assert.Eventually(s.T(), func() bool { return len(s.lastReports()) == 1 }, time.Second, 10*time.Millisecond)
+
+	instance := `Local\ Device._ship._tcp.` + unicastTestDomain
+	srvs := s.server.lookup(instance, dns.TypeSRV)
+	if assert.Equal(s.T(), 1, len(srvs)) {
+		assert.Equal(s.T(), uint16(4711), srvs[0].(*dns.SRV).Port)
+		assert.Equal(s.T(), "local."+unicastTestDomain, srvs[0].(*dns.SRV).Target)
+	}
+	assert.Equal(s.T(), 1, len(s.server.lookup("local."+unicastTestDomain, dns.TypeA)))
+	assert.Equal(s.T(), 1, len(s.server.lookup("local."+unicastTestDomain, dns.TypeAAAA)))
+	assert.Equal(s.T(), "Local Device", s.lastReports()[0].entry.name)
+
+	// a new announcement replaces the records
+	err = s.sut.Announce("Local Device", 4712, []string{"txtvers=1", "ski=5678"})
+	assert.Nil(s.T(), err)
+	srvs = s.server.lookup(instance, dns.TypeSRV)
+	if assert.Equal(s.T(), 1, len(srvs)) {
+		assert.Equal(s.T(), uint16(4712), srvs[0].(*dns.SRV).Port)
+	}
+	assert.Equal(s.T(), 1, len(s.server.lookup("_ship._tcp."+unicastTestDomain, dns.TypePTR)))
+
+	// a new name removes the previous records
+	err = s.sut.Announce("Renamed Device", 4712, []string{"txtvers=1", "ski=5678"})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, len(s.server.lookup(instance, dns.TypeSRV)))
+	ptrs := s.server.lookup("_ship._tcp."+unicastTestDomain, dns.TypePTR)
+	if assert.Equal(s.T(), 1, len(ptrs)) {
+		assert.Equal(s.T(), `Renamed\ Device._ship._tcp.`+unicastTestDomain, ptrs[0].(*dns.PTR).Ptr)
+	}
+
+	// a lost registration is sent again
+	s.server.mux.Lock()
+	s.server.records = make(map[string][]dns.RR)
+	s.server.mux.Unlock()
+	s.sut.browse()
+	assert.Eventually(s.T(), func() bool {
+		return len(s.server.lookup("_ship._tcp."+unicastTestDomain, dns.TypePTR)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.sut.Unannounce()
+	assert.Equal(s.T(), 0, len(s.server.lookup("_ship._tcp."+unicastTestDomain, dns.TypePTR)))
+	assert.Equal(s.T(), 0, len(s.server.lookup(`Renamed\ Device._ship._tcp.`+unicastTestDomain, dns.TypeTXT)))
+	assert.Equal(s.T(), 0, len(s.server.lookup("local."+unicastTestDomain, dns.TypeA)))
+}
+
+func (s *UnicastSuite) Test_Register_Refused() {
+	s.server.shutdown()
+	s.server = newTestDNSServer(s.T(), true)
+	s.sut = NewUnicastProvider(UnicastConfig{
+		Server:         s.server.addr,
+		Domain:         "example.test",
+		BrowseInterval: time.Hour,
+		Timeout:        time.Second,
+		Register:       true,
+		Addresses:      []net.IP{net.ParseIP("192.0.2.10")},
+	})
+	assert.True(s.T(), s.sut.Start(true, s.cb))
+
+	err := s.sut.Announce("Local Device", 4711, []string{"txtvers=1"})
+	assert.NotNil(s.T(), err)
+
+	err = s.sut.Announce("", 4711, nil)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *UnicastSuite) Test_NoRegister() {
+	err := s.sut.Announce("Local Device", 4711, []string{"txtvers=1"})
+	assert.Nil(s.T(), err)
+
+	assert.True(s.T(), s.sut.Start(true, s.cb))
+	s.sut.browse()
+
+	s.server.mux.Lock()
+	assert.Equal(s.T(), 0, s.server.updates)
+	s.server.mux.Unlock()
+}
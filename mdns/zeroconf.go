@@ -15,6 +15,8 @@ type ZeroconfProvider struct {
 
 	zc *zeroconf.Server
 
+	cb api.MdnsResolveCB
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
@@ -30,7 +32,11 @@ func NewZeroconfProvider(ifaces []net.Interface) *ZeroconfProvider {
 var _ api.MdnsProviderInterface = (*ZeroconfProvider)(nil)
 
 func (z *ZeroconfProvider) Start(autoReconnect bool, cb api.MdnsResolveCB) bool {
-	go z.chanListener(cb)
+	z.mux.Lock()
+	defer z.mux.Unlock()
+
+	z.cb = cb
+	z.browse()
 
 	return true
 }
@@ -43,9 +49,34 @@ func (z *ZeroconfProvider) Shutdown() {
 
 	if z.cancel != nil {
 		z.cancel()
+		z.cancel = nil
 	}
 }
 
+// Query the network for SHIP services again
+//
+// The browse is restarted, so all responding services are reported again
+func (z *ZeroconfProvider) Query() {
+	z.mux.Lock()
+	defer z.mux.Unlock()
+
+	// not started or already shut down
+	if z.cancel == nil {
+		return
+	}
+
+	z.cancel()
+	z.browse()
+}
+
+// start a new browse, needs to be called with mux locked
+func (z *ZeroconfProvider) browse() {
+	// for Zeroconf we need a context
+	z.ctx, z.cancel = context.WithCancel(context.Background())
+
+	go z.chanListener(z.ctx, z.cb)
+}
+
 func (z *ZeroconfProvider) Announce(serviceName string, port int, txt []string) error {
 	logging.Log().Debug("mdns: using zeroconf")
 
@@ -76,23 +107,29 @@ func (z *ZeroconfProvider) Unannounce() {
 	z.zc = nil
 }
 
-func (z *ZeroconfProvider) chanListener(cb api.MdnsResolveCB) {
+func (z *ZeroconfProvider) chanListener(ctx context.Context, cb api.MdnsResolveCB) {
 	zcEntries := make(chan *zeroconf.ServiceEntry)
 	zcRemoved := make(chan *zeroconf.ServiceEntry)
 
-	z.mux.Lock()
-	// for Zeroconf we need a context
-	z.ctx, z.cancel = context.WithCancel(context.Background())
-	z.mux.Unlock()
+	browseDone := make(chan struct{})
 
 	go func() {
-		_ = zeroconf.Browse(z.ctx, shipZeroConfServiceType, shipZeroConfDomain, zcEntries, zcRemoved)
+		_ = zeroconf.Browse(ctx, shipZeroConfServiceType, shipZeroConfDomain, zcEntries, zcRemoved)
+		close(browseDone)
 	}()
 
 	for {
 		select {
-		case <-z.ctx.Done():
-			return
+		case <-ctx.Done():
+			// the browse may still be sending an entry, drain the channels until it ended
+			for {
+				select {
+				case <-browseDone:
+					return
+				case <-zcEntries:
+				case <-zcRemoved:
+				}
+			}
 		case service := <-zcRemoved:
 			// Zeroconf has issues with merging mDNS data and sometimes reports incomplete records
 			if service == nil || len(service.Text) == 0 {
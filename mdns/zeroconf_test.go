@@ -50,6 +50,25 @@ func (z *ZeroconfSuite) Test_Shutdown() {
 	z.sut.Shutdown()
 }
 
+func (z *ZeroconfSuite) Test_Query() {
+	// not started
+	z.sut.Query()
+
+	boolV := z.sut.Start(false, func(elements map[string]string, name, host string, addresses []net.IP, port int, remove bool) {})
+	assert.Equal(z.T(), true, boolV)
+
+	z.sut.mux.Lock()
+	ctx := z.sut.ctx
+	z.sut.mux.Unlock()
+
+	// the browse is restarted
+	z.sut.Query()
+	assert.NotNil(z.T(), ctx.Err())
+
+	z.sut.Shutdown()
+	z.sut.Query()
+}
+
 func (z *ZeroconfSuite) Test_ZeroConf() {
 	var addedEntries, removedEntries []mDNSEntry
 
@@ -4,7 +4,13 @@ package mocks
 
 import (
 	api "github.com/enbility/ship-go/api"
+	http "net/http"
+
 	mock "github.com/stretchr/testify/mock"
+
+	net "net"
+
+	tls "crypto/tls"
 )
 
 // HubInterface is an autogenerated mock type for the HubInterface type
@@ -53,6 +59,101 @@ func (_c *HubInterface_CancelPairingWithSKI_Call) RunAndReturn(run func(string))
 	return _c
 }
 
+// ConnectionHistoryForSKI provides a mock function with given fields: ski
+func (_m *HubInterface) ConnectionHistoryForSKI(ski string) []api.ShipConnectionHistory {
+	ret := _m.Called(ski)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConnectionHistoryForSKI")
+	}
+
+	var r0 []api.ShipConnectionHistory
+	if rf, ok := ret.Get(0).(func(string) []api.ShipConnectionHistory); ok {
+		r0 = rf(ski)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.ShipConnectionHistory)
+		}
+	}
+
+	return r0
+}
+
+// HubInterface_ConnectionHistoryForSKI_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConnectionHistoryForSKI'
+type HubInterface_ConnectionHistoryForSKI_Call struct {
+	*mock.Call
+}
+
+// ConnectionHistoryForSKI is a helper method to define mock.On call
+//   - ski string
+func (_e *HubInterface_Expecter) ConnectionHistoryForSKI(ski interface{}) *HubInterface_ConnectionHistoryForSKI_Call {
+	return &HubInterface_ConnectionHistoryForSKI_Call{Call: _e.mock.On("ConnectionHistoryForSKI", ski)}
+}
+
+func (_c *HubInterface_ConnectionHistoryForSKI_Call) Run(run func(ski string)) *HubInterface_ConnectionHistoryForSKI_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *HubInterface_ConnectionHistoryForSKI_Call) Return(_a0 []api.ShipConnectionHistory) *HubInterface_ConnectionHistoryForSKI_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *HubInterface_ConnectionHistoryForSKI_Call) RunAndReturn(run func(string) []api.ShipConnectionHistory) *HubInterface_ConnectionHistoryForSKI_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Connections provides a mock function with given fields:
+func (_m *HubInterface) Connections() []api.ShipConnectionInfo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Connections")
+	}
+
+	var r0 []api.ShipConnectionInfo
+	if rf, ok := ret.Get(0).(func() []api.ShipConnectionInfo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.ShipConnectionInfo)
+		}
+	}
+
+	return r0
+}
+
+// HubInterface_Connections_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Connections'
+type HubInterface_Connections_Call struct {
+	*mock.Call
+}
+
+// Connections is a helper method to define mock.On call
+func (_e *HubInterface_Expecter) Connections() *HubInterface_Connections_Call {
+	return &HubInterface_Connections_Call{Call: _e.mock.On("Connections")}
+}
+
+func (_c *HubInterface_Connections_Call) Run(run func()) *HubInterface_Connections_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *HubInterface_Connections_Call) Return(_a0 []api.ShipConnectionInfo) *HubInterface_Connections_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *HubInterface_Connections_Call) RunAndReturn(run func() []api.ShipConnectionInfo) *HubInterface_Connections_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DisconnectSKI provides a mock function with given fields: ski, reason
 func (_m *HubInterface) DisconnectSKI(ski string, reason string) {
 	_m.Called(ski, reason)
@@ -87,6 +188,53 @@ func (_c *HubInterface_DisconnectSKI_Call) RunAndReturn(run func(string, string)
 	return _c
 }
 
+// KnownServices provides a mock function with given fields:
+func (_m *HubInterface) KnownServices() []*api.ServiceDetails {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for KnownServices")
+	}
+
+	var r0 []*api.ServiceDetails
+	if rf, ok := ret.Get(0).(func() []*api.ServiceDetails); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*api.ServiceDetails)
+		}
+	}
+
+	return r0
+}
+
+// HubInterface_KnownServices_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KnownServices'
+type HubInterface_KnownServices_Call struct {
+	*mock.Call
+}
+
+// KnownServices is a helper method to define mock.On call
+func (_e *HubInterface_Expecter) KnownServices() *HubInterface_KnownServices_Call {
+	return &HubInterface_KnownServices_Call{Call: _e.mock.On("KnownServices")}
+}
+
+func (_c *HubInterface_KnownServices_Call) Run(run func()) *HubInterface_KnownServices_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *HubInterface_KnownServices_Call) Return(_a0 []*api.ServiceDetails) *HubInterface_KnownServices_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *HubInterface_KnownServices_Call) RunAndReturn(run func() []*api.ServiceDetails) *HubInterface_KnownServices_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // PairingDetailForSki provides a mock function with given fields: ski
 func (_m *HubInterface) PairingDetailForSki(ski string) *api.ConnectionStateDetail {
 	ret := _m.Called(ski)
@@ -135,6 +283,40 @@ func (_c *HubInterface_PairingDetailForSki_Call) RunAndReturn(run func(string) *
 	return _c
 }
 
+// PreAuthorizeRemoteService provides a mock function with given fields: ski, shipID
+func (_m *HubInterface) PreAuthorizeRemoteService(ski string, shipID string) {
+	_m.Called(ski, shipID)
+}
+
+// HubInterface_PreAuthorizeRemoteService_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PreAuthorizeRemoteService'
+type HubInterface_PreAuthorizeRemoteService_Call struct {
+	*mock.Call
+}
+
+// PreAuthorizeRemoteService is a helper method to define mock.On call
+//   - ski string
+//   - shipID string
+func (_e *HubInterface_Expecter) PreAuthorizeRemoteService(ski interface{}, shipID interface{}) *HubInterface_PreAuthorizeRemoteService_Call {
+	return &HubInterface_PreAuthorizeRemoteService_Call{Call: _e.mock.On("PreAuthorizeRemoteService", ski, shipID)}
+}
+
+func (_c *HubInterface_PreAuthorizeRemoteService_Call) Run(run func(ski string, shipID string)) *HubInterface_PreAuthorizeRemoteService_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *HubInterface_PreAuthorizeRemoteService_Call) Return() *HubInterface_PreAuthorizeRemoteService_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_PreAuthorizeRemoteService_Call) RunAndReturn(run func(string, string)) *HubInterface_PreAuthorizeRemoteService_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RegisterRemoteSKI provides a mock function with given fields: ski
 func (_m *HubInterface) RegisterRemoteSKI(ski string) {
 	_m.Called(ski)
@@ -168,6 +350,73 @@ func (_c *HubInterface_RegisterRemoteSKI_Call) RunAndReturn(run func(string)) *H
 	return _c
 }
 
+// RemovePreAuthorizedRemoteService provides a mock function with given fields: ski
+func (_m *HubInterface) RemovePreAuthorizedRemoteService(ski string) {
+	_m.Called(ski)
+}
+
+// HubInterface_RemovePreAuthorizedRemoteService_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemovePreAuthorizedRemoteService'
+type HubInterface_RemovePreAuthorizedRemoteService_Call struct {
+	*mock.Call
+}
+
+// RemovePreAuthorizedRemoteService is a helper method to define mock.On call
+//   - ski string
+func (_e *HubInterface_Expecter) RemovePreAuthorizedRemoteService(ski interface{}) *HubInterface_RemovePreAuthorizedRemoteService_Call {
+	return &HubInterface_RemovePreAuthorizedRemoteService_Call{Call: _e.mock.On("RemovePreAuthorizedRemoteService", ski)}
+}
+
+func (_c *HubInterface_RemovePreAuthorizedRemoteService_Call) Run(run func(ski string)) *HubInterface_RemovePreAuthorizedRemoteService_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *HubInterface_RemovePreAuthorizedRemoteService_Call) Return() *HubInterface_RemovePreAuthorizedRemoteService_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_RemovePreAuthorizedRemoteService_Call) RunAndReturn(run func(string)) *HubInterface_RemovePreAuthorizedRemoteService_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ServeHTTP provides a mock function with given fields: _a0, _a1
+func (_m *HubInterface) ServeHTTP(_a0 http.ResponseWriter, _a1 *http.Request) {
+	_m.Called(_a0, _a1)
+}
+
+// HubInterface_ServeHTTP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ServeHTTP'
+type HubInterface_ServeHTTP_Call struct {
+	*mock.Call
+}
+
+// ServeHTTP is a helper method to define mock.On call
+//   - _a0 http.ResponseWriter
+//   - _a1 *http.Request
+func (_e *HubInterface_Expecter) ServeHTTP(_a0 interface{}, _a1 interface{}) *HubInterface_ServeHTTP_Call {
+	return &HubInterface_ServeHTTP_Call{Call: _e.mock.On("ServeHTTP", _a0, _a1)}
+}
+
+func (_c *HubInterface_ServeHTTP_Call) Run(run func(_a0 http.ResponseWriter, _a1 *http.Request)) *HubInterface_ServeHTTP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *HubInterface_ServeHTTP_Call) Return() *HubInterface_ServeHTTP_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_ServeHTTP_Call) RunAndReturn(run func(http.ResponseWriter, *http.Request)) *HubInterface_ServeHTTP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ServiceForSKI provides a mock function with given fields: ski
 func (_m *HubInterface) ServiceForSKI(ski string) *api.ServiceDetails {
 	ret := _m.Called(ski)
@@ -249,6 +498,336 @@ func (_c *HubInterface_SetAutoAccept_Call) RunAndReturn(run func(bool)) *HubInte
 	return _c
 }
 
+// SetCallbackConfig provides a mock function with given fields: config
+func (_m *HubInterface) SetCallbackConfig(config api.CallbackConfig) {
+	_m.Called(config)
+}
+
+// HubInterface_SetCallbackConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetCallbackConfig'
+type HubInterface_SetCallbackConfig_Call struct {
+	*mock.Call
+}
+
+// SetCallbackConfig is a helper method to define mock.On call
+//   - config api.CallbackConfig
+func (_e *HubInterface_Expecter) SetCallbackConfig(config interface{}) *HubInterface_SetCallbackConfig_Call {
+	return &HubInterface_SetCallbackConfig_Call{Call: _e.mock.On("SetCallbackConfig", config)}
+}
+
+func (_c *HubInterface_SetCallbackConfig_Call) Run(run func(config api.CallbackConfig)) *HubInterface_SetCallbackConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.CallbackConfig))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetCallbackConfig_Call) Return() *HubInterface_SetCallbackConfig_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetCallbackConfig_Call) RunAndReturn(run func(api.CallbackConfig)) *HubInterface_SetCallbackConfig_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetClock provides a mock function with given fields: clock
+func (_m *HubInterface) SetClock(clock api.ClockInterface) {
+	_m.Called(clock)
+}
+
+// HubInterface_SetClock_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetClock'
+type HubInterface_SetClock_Call struct {
+	*mock.Call
+}
+
+// SetClock is a helper method to define mock.On call
+//   - clock api.ClockInterface
+func (_e *HubInterface_Expecter) SetClock(clock interface{}) *HubInterface_SetClock_Call {
+	return &HubInterface_SetClock_Call{Call: _e.mock.On("SetClock", clock)}
+}
+
+func (_c *HubInterface_SetClock_Call) Run(run func(clock api.ClockInterface)) *HubInterface_SetClock_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.ClockInterface))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetClock_Call) Return() *HubInterface_SetClock_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetClock_Call) RunAndReturn(run func(api.ClockInterface)) *HubInterface_SetClock_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetConnectionLimits provides a mock function with given fields: limits
+func (_m *HubInterface) SetConnectionLimits(limits api.ConnectionLimits) {
+	_m.Called(limits)
+}
+
+// HubInterface_SetConnectionLimits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetConnectionLimits'
+type HubInterface_SetConnectionLimits_Call struct {
+	*mock.Call
+}
+
+// SetConnectionLimits is a helper method to define mock.On call
+//   - limits api.ConnectionLimits
+func (_e *HubInterface_Expecter) SetConnectionLimits(limits interface{}) *HubInterface_SetConnectionLimits_Call {
+	return &HubInterface_SetConnectionLimits_Call{Call: _e.mock.On("SetConnectionLimits", limits)}
+}
+
+func (_c *HubInterface_SetConnectionLimits_Call) Run(run func(limits api.ConnectionLimits)) *HubInterface_SetConnectionLimits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.ConnectionLimits))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetConnectionLimits_Call) Return() *HubInterface_SetConnectionLimits_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetConnectionLimits_Call) RunAndReturn(run func(api.ConnectionLimits)) *HubInterface_SetConnectionLimits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetExternalWebsocketServer provides a mock function with given fields: port
+func (_m *HubInterface) SetExternalWebsocketServer(port int) {
+	_m.Called(port)
+}
+
+// HubInterface_SetExternalWebsocketServer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetExternalWebsocketServer'
+type HubInterface_SetExternalWebsocketServer_Call struct {
+	*mock.Call
+}
+
+// SetExternalWebsocketServer is a helper method to define mock.On call
+//   - port int
+func (_e *HubInterface_Expecter) SetExternalWebsocketServer(port interface{}) *HubInterface_SetExternalWebsocketServer_Call {
+	return &HubInterface_SetExternalWebsocketServer_Call{Call: _e.mock.On("SetExternalWebsocketServer", port)}
+}
+
+func (_c *HubInterface_SetExternalWebsocketServer_Call) Run(run func(port int)) *HubInterface_SetExternalWebsocketServer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetExternalWebsocketServer_Call) Return() *HubInterface_SetExternalWebsocketServer_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetExternalWebsocketServer_Call) RunAndReturn(run func(int)) *HubInterface_SetExternalWebsocketServer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetPairingPolicy provides a mock function with given fields: policy
+func (_m *HubInterface) SetPairingPolicy(policy api.PairingPolicyInterface) {
+	_m.Called(policy)
+}
+
+// HubInterface_SetPairingPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetPairingPolicy'
+type HubInterface_SetPairingPolicy_Call struct {
+	*mock.Call
+}
+
+// SetPairingPolicy is a helper method to define mock.On call
+//   - policy api.PairingPolicyInterface
+func (_e *HubInterface_Expecter) SetPairingPolicy(policy interface{}) *HubInterface_SetPairingPolicy_Call {
+	return &HubInterface_SetPairingPolicy_Call{Call: _e.mock.On("SetPairingPolicy", policy)}
+}
+
+func (_c *HubInterface_SetPairingPolicy_Call) Run(run func(policy api.PairingPolicyInterface)) *HubInterface_SetPairingPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.PairingPolicyInterface))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetPairingPolicy_Call) Return() *HubInterface_SetPairingPolicy_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetPairingPolicy_Call) RunAndReturn(run func(api.PairingPolicyInterface)) *HubInterface_SetPairingPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetSpineBufferConfig provides a mock function with given fields: config
+func (_m *HubInterface) SetSpineBufferConfig(config api.SpineBufferConfig) {
+	_m.Called(config)
+}
+
+// HubInterface_SetSpineBufferConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetSpineBufferConfig'
+type HubInterface_SetSpineBufferConfig_Call struct {
+	*mock.Call
+}
+
+// SetSpineBufferConfig is a helper method to define mock.On call
+//   - config api.SpineBufferConfig
+func (_e *HubInterface_Expecter) SetSpineBufferConfig(config interface{}) *HubInterface_SetSpineBufferConfig_Call {
+	return &HubInterface_SetSpineBufferConfig_Call{Call: _e.mock.On("SetSpineBufferConfig", config)}
+}
+
+func (_c *HubInterface_SetSpineBufferConfig_Call) Run(run func(config api.SpineBufferConfig)) *HubInterface_SetSpineBufferConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.SpineBufferConfig))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetSpineBufferConfig_Call) Return() *HubInterface_SetSpineBufferConfig_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetSpineBufferConfig_Call) RunAndReturn(run func(api.SpineBufferConfig)) *HubInterface_SetSpineBufferConfig_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUnpairOnRemoteRemoval provides a mock function with given fields: enable
+func (_m *HubInterface) SetUnpairOnRemoteRemoval(enable bool) {
+	_m.Called(enable)
+}
+
+// HubInterface_SetUnpairOnRemoteRemoval_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUnpairOnRemoteRemoval'
+type HubInterface_SetUnpairOnRemoteRemoval_Call struct {
+	*mock.Call
+}
+
+// SetUnpairOnRemoteRemoval is a helper method to define mock.On call
+//   - enable bool
+func (_e *HubInterface_Expecter) SetUnpairOnRemoteRemoval(enable interface{}) *HubInterface_SetUnpairOnRemoteRemoval_Call {
+	return &HubInterface_SetUnpairOnRemoteRemoval_Call{Call: _e.mock.On("SetUnpairOnRemoteRemoval", enable)}
+}
+
+func (_c *HubInterface_SetUnpairOnRemoteRemoval_Call) Run(run func(enable bool)) *HubInterface_SetUnpairOnRemoteRemoval_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetUnpairOnRemoteRemoval_Call) Return() *HubInterface_SetUnpairOnRemoteRemoval_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetUnpairOnRemoteRemoval_Call) RunAndReturn(run func(bool)) *HubInterface_SetUnpairOnRemoteRemoval_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWebsocketListener provides a mock function with given fields: listener
+func (_m *HubInterface) SetWebsocketListener(listener net.Listener) {
+	_m.Called(listener)
+}
+
+// HubInterface_SetWebsocketListener_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWebsocketListener'
+type HubInterface_SetWebsocketListener_Call struct {
+	*mock.Call
+}
+
+// SetWebsocketListener is a helper method to define mock.On call
+//   - listener net.Listener
+func (_e *HubInterface_Expecter) SetWebsocketListener(listener interface{}) *HubInterface_SetWebsocketListener_Call {
+	return &HubInterface_SetWebsocketListener_Call{Call: _e.mock.On("SetWebsocketListener", listener)}
+}
+
+func (_c *HubInterface_SetWebsocketListener_Call) Run(run func(listener net.Listener)) *HubInterface_SetWebsocketListener_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(net.Listener))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetWebsocketListener_Call) Return() *HubInterface_SetWebsocketListener_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetWebsocketListener_Call) RunAndReturn(run func(net.Listener)) *HubInterface_SetWebsocketListener_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWebsocketPath provides a mock function with given fields: path
+func (_m *HubInterface) SetWebsocketPath(path string) {
+	_m.Called(path)
+}
+
+// HubInterface_SetWebsocketPath_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWebsocketPath'
+type HubInterface_SetWebsocketPath_Call struct {
+	*mock.Call
+}
+
+// SetWebsocketPath is a helper method to define mock.On call
+//   - path string
+func (_e *HubInterface_Expecter) SetWebsocketPath(path interface{}) *HubInterface_SetWebsocketPath_Call {
+	return &HubInterface_SetWebsocketPath_Call{Call: _e.mock.On("SetWebsocketPath", path)}
+}
+
+func (_c *HubInterface_SetWebsocketPath_Call) Run(run func(path string)) *HubInterface_SetWebsocketPath_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetWebsocketPath_Call) Return() *HubInterface_SetWebsocketPath_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetWebsocketPath_Call) RunAndReturn(run func(string)) *HubInterface_SetWebsocketPath_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWebsocketWriteQueueSize provides a mock function with given fields: size
+func (_m *HubInterface) SetWebsocketWriteQueueSize(size int) {
+	_m.Called(size)
+}
+
+// HubInterface_SetWebsocketWriteQueueSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWebsocketWriteQueueSize'
+type HubInterface_SetWebsocketWriteQueueSize_Call struct {
+	*mock.Call
+}
+
+// SetWebsocketWriteQueueSize is a helper method to define mock.On call
+//   - size int
+func (_e *HubInterface_Expecter) SetWebsocketWriteQueueSize(size interface{}) *HubInterface_SetWebsocketWriteQueueSize_Call {
+	return &HubInterface_SetWebsocketWriteQueueSize_Call{Call: _e.mock.On("SetWebsocketWriteQueueSize", size)}
+}
+
+func (_c *HubInterface_SetWebsocketWriteQueueSize_Call) Run(run func(size int)) *HubInterface_SetWebsocketWriteQueueSize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *HubInterface_SetWebsocketWriteQueueSize_Call) Return() *HubInterface_SetWebsocketWriteQueueSize_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubInterface_SetWebsocketWriteQueueSize_Call) RunAndReturn(run func(int)) *HubInterface_SetWebsocketWriteQueueSize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Shutdown provides a mock function with given fields:
 func (_m *HubInterface) Shutdown() {
 	_m.Called()
@@ -313,6 +892,53 @@ func (_c *HubInterface_Start_Call) RunAndReturn(run func()) *HubInterface_Start_
 	return _c
 }
 
+// TLSConfig provides a mock function with given fields:
+func (_m *HubInterface) TLSConfig() *tls.Config {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for TLSConfig")
+	}
+
+	var r0 *tls.Config
+	if rf, ok := ret.Get(0).(func() *tls.Config); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*tls.Config)
+		}
+	}
+
+	return r0
+}
+
+// HubInterface_TLSConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TLSConfig'
+type HubInterface_TLSConfig_Call struct {
+	*mock.Call
+}
+
+// TLSConfig is a helper method to define mock.On call
+func (_e *HubInterface_Expecter) TLSConfig() *HubInterface_TLSConfig_Call {
+	return &HubInterface_TLSConfig_Call{Call: _e.mock.On("TLSConfig")}
+}
+
+func (_c *HubInterface_TLSConfig_Call) Run(run func()) *HubInterface_TLSConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *HubInterface_TLSConfig_Call) Return(_a0 *tls.Config) *HubInterface_TLSConfig_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *HubInterface_TLSConfig_Call) RunAndReturn(run func() *tls.Config) *HubInterface_TLSConfig_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UnregisterRemoteSKI provides a mock function with given fields: ski
 func (_m *HubInterface) UnregisterRemoteSKI(ski string) {
 	_m.Called(ski)
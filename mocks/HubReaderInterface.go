@@ -66,9 +66,9 @@ func (_c *HubReaderInterface_AllowWaitingForTrust_Call) RunAndReturn(run func(st
 	return _c
 }
 
-// RemoteSKIConnected provides a mock function with given fields: ski
-func (_m *HubReaderInterface) RemoteSKIConnected(ski string) {
-	_m.Called(ski)
+// RemoteSKIConnected provides a mock function with given fields: ski, generation
+func (_m *HubReaderInterface) RemoteSKIConnected(ski string, generation uint64) {
+	_m.Called(ski, generation)
 }
 
 // HubReaderInterface_RemoteSKIConnected_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteSKIConnected'
@@ -78,13 +78,14 @@ type HubReaderInterface_RemoteSKIConnected_Call struct {
 
 // RemoteSKIConnected is a helper method to define mock.On call
 //   - ski string
-func (_e *HubReaderInterface_Expecter) RemoteSKIConnected(ski interface{}) *HubReaderInterface_RemoteSKIConnected_Call {
-	return &HubReaderInterface_RemoteSKIConnected_Call{Call: _e.mock.On("RemoteSKIConnected", ski)}
+//   - generation uint64
+func (_e *HubReaderInterface_Expecter) RemoteSKIConnected(ski interface{}, generation interface{}) *HubReaderInterface_RemoteSKIConnected_Call {
+	return &HubReaderInterface_RemoteSKIConnected_Call{Call: _e.mock.On("RemoteSKIConnected", ski, generation)}
 }
 
-func (_c *HubReaderInterface_RemoteSKIConnected_Call) Run(run func(ski string)) *HubReaderInterface_RemoteSKIConnected_Call {
+func (_c *HubReaderInterface_RemoteSKIConnected_Call) Run(run func(ski string, generation uint64)) *HubReaderInterface_RemoteSKIConnected_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(string), args[1].(uint64))
 	})
 	return _c
 }
@@ -94,14 +95,14 @@ func (_c *HubReaderInterface_RemoteSKIConnected_Call) Return() *HubReaderInterfa
 	return _c
 }
 
-func (_c *HubReaderInterface_RemoteSKIConnected_Call) RunAndReturn(run func(string)) *HubReaderInterface_RemoteSKIConnected_Call {
+func (_c *HubReaderInterface_RemoteSKIConnected_Call) RunAndReturn(run func(string, uint64)) *HubReaderInterface_RemoteSKIConnected_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoteSKIDisconnected provides a mock function with given fields: ski
-func (_m *HubReaderInterface) RemoteSKIDisconnected(ski string) {
-	_m.Called(ski)
+// RemoteSKIDisconnected provides a mock function with given fields: ski, generation, details
+func (_m *HubReaderInterface) RemoteSKIDisconnected(ski string, generation uint64, details api.ShipCloseDetails) {
+	_m.Called(ski, generation, details)
 }
 
 // HubReaderInterface_RemoteSKIDisconnected_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteSKIDisconnected'
@@ -111,13 +112,15 @@ type HubReaderInterface_RemoteSKIDisconnected_Call struct {
 
 // RemoteSKIDisconnected is a helper method to define mock.On call
 //   - ski string
-func (_e *HubReaderInterface_Expecter) RemoteSKIDisconnected(ski interface{}) *HubReaderInterface_RemoteSKIDisconnected_Call {
-	return &HubReaderInterface_RemoteSKIDisconnected_Call{Call: _e.mock.On("RemoteSKIDisconnected", ski)}
+//   - generation uint64
+//   - details api.ShipCloseDetails
+func (_e *HubReaderInterface_Expecter) RemoteSKIDisconnected(ski interface{}, generation interface{}, details interface{}) *HubReaderInterface_RemoteSKIDisconnected_Call {
+	return &HubReaderInterface_RemoteSKIDisconnected_Call{Call: _e.mock.On("RemoteSKIDisconnected", ski, generation, details)}
 }
 
-func (_c *HubReaderInterface_RemoteSKIDisconnected_Call) Run(run func(ski string)) *HubReaderInterface_RemoteSKIDisconnected_Call {
+func (_c *HubReaderInterface_RemoteSKIDisconnected_Call) Run(run func(ski string, generation uint64, details api.ShipCloseDetails)) *HubReaderInterface_RemoteSKIDisconnected_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(string), args[1].(uint64), args[2].(api.ShipCloseDetails))
 	})
 	return _c
 }
@@ -127,7 +130,40 @@ func (_c *HubReaderInterface_RemoteSKIDisconnected_Call) Return() *HubReaderInte
 	return _c
 }
 
-func (_c *HubReaderInterface_RemoteSKIDisconnected_Call) RunAndReturn(run func(string)) *HubReaderInterface_RemoteSKIDisconnected_Call {
+func (_c *HubReaderInterface_RemoteSKIDisconnected_Call) RunAndReturn(run func(string, uint64, api.ShipCloseDetails)) *HubReaderInterface_RemoteSKIDisconnected_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoteSKIPairingRemoved provides a mock function with given fields: ski
+func (_m *HubReaderInterface) RemoteSKIPairingRemoved(ski string) {
+	_m.Called(ski)
+}
+
+// HubReaderInterface_RemoteSKIPairingRemoved_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteSKIPairingRemoved'
+type HubReaderInterface_RemoteSKIPairingRemoved_Call struct {
+	*mock.Call
+}
+
+// RemoteSKIPairingRemoved is a helper method to define mock.On call
+//   - ski string
+func (_e *HubReaderInterface_Expecter) RemoteSKIPairingRemoved(ski interface{}) *HubReaderInterface_RemoteSKIPairingRemoved_Call {
+	return &HubReaderInterface_RemoteSKIPairingRemoved_Call{Call: _e.mock.On("RemoteSKIPairingRemoved", ski)}
+}
+
+func (_c *HubReaderInterface_RemoteSKIPairingRemoved_Call) Run(run func(ski string)) *HubReaderInterface_RemoteSKIPairingRemoved_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *HubReaderInterface_RemoteSKIPairingRemoved_Call) Return() *HubReaderInterface_RemoteSKIPairingRemoved_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *HubReaderInterface_RemoteSKIPairingRemoved_Call) RunAndReturn(run func(string)) *HubReaderInterface_RemoteSKIPairingRemoved_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -175,6 +175,40 @@ func (_c *MdnsInterface_SetAutoAccept_Call) RunAndReturn(run func(bool)) *MdnsIn
 	return _c
 }
 
+// SetWebsocketEndpoint provides a mock function with given fields: port, path
+func (_m *MdnsInterface) SetWebsocketEndpoint(port int, path string) {
+	_m.Called(port, path)
+}
+
+// MdnsInterface_SetWebsocketEndpoint_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWebsocketEndpoint'
+type MdnsInterface_SetWebsocketEndpoint_Call struct {
+	*mock.Call
+}
+
+// SetWebsocketEndpoint is a helper method to define mock.On call
+//   - port int
+//   - path string
+func (_e *MdnsInterface_Expecter) SetWebsocketEndpoint(port interface{}, path interface{}) *MdnsInterface_SetWebsocketEndpoint_Call {
+	return &MdnsInterface_SetWebsocketEndpoint_Call{Call: _e.mock.On("SetWebsocketEndpoint", port, path)}
+}
+
+func (_c *MdnsInterface_SetWebsocketEndpoint_Call) Run(run func(port int, path string)) *MdnsInterface_SetWebsocketEndpoint_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MdnsInterface_SetWebsocketEndpoint_Call) Return() *MdnsInterface_SetWebsocketEndpoint_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MdnsInterface_SetWebsocketEndpoint_Call) RunAndReturn(run func(int, string)) *MdnsInterface_SetWebsocketEndpoint_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Shutdown provides a mock function with given fields:
 func (_m *MdnsInterface) Shutdown() {
 	_m.Called()
@@ -68,6 +68,38 @@ func (_c *MdnsProviderInterface_Announce_Call) RunAndReturn(run func(string, int
 	return _c
 }
 
+// Query provides a mock function with given fields:
+func (_m *MdnsProviderInterface) Query() {
+	_m.Called()
+}
+
+// MdnsProviderInterface_Query_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Query'
+type MdnsProviderInterface_Query_Call struct {
+	*mock.Call
+}
+
+// Query is a helper method to define mock.On call
+func (_e *MdnsProviderInterface_Expecter) Query() *MdnsProviderInterface_Query_Call {
+	return &MdnsProviderInterface_Query_Call{Call: _e.mock.On("Query")}
+}
+
+func (_c *MdnsProviderInterface_Query_Call) Run(run func()) *MdnsProviderInterface_Query_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MdnsProviderInterface_Query_Call) Return() *MdnsProviderInterface_Query_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MdnsProviderInterface_Query_Call) RunAndReturn(run func()) *MdnsProviderInterface_Query_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Shutdown provides a mock function with given fields:
 func (_m *MdnsProviderInterface) Shutdown() {
 	_m.Called()
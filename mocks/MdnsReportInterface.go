@@ -54,6 +54,39 @@ func (_c *MdnsReportInterface_ReportMdnsEntries_Call) RunAndReturn(run func(map[
 	return _c
 }
 
+// ReportMdnsEntryUpdate provides a mock function with given fields: update
+func (_m *MdnsReportInterface) ReportMdnsEntryUpdate(update api.MdnsEntryUpdate) {
+	_m.Called(update)
+}
+
+// MdnsReportInterface_ReportMdnsEntryUpdate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReportMdnsEntryUpdate'
+type MdnsReportInterface_ReportMdnsEntryUpdate_Call struct {
+	*mock.Call
+}
+
+// ReportMdnsEntryUpdate is a helper method to define mock.On call
+//   - update api.MdnsEntryUpdate
+func (_e *MdnsReportInterface_Expecter) ReportMdnsEntryUpdate(update interface{}) *MdnsReportInterface_ReportMdnsEntryUpdate_Call {
+	return &MdnsReportInterface_ReportMdnsEntryUpdate_Call{Call: _e.mock.On("ReportMdnsEntryUpdate", update)}
+}
+
+func (_c *MdnsReportInterface_ReportMdnsEntryUpdate_Call) Run(run func(update api.MdnsEntryUpdate)) *MdnsReportInterface_ReportMdnsEntryUpdate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.MdnsEntryUpdate))
+	})
+	return _c
+}
+
+func (_c *MdnsReportInterface_ReportMdnsEntryUpdate_Call) Return() *MdnsReportInterface_ReportMdnsEntryUpdate_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MdnsReportInterface_ReportMdnsEntryUpdate_Call) RunAndReturn(run func(api.MdnsEntryUpdate)) *MdnsReportInterface_ReportMdnsEntryUpdate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMdnsReportInterface creates a new instance of MdnsReportInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMdnsReportInterface(t interface {
@@ -50,6 +50,54 @@ func (_c *ShipConnectionDataWriterInterface_WriteShipMessageWithPayload_Call) Ru
 	return _c
 }
 
+// WriteShipMessageWithPayloadResult provides a mock function with given fields: message
+func (_m *ShipConnectionDataWriterInterface) WriteShipMessageWithPayloadResult(message []byte) <-chan error {
+	ret := _m.Called(message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteShipMessageWithPayloadResult")
+	}
+
+	var r0 <-chan error
+	if rf, ok := ret.Get(0).(func([]byte) <-chan error); ok {
+		r0 = rf(message)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan error)
+		}
+	}
+
+	return r0
+}
+
+// ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteShipMessageWithPayloadResult'
+type ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call struct {
+	*mock.Call
+}
+
+// WriteShipMessageWithPayloadResult is a helper method to define mock.On call
+//   - message []byte
+func (_e *ShipConnectionDataWriterInterface_Expecter) WriteShipMessageWithPayloadResult(message interface{}) *ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call {
+	return &ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call{Call: _e.mock.On("WriteShipMessageWithPayloadResult", message)}
+}
+
+func (_c *ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call) Run(run func(message []byte)) *ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call) Return(_a0 <-chan error) *ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call) RunAndReturn(run func([]byte) <-chan error) *ShipConnectionDataWriterInterface_WriteShipMessageWithPayloadResult_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewShipConnectionDataWriterInterface creates a new instance of ShipConnectionDataWriterInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewShipConnectionDataWriterInterface(t interface {
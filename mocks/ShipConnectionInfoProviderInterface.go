@@ -102,6 +102,72 @@ func (_c *ShipConnectionInfoProviderInterface_HandleConnectionClosed_Call) RunAn
 	return _c
 }
 
+// HandleRemotePairingRemoved provides a mock function with given fields: _a0
+func (_m *ShipConnectionInfoProviderInterface) HandleRemotePairingRemoved(_a0 string) {
+	_m.Called(_a0)
+}
+
+// ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HandleRemotePairingRemoved'
+type ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call struct {
+	*mock.Call
+}
+
+// HandleRemotePairingRemoved is a helper method to define mock.On call
+//   - _a0 string
+func (_e *ShipConnectionInfoProviderInterface_Expecter) HandleRemotePairingRemoved(_a0 interface{}) *ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call {
+	return &ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call{Call: _e.mock.On("HandleRemotePairingRemoved", _a0)}
+}
+
+func (_c *ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call) Run(run func(_a0 string)) *ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call) Return() *ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call) RunAndReturn(run func(string)) *ShipConnectionInfoProviderInterface_HandleRemotePairingRemoved_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HandleShipHandshakeCompleted provides a mock function with given fields: _a0
+func (_m *ShipConnectionInfoProviderInterface) HandleShipHandshakeCompleted(_a0 api.ShipConnectionInterface) {
+	_m.Called(_a0)
+}
+
+// ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HandleShipHandshakeCompleted'
+type ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call struct {
+	*mock.Call
+}
+
+// HandleShipHandshakeCompleted is a helper method to define mock.On call
+//   - _a0 api.ShipConnectionInterface
+func (_e *ShipConnectionInfoProviderInterface_Expecter) HandleShipHandshakeCompleted(_a0 interface{}) *ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call {
+	return &ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call{Call: _e.mock.On("HandleShipHandshakeCompleted", _a0)}
+}
+
+func (_c *ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call) Run(run func(_a0 api.ShipConnectionInterface)) *ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(api.ShipConnectionInterface))
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call) Return() *ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call) RunAndReturn(run func(api.ShipConnectionInterface)) *ShipConnectionInfoProviderInterface_HandleShipHandshakeCompleted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // HandleShipHandshakeStateUpdate provides a mock function with given fields: _a0, _a1
 func (_m *ShipConnectionInfoProviderInterface) HandleShipHandshakeStateUpdate(_a0 string, _a1 model.ShipState) {
 	_m.Called(_a0, _a1)
@@ -227,6 +293,52 @@ func (_c *ShipConnectionInfoProviderInterface_IsRemoteServiceForSKIPaired_Call)
 	return _c
 }
 
+// PairingDecisionForSKI provides a mock function with given fields: _a0
+func (_m *ShipConnectionInfoProviderInterface) PairingDecisionForSKI(_a0 string) api.PairingDecision {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PairingDecisionForSKI")
+	}
+
+	var r0 api.PairingDecision
+	if rf, ok := ret.Get(0).(func(string) api.PairingDecision); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(api.PairingDecision)
+	}
+
+	return r0
+}
+
+// ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PairingDecisionForSKI'
+type ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call struct {
+	*mock.Call
+}
+
+// PairingDecisionForSKI is a helper method to define mock.On call
+//   - _a0 string
+func (_e *ShipConnectionInfoProviderInterface_Expecter) PairingDecisionForSKI(_a0 interface{}) *ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call {
+	return &ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call{Call: _e.mock.On("PairingDecisionForSKI", _a0)}
+}
+
+func (_c *ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call) Run(run func(_a0 string)) *ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call) Return(_a0 api.PairingDecision) *ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call) RunAndReturn(run func(string) api.PairingDecision) *ShipConnectionInfoProviderInterface_PairingDecisionForSKI_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ReportServiceShipID provides a mock function with given fields: _a0, _a1
 func (_m *ShipConnectionInfoProviderInterface) ReportServiceShipID(_a0 string, _a1 string) {
 	_m.Called(_a0, _a1)
@@ -262,7 +374,7 @@ func (_c *ShipConnectionInfoProviderInterface_ReportServiceShipID_Call) RunAndRe
 }
 
 // SetupRemoteDevice provides a mock function with given fields: ski, writeI
-func (_m *ShipConnectionInfoProviderInterface) SetupRemoteDevice(ski string, writeI api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface {
+func (_m *ShipConnectionInfoProviderInterface) SetupRemoteDevice(ski string, writeI api.ShipConnectionDataWriterInterface) (api.ShipConnectionDataReaderInterface, error) {
 	ret := _m.Called(ski, writeI)
 
 	if len(ret) == 0 {
@@ -270,6 +382,10 @@ func (_m *ShipConnectionInfoProviderInterface) SetupRemoteDevice(ski string, wri
 	}
 
 	var r0 api.ShipConnectionDataReaderInterface
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, api.ShipConnectionDataWriterInterface) (api.ShipConnectionDataReaderInterface, error)); ok {
+		return rf(ski, writeI)
+	}
 	if rf, ok := ret.Get(0).(func(string, api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface); ok {
 		r0 = rf(ski, writeI)
 	} else {
@@ -278,7 +394,13 @@ func (_m *ShipConnectionInfoProviderInterface) SetupRemoteDevice(ski string, wri
 		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string, api.ShipConnectionDataWriterInterface) error); ok {
+		r1 = rf(ski, writeI)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetupRemoteDevice'
@@ -300,12 +422,12 @@ func (_c *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call) Run(run fu
 	return _c
 }
 
-func (_c *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call) Return(_a0 api.ShipConnectionDataReaderInterface) *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call {
-	_c.Call.Return(_a0)
+func (_c *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call) Return(_a0 api.ShipConnectionDataReaderInterface, _a1 error) *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call) RunAndReturn(run func(string, api.ShipConnectionDataWriterInterface) api.ShipConnectionDataReaderInterface) *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call {
+func (_c *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call) RunAndReturn(run func(string, api.ShipConnectionDataWriterInterface) (api.ShipConnectionDataReaderInterface, error)) *ShipConnectionInfoProviderInterface_SetupRemoteDevice_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -87,7 +87,7 @@ func (_c *ShipConnectionInterface_ApprovePendingHandshake_Call) RunAndReturn(run
 }
 
 // CloseConnection provides a mock function with given fields: safe, code, reason
-func (_m *ShipConnectionInterface) CloseConnection(safe bool, code int, reason string) {
+func (_m *ShipConnectionInterface) CloseConnection(safe bool, code api.ShipCloseCode, reason string) {
 	_m.Called(safe, code, reason)
 }
 
@@ -98,15 +98,15 @@ type ShipConnectionInterface_CloseConnection_Call struct {
 
 // CloseConnection is a helper method to define mock.On call
 //   - safe bool
-//   - code int
+//   - code api.ShipCloseCode
 //   - reason string
 func (_e *ShipConnectionInterface_Expecter) CloseConnection(safe interface{}, code interface{}, reason interface{}) *ShipConnectionInterface_CloseConnection_Call {
 	return &ShipConnectionInterface_CloseConnection_Call{Call: _e.mock.On("CloseConnection", safe, code, reason)}
 }
 
-func (_c *ShipConnectionInterface_CloseConnection_Call) Run(run func(safe bool, code int, reason string)) *ShipConnectionInterface_CloseConnection_Call {
+func (_c *ShipConnectionInterface_CloseConnection_Call) Run(run func(safe bool, code api.ShipCloseCode, reason string)) *ShipConnectionInterface_CloseConnection_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(bool), args[1].(int), args[2].(string))
+		run(args[0].(bool), args[1].(api.ShipCloseCode), args[2].(string))
 	})
 	return _c
 }
@@ -116,7 +116,129 @@ func (_c *ShipConnectionInterface_CloseConnection_Call) Return() *ShipConnection
 	return _c
 }
 
-func (_c *ShipConnectionInterface_CloseConnection_Call) RunAndReturn(run func(bool, int, string)) *ShipConnectionInterface_CloseConnection_Call {
+func (_c *ShipConnectionInterface_CloseConnection_Call) RunAndReturn(run func(bool, api.ShipCloseCode, string)) *ShipConnectionInterface_CloseConnection_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CloseConnectionPairingRemoved provides a mock function with given fields:
+func (_m *ShipConnectionInterface) CloseConnectionPairingRemoved() {
+	_m.Called()
+}
+
+// ShipConnectionInterface_CloseConnectionPairingRemoved_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CloseConnectionPairingRemoved'
+type ShipConnectionInterface_CloseConnectionPairingRemoved_Call struct {
+	*mock.Call
+}
+
+// CloseConnectionPairingRemoved is a helper method to define mock.On call
+func (_e *ShipConnectionInterface_Expecter) CloseConnectionPairingRemoved() *ShipConnectionInterface_CloseConnectionPairingRemoved_Call {
+	return &ShipConnectionInterface_CloseConnectionPairingRemoved_Call{Call: _e.mock.On("CloseConnectionPairingRemoved")}
+}
+
+func (_c *ShipConnectionInterface_CloseConnectionPairingRemoved_Call) Run(run func()) *ShipConnectionInterface_CloseConnectionPairingRemoved_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInterface_CloseConnectionPairingRemoved_Call) Return() *ShipConnectionInterface_CloseConnectionPairingRemoved_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ShipConnectionInterface_CloseConnectionPairingRemoved_Call) RunAndReturn(run func()) *ShipConnectionInterface_CloseConnectionPairingRemoved_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CloseDetails provides a mock function with given fields:
+func (_m *ShipConnectionInterface) CloseDetails() api.ShipCloseDetails {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for CloseDetails")
+	}
+
+	var r0 api.ShipCloseDetails
+	if rf, ok := ret.Get(0).(func() api.ShipCloseDetails); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(api.ShipCloseDetails)
+	}
+
+	return r0
+}
+
+// ShipConnectionInterface_CloseDetails_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CloseDetails'
+type ShipConnectionInterface_CloseDetails_Call struct {
+	*mock.Call
+}
+
+// CloseDetails is a helper method to define mock.On call
+func (_e *ShipConnectionInterface_Expecter) CloseDetails() *ShipConnectionInterface_CloseDetails_Call {
+	return &ShipConnectionInterface_CloseDetails_Call{Call: _e.mock.On("CloseDetails")}
+}
+
+func (_c *ShipConnectionInterface_CloseDetails_Call) Run(run func()) *ShipConnectionInterface_CloseDetails_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInterface_CloseDetails_Call) Return(_a0 api.ShipCloseDetails) *ShipConnectionInterface_CloseDetails_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ShipConnectionInterface_CloseDetails_Call) RunAndReturn(run func() api.ShipCloseDetails) *ShipConnectionInterface_CloseDetails_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ConnectionInfo provides a mock function with given fields:
+func (_m *ShipConnectionInterface) ConnectionInfo() api.ShipConnectionInfo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConnectionInfo")
+	}
+
+	var r0 api.ShipConnectionInfo
+	if rf, ok := ret.Get(0).(func() api.ShipConnectionInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(api.ShipConnectionInfo)
+	}
+
+	return r0
+}
+
+// ShipConnectionInterface_ConnectionInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConnectionInfo'
+type ShipConnectionInterface_ConnectionInfo_Call struct {
+	*mock.Call
+}
+
+// ConnectionInfo is a helper method to define mock.On call
+func (_e *ShipConnectionInterface_Expecter) ConnectionInfo() *ShipConnectionInterface_ConnectionInfo_Call {
+	return &ShipConnectionInterface_ConnectionInfo_Call{Call: _e.mock.On("ConnectionInfo")}
+}
+
+func (_c *ShipConnectionInterface_ConnectionInfo_Call) Run(run func()) *ShipConnectionInterface_ConnectionInfo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInterface_ConnectionInfo_Call) Return(_a0 api.ShipConnectionInfo) *ShipConnectionInterface_ConnectionInfo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ShipConnectionInterface_ConnectionInfo_Call) RunAndReturn(run func() api.ShipConnectionInfo) *ShipConnectionInterface_ConnectionInfo_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -268,6 +390,51 @@ func (_c *ShipConnectionInterface_ShipHandshakeState_Call) RunAndReturn(run func
 	return _c
 }
 
+// StateHistory provides a mock function with given fields:
+func (_m *ShipConnectionInterface) StateHistory() api.ShipConnectionHistory {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for StateHistory")
+	}
+
+	var r0 api.ShipConnectionHistory
+	if rf, ok := ret.Get(0).(func() api.ShipConnectionHistory); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(api.ShipConnectionHistory)
+	}
+
+	return r0
+}
+
+// ShipConnectionInterface_StateHistory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StateHistory'
+type ShipConnectionInterface_StateHistory_Call struct {
+	*mock.Call
+}
+
+// StateHistory is a helper method to define mock.On call
+func (_e *ShipConnectionInterface_Expecter) StateHistory() *ShipConnectionInterface_StateHistory_Call {
+	return &ShipConnectionInterface_StateHistory_Call{Call: _e.mock.On("StateHistory")}
+}
+
+func (_c *ShipConnectionInterface_StateHistory_Call) Run(run func()) *ShipConnectionInterface_StateHistory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *ShipConnectionInterface_StateHistory_Call) Return(_a0 api.ShipConnectionHistory) *ShipConnectionInterface_StateHistory_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ShipConnectionInterface_StateHistory_Call) RunAndReturn(run func() api.ShipConnectionHistory) *ShipConnectionInterface_StateHistory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewShipConnectionInterface creates a new instance of ShipConnectionInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewShipConnectionInterface(t interface {
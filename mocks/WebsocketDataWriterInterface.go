@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	context "context"
+
 	api "github.com/enbility/ship-go/api"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -54,6 +57,51 @@ func (_c *WebsocketDataWriterInterface_CloseDataConnection_Call) RunAndReturn(ru
 	return _c
 }
 
+// ConnectionInfo provides a mock function with given fields:
+func (_m *WebsocketDataWriterInterface) ConnectionInfo() api.WebsocketConnectionInfo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConnectionInfo")
+	}
+
+	var r0 api.WebsocketConnectionInfo
+	if rf, ok := ret.Get(0).(func() api.WebsocketConnectionInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(api.WebsocketConnectionInfo)
+	}
+
+	return r0
+}
+
+// WebsocketDataWriterInterface_ConnectionInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConnectionInfo'
+type WebsocketDataWriterInterface_ConnectionInfo_Call struct {
+	*mock.Call
+}
+
+// ConnectionInfo is a helper method to define mock.On call
+func (_e *WebsocketDataWriterInterface_Expecter) ConnectionInfo() *WebsocketDataWriterInterface_ConnectionInfo_Call {
+	return &WebsocketDataWriterInterface_ConnectionInfo_Call{Call: _e.mock.On("ConnectionInfo")}
+}
+
+func (_c *WebsocketDataWriterInterface_ConnectionInfo_Call) Run(run func()) *WebsocketDataWriterInterface_ConnectionInfo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_ConnectionInfo_Call) Return(_a0 api.WebsocketConnectionInfo) *WebsocketDataWriterInterface_ConnectionInfo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_ConnectionInfo_Call) RunAndReturn(run func() api.WebsocketConnectionInfo) *WebsocketDataWriterInterface_ConnectionInfo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // InitDataProcessing provides a mock function with given fields: _a0
 func (_m *WebsocketDataWriterInterface) InitDataProcessing(_a0 api.WebsocketDataReaderInterface) {
 	_m.Called(_a0)
@@ -188,6 +236,146 @@ func (_c *WebsocketDataWriterInterface_WriteMessageToWebsocketConnection_Call) R
 	return _c
 }
 
+// WriteMessageToWebsocketConnectionWithContext provides a mock function with given fields: _a0, _a1
+func (_m *WebsocketDataWriterInterface) WriteMessageToWebsocketConnectionWithContext(_a0 context.Context, _a1 []byte) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteMessageToWebsocketConnectionWithContext")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteMessageToWebsocketConnectionWithContext'
+type WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call struct {
+	*mock.Call
+}
+
+// WriteMessageToWebsocketConnectionWithContext is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 []byte
+func (_e *WebsocketDataWriterInterface_Expecter) WriteMessageToWebsocketConnectionWithContext(_a0 interface{}, _a1 interface{}) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call {
+	return &WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call{Call: _e.mock.On("WriteMessageToWebsocketConnectionWithContext", _a0, _a1)}
+}
+
+func (_c *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call) Run(run func(_a0 context.Context, _a1 []byte)) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]byte))
+	})
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call) Return(_a0 error) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call) RunAndReturn(run func(context.Context, []byte) error) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteMessageToWebsocketConnectionWithResult provides a mock function with given fields: _a0
+func (_m *WebsocketDataWriterInterface) WriteMessageToWebsocketConnectionWithResult(_a0 []byte) <-chan error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteMessageToWebsocketConnectionWithResult")
+	}
+
+	var r0 <-chan error
+	if rf, ok := ret.Get(0).(func([]byte) <-chan error); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan error)
+		}
+	}
+
+	return r0
+}
+
+// WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteMessageToWebsocketConnectionWithResult'
+type WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call struct {
+	*mock.Call
+}
+
+// WriteMessageToWebsocketConnectionWithResult is a helper method to define mock.On call
+//   - _a0 []byte
+func (_e *WebsocketDataWriterInterface_Expecter) WriteMessageToWebsocketConnectionWithResult(_a0 interface{}) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call {
+	return &WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call{Call: _e.mock.On("WriteMessageToWebsocketConnectionWithResult", _a0)}
+}
+
+func (_c *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call) Run(run func(_a0 []byte)) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call) Return(_a0 <-chan error) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call) RunAndReturn(run func([]byte) <-chan error) *WebsocketDataWriterInterface_WriteMessageToWebsocketConnectionWithResult_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteQueueStats provides a mock function with given fields:
+func (_m *WebsocketDataWriterInterface) WriteQueueStats() api.WebsocketWriteQueueStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteQueueStats")
+	}
+
+	var r0 api.WebsocketWriteQueueStats
+	if rf, ok := ret.Get(0).(func() api.WebsocketWriteQueueStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(api.WebsocketWriteQueueStats)
+	}
+
+	return r0
+}
+
+// WebsocketDataWriterInterface_WriteQueueStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteQueueStats'
+type WebsocketDataWriterInterface_WriteQueueStats_Call struct {
+	*mock.Call
+}
+
+// WriteQueueStats is a helper method to define mock.On call
+func (_e *WebsocketDataWriterInterface_Expecter) WriteQueueStats() *WebsocketDataWriterInterface_WriteQueueStats_Call {
+	return &WebsocketDataWriterInterface_WriteQueueStats_Call{Call: _e.mock.On("WriteQueueStats")}
+}
+
+func (_c *WebsocketDataWriterInterface_WriteQueueStats_Call) Run(run func()) *WebsocketDataWriterInterface_WriteQueueStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_WriteQueueStats_Call) Return(_a0 api.WebsocketWriteQueueStats) *WebsocketDataWriterInterface_WriteQueueStats_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebsocketDataWriterInterface_WriteQueueStats_Call) RunAndReturn(run func() api.WebsocketWriteQueueStats) *WebsocketDataWriterInterface_WriteQueueStats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewWebsocketDataWriterInterface creates a new instance of WebsocketDataWriterInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewWebsocketDataWriterInterface(t interface {
@@ -91,6 +91,18 @@ func (mr *MockMdnsInterfaceMockRecorder) SetAutoAccept(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAutoAccept", reflect.TypeOf((*MockMdnsInterface)(nil).SetAutoAccept), arg0)
 }
 
+// SetWebsocketEndpoint mocks base method.
+func (m *MockMdnsInterface) SetWebsocketEndpoint(arg0 int, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWebsocketEndpoint", arg0, arg1)
+}
+
+// SetWebsocketEndpoint indicates an expected call of SetWebsocketEndpoint.
+func (mr *MockMdnsInterfaceMockRecorder) SetWebsocketEndpoint(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWebsocketEndpoint", reflect.TypeOf((*MockMdnsInterface)(nil).SetWebsocketEndpoint), arg0, arg1)
+}
+
 // Shutdown mocks base method.
 func (m *MockMdnsInterface) Shutdown() {
 	m.ctrl.T.Helper()
@@ -167,27 +179,39 @@ func (mr *MockHubReaderInterfaceMockRecorder) AllowWaitingForTrust(arg0 any) *go
 }
 
 // RemoteSKIConnected mocks base method.
-func (m *MockHubReaderInterface) RemoteSKIConnected(arg0 string) {
+func (m *MockHubReaderInterface) RemoteSKIConnected(arg0 string, arg1 uint64) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "RemoteSKIConnected", arg0)
+	m.ctrl.Call(m, "RemoteSKIConnected", arg0, arg1)
 }
 
 // RemoteSKIConnected indicates an expected call of RemoteSKIConnected.
-func (mr *MockHubReaderInterfaceMockRecorder) RemoteSKIConnected(arg0 any) *gomock.Call {
+func (mr *MockHubReaderInterfaceMockRecorder) RemoteSKIConnected(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteSKIConnected", reflect.TypeOf((*MockHubReaderInterface)(nil).RemoteSKIConnected), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteSKIConnected", reflect.TypeOf((*MockHubReaderInterface)(nil).RemoteSKIConnected), arg0, arg1)
 }
 
 // RemoteSKIDisconnected mocks base method.
-func (m *MockHubReaderInterface) RemoteSKIDisconnected(arg0 string) {
+func (m *MockHubReaderInterface) RemoteSKIDisconnected(arg0 string, arg1 uint64, arg2 api.ShipCloseDetails) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "RemoteSKIDisconnected", arg0)
+	m.ctrl.Call(m, "RemoteSKIDisconnected", arg0, arg1, arg2)
 }
 
 // RemoteSKIDisconnected indicates an expected call of RemoteSKIDisconnected.
-func (mr *MockHubReaderInterfaceMockRecorder) RemoteSKIDisconnected(arg0 any) *gomock.Call {
+func (mr *MockHubReaderInterfaceMockRecorder) RemoteSKIDisconnected(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteSKIDisconnected", reflect.TypeOf((*MockHubReaderInterface)(nil).RemoteSKIDisconnected), arg0, arg1, arg2)
+}
+
+// RemoteSKIPairingRemoved mocks base method.
+func (m *MockHubReaderInterface) RemoteSKIPairingRemoved(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RemoteSKIPairingRemoved", arg0)
+}
+
+// RemoteSKIPairingRemoved indicates an expected call of RemoteSKIPairingRemoved.
+func (mr *MockHubReaderInterfaceMockRecorder) RemoteSKIPairingRemoved(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteSKIDisconnected", reflect.TypeOf((*MockHubReaderInterface)(nil).RemoteSKIDisconnected), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteSKIPairingRemoved", reflect.TypeOf((*MockHubReaderInterface)(nil).RemoteSKIPairingRemoved), arg0)
 }
 
 // ServicePairingDetailUpdate mocks base method.
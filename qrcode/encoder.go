@@ -0,0 +1,522 @@
+package qrcode
+
+import "math"
+
+// the number of error correction codewords per block, indexed by [level][version]
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// the number of error correction blocks, indexed by [level][version]
+var numErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// the error correction level bits used in the format information
+var formatLevelBits = [4]int{1, 0, 3, 2}
+
+const (
+	// byte mode indicator
+	modeByte = 0x4
+
+	// pad codewords used to fill the data capacity
+	padCodeword1 = 0xEC
+	padCodeword2 = 0x11
+
+	// penalty weights used to select the mask
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// returns the number of bits of the character count indicator in byte mode
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+
+	return 16
+}
+
+// returns the number of bits needed to encode the data length in byte mode
+func dataBitsNeeded(version, length int) int {
+	if length >= 1<<charCountBits(version) {
+		return math.MaxInt
+	}
+
+	return 4 + charCountBits(version) + length*8
+}
+
+// returns the number of modules available for data and error correction codewords,
+// including remainder bits
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+
+	return result
+}
+
+// returns the number of data codewords for a version and level
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 -
+		eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// returns the center positions of the alignment patterns in ascending order
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, version*4+10; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+
+	return result
+}
+
+// bit buffer used for encoding the data codewords
+type bitBuffer []bool
+
+func (b *bitBuffer) appendBits(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+// encode the data in byte mode, including terminator and padding
+func (q *QRCode) encodeData(data []byte) []byte {
+	var bits bitBuffer
+
+	bits.appendBits(modeByte, 4)
+	bits.appendBits(len(data), charCountBits(q.version))
+	for _, b := range data {
+		bits.appendBits(int(b), 8)
+	}
+
+	capacity := numDataCodewords(q.version, q.level) * 8
+
+	// terminator with up to 4 bits, then pad to a byte boundary
+	bits.appendBits(0, min(4, capacity-len(bits)))
+	bits.appendBits(0, (8-len(bits)%8)%8)
+
+	for pad := padCodeword1; len(bits) < capacity; pad ^= padCodeword1 ^ padCodeword2 {
+		bits.appendBits(pad, 8)
+	}
+
+	result := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			result[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	return result
+}
+
+// split the data codewords into blocks, add the error correction codewords
+// and return the interleaved codewords
+func (q *QRCode) addErrorCorrection(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[q.level][q.version]
+	blockEccLen := eccCodewordsPerBlock[q.level][q.version]
+	rawCodewords := numRawDataModules(q.version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockEccLen)
+
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		dataLen := shortBlockLen - blockEccLen
+		if i >= numShortBlocks {
+			dataLen++
+		}
+
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, data[k:k+dataLen]...)
+		k += dataLen
+		ecc := reedSolomonRemainder(block, divisor)
+
+		// short blocks get a placeholder, which is skipped when interleaving
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := 0; i <= shortBlockLen; i++ {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockEccLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	return result
+}
+
+// returns the generator polynomial of the given degree, without the leading term
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// returns the error correction codewords for the data
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+
+	return result
+}
+
+// multiply in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+
+	return byte(z)
+}
+
+// set a function pattern module
+func (q *QRCode) setFunctionModule(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+// draw all function patterns, the format bits are drawn as placeholder
+func (q *QRCode) drawFunctionPatterns() {
+	// timing patterns
+	for i := 0; i < q.size; i++ {
+		q.setFunctionModule(6, i, i%2 == 0)
+		q.setFunctionModule(i, 6, i%2 == 0)
+	}
+
+	// finder patterns, overwriting the timing patterns
+	q.drawFinderPattern(3, 3)
+	q.drawFinderPattern(q.size-4, 3)
+	q.drawFinderPattern(3, q.size-4)
+
+	// alignment patterns, except the ones overlapping the finder patterns
+	positions := alignmentPatternPositions(q.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			q.drawAlignmentPattern(x, y)
+		}
+	}
+
+	q.drawFormatBits(0)
+	q.drawVersionBits()
+}
+
+// draw a finder pattern including the separator, centered at x, y
+func (q *QRCode) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= q.size || yy < 0 || yy >= q.size {
+				continue
+			}
+
+			dist := max(abs(dx), abs(dy))
+			q.setFunctionModule(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// draw an alignment pattern centered at x, y
+func (q *QRCode) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunctionModule(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// returns the 15 format information bits for the level and mask
+func formatBits(level Level, mask int) int {
+	data := formatLevelBits[level]<<3 | mask
+
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// draw both copies of the format information and the dark module
+func (q *QRCode) drawFormatBits(mask int) {
+	bits := formatBits(q.level, mask)
+
+	// first copy, around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		q.setFunctionModule(8, i, getBit(bits, i))
+	}
+	q.setFunctionModule(8, 7, getBit(bits, 6))
+	q.setFunctionModule(8, 8, getBit(bits, 7))
+	q.setFunctionModule(7, 8, getBit(bits, 8))
+	for i := 9; i < 15; i++ {
+		q.setFunctionModule(14-i, 8, getBit(bits, i))
+	}
+
+	// second copy, split between the top right and bottom left finder patterns
+	for i := 0; i < 8; i++ {
+		q.setFunctionModule(q.size-1-i, 8, getBit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunctionModule(8, q.size-15+i, getBit(bits, i))
+	}
+	q.setFunctionModule(8, q.size-8, true)
+}
+
+// returns the 18 version information bits, used for version 7 and above
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+
+	return version<<12 | rem
+}
+
+// draw both copies of the version information for version 7 and above
+func (q *QRCode) drawVersionBits() {
+	if q.version < 7 {
+		return
+	}
+
+	bits := versionBits(q.version)
+	for i := 0; i < 18; i++ {
+		a := q.size - 11 + i%3
+		b := i / 3
+		q.setFunctionModule(a, b, getBit(bits, i))
+		q.setFunctionModule(b, a, getBit(bits, i))
+	}
+}
+
+// draw the codewords in the zigzag order into the non function modules
+func (q *QRCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		// skip the vertical timing pattern
+		if right == 6 {
+			right = 5
+		}
+
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if q.isFunction[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+
+				q.modules[y][x] = getBit(int(codewords[i>>3]), 7-i&7)
+				i++
+			}
+		}
+	}
+}
+
+// returns true if the mask inverts the module at x, y
+func maskInverts(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// apply the mask to all non function modules, applying it twice reverts it
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.isFunction[y][x] && maskInverts(mask, x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// apply the mask with the lowest penalty score
+func (q *QRCode) applyBestMask() {
+	bestMask := 0
+	bestPenalty := math.MaxInt
+
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if penalty := q.penaltyScore(); penalty < bestPenalty {
+			bestMask = mask
+			bestPenalty = penalty
+		}
+		q.applyMask(mask)
+	}
+
+	q.mask = bestMask
+	q.applyMask(bestMask)
+	q.drawFormatBits(bestMask)
+}
+
+// finder like patterns with 4 light modules on one side, used for the penalty score
+var (
+	finderLikePattern1 = []bool{true, false, true, true, true, false, true, false, false, false, false}
+	finderLikePattern2 = []bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+// returns the penalty score of the current modules
+func (q *QRCode) penaltyScore() int {
+	result := 0
+	dark := 0
+
+	line := make([]bool, q.size)
+	for _, horizontal := range []bool{true, false} {
+		for i := 0; i < q.size; i++ {
+			for j := 0; j < q.size; j++ {
+				if horizontal {
+					line[j] = q.modules[i][j]
+				} else {
+					line[j] = q.modules[j][i]
+				}
+			}
+
+			result += linePenalty(line)
+		}
+	}
+
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+
+			// 2x2 blocks of the same color
+			if x < q.size-1 && y < q.size-1 {
+				color := q.modules[y][x]
+				if color == q.modules[y][x+1] && color == q.modules[y+1][x] && color == q.modules[y+1][x+1] {
+					result += penaltyN2
+				}
+			}
+		}
+	}
+
+	// the deviation of the dark modules from 50% in steps of 5%
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyN4
+
+	return result
+}
+
+// returns the penalty of a row or column for runs of the same color and finder like patterns
+func linePenalty(line []bool) int {
+	result := 0
+
+	runLength := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			runLength++
+			continue
+		}
+
+		if runLength >= 5 {
+			result += penaltyN1 + runLength - 5
+		}
+		runLength = 1
+	}
+
+	for i := 0; i+len(finderLikePattern1) <= len(line); i++ {
+		if matchesPattern(line[i:], finderLikePattern1) || matchesPattern(line[i:], finderLikePattern2) {
+			result += penaltyN3
+		}
+	}
+
+	return result
+}
+
+func matchesPattern(line, pattern []bool) bool {
+	for i, value := range pattern {
+		if line[i] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getBit(value, i int) bool {
+	return (value>>i)&1 != 0
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
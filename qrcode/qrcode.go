@@ -0,0 +1,134 @@
+// Package qrcode renders QR codes, e.g. for the SHIP installation QR code text
+// provided by api.MdnsInterface.QRCodeText, as PNG, SVG or terminal text.
+//
+// The QR code encoder is implemented in this package according to ISO/IEC 18004
+// and uses byte mode for the whole text.
+package qrcode
+
+import (
+	"errors"
+)
+
+// The error correction level of a QR code
+type Level int
+
+const (
+	// recovers about 7% of the data
+	LevelLow Level = iota
+	// recovers about 15% of the data
+	LevelMedium
+	// recovers about 25% of the data
+	LevelQuartile
+	// recovers about 30% of the data
+	LevelHigh
+)
+
+const (
+	// the recommended quiet zone around the QR code in modules
+	DefaultQuietZone = 4
+
+	minVersion = 1
+	maxVersion = 40
+)
+
+var (
+	ErrInvalidLevel = errors.New("invalid QR code error correction level")
+	ErrTextTooLong  = errors.New("text is too long for a QR code")
+)
+
+// A QR code symbol
+type QRCode struct {
+	version int
+	level   Level
+	mask    int
+
+	// the width and height in modules, without the quiet zone
+	size int
+
+	// the dark modules, indexed by [y][x]
+	modules [][]bool
+
+	// the function pattern modules, which do not contain data, indexed by [y][x]
+	isFunction [][]bool
+
+	// the quiet zone around the QR code in modules
+	quietZone int
+}
+
+// Create the QR code for a text with the given error correction level
+//
+// the smallest possible version is used
+func New(text string, level Level) (*QRCode, error) {
+	if level < LevelLow || level > LevelHigh {
+		return nil, ErrInvalidLevel
+	}
+
+	data := []byte(text)
+
+	version := minVersion
+	for ; version <= maxVersion; version++ {
+		if dataBitsNeeded(version, len(data)) <= numDataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, ErrTextTooLong
+	}
+
+	q := &QRCode{
+		version:   version,
+		level:     level,
+		size:      version*4 + 17,
+		quietZone: DefaultQuietZone,
+	}
+
+	q.modules = make([][]bool, q.size)
+	q.isFunction = make([][]bool, q.size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, q.size)
+		q.isFunction[i] = make([]bool, q.size)
+	}
+
+	q.drawFunctionPatterns()
+	q.drawCodewords(q.addErrorCorrection(q.encodeData(data)))
+	q.applyBestMask()
+
+	return q, nil
+}
+
+// Returns the QR code version, between 1 and 40
+func (q *QRCode) Version() int {
+	return q.version
+}
+
+// Returns the error correction level
+func (q *QRCode) Level() Level {
+	return q.level
+}
+
+// Returns the width and height in modules, without the quiet zone
+func (q *QRCode) Size() int {
+	return q.size
+}
+
+// Returns true if the module at the given position is dark
+//
+// x and y start at the top left corner, positions outside of the symbol are light
+func (q *QRCode) IsDark(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.size || y >= q.size {
+		return false
+	}
+
+	return q.modules[y][x]
+}
+
+// Sets the quiet zone around the QR code in modules used for rendering
+//
+// Default: 4
+func (q *QRCode) SetQuietZone(modules int) {
+	if modules < 0 {
+		modules = 0
+	}
+
+	q.quietZone = modules
+}
@@ -0,0 +1,279 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestQRCodeSuite(t *testing.T) {
+	suite.Run(t, new(QRCodeSuite))
+}
+
+type QRCodeSuite struct {
+	suite.Suite
+}
+
+const shipText = "SHIP;SKI:0123456789abcdef0123456789abcdef01234567;ID:Demo-EVSE-234567890;BRAND:Demo;TYPE:Charger;MODEL:EVSE;SERIAL:234567890;CAT:3;ENDSHIP;"
+
+func (s *QRCodeSuite) Test_New() {
+	qr, err := New(shipText, LevelMedium)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), LevelMedium, qr.Level())
+	assert.Equal(s.T(), qr.Version()*4+17, qr.Size())
+	assert.False(s.T(), qr.IsDark(-1, 0))
+	assert.False(s.T(), qr.IsDark(0, qr.Size()))
+	assert.True(s.T(), qr.IsDark(0, 0))
+
+	_, err = New(shipText, Level(4))
+	assert.True(s.T(), errors.Is(err, ErrInvalidLevel))
+
+	_, err = New(strings.Repeat("a", 2954), LevelLow)
+	assert.True(s.T(), errors.Is(err, ErrTextTooLong))
+}
+
+func (s *QRCodeSuite) Test_Versions() {
+	tests := []struct {
+		length  int
+		level   Level
+		version int
+	}{
+		{17, LevelLow, 1},
+		{18, LevelLow, 2},
+		{7, LevelHigh, 1},
+		{8, LevelHigh, 2},
+		{154, LevelLow, 7},
+		{155, LevelLow, 8},
+		{122, LevelMedium, 7},
+		{2953, LevelLow, 40},
+		{1273, LevelHigh, 40},
+	}
+
+	for _, test := range tests {
+		qr, err := New(strings.Repeat("a", test.length), test.level)
+		assert.Nil(s.T(), err)
+		assert.Equal(s.T(), test.version, qr.Version(), test.length)
+	}
+}
+
+func (s *QRCodeSuite) Test_KnownValues() {
+	// format information for level M mask 0 and level L mask 0
+	assert.Equal(s.T(), 0x5412, formatBits(LevelMedium, 0))
+	assert.Equal(s.T(), 0x77C4, formatBits(LevelLow, 0))
+
+	// version information for version 7
+	assert.Equal(s.T(), 0x07C94, versionBits(7))
+
+	assert.Equal(s.T(), []int{6, 22, 38}, alignmentPatternPositions(7))
+	assert.Equal(s.T(), []int{6, 34, 60, 86, 112, 138}, alignmentPatternPositions(32))
+
+	// error correction codewords for "HELLO WORLD" with version 1 and level M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := reedSolomonRemainder(data, reedSolomonDivisor(10))
+	assert.Equal(s.T(), []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, ecc)
+}
+
+func (s *QRCodeSuite) Test_Decode() {
+	texts := []string{"", "a", shipText, strings.Repeat("0123456789", 40)}
+
+	for _, text := range texts {
+		for level := LevelLow; level <= LevelHigh; level++ {
+			qr, err := New(text, level)
+			assert.Nil(s.T(), err)
+
+			s.assertFinderPatterns(qr)
+			s.assertFormatBits(qr)
+			assert.Equal(s.T(), text, s.decode(qr), "level %d", level)
+		}
+	}
+}
+
+func (s *QRCodeSuite) Test_PNG() {
+	qr, err := New(shipText, LevelMedium)
+	assert.Nil(s.T(), err)
+
+	data, err := qr.PNG(300)
+	assert.Nil(s.T(), err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 300, img.Bounds().Dx())
+	assert.Equal(s.T(), 300, img.Bounds().Dy())
+
+	// the image is larger if the size is too small
+	qr.SetQuietZone(0)
+	data, err = qr.PNG(1)
+	assert.Nil(s.T(), err)
+
+	img, err = png.Decode(bytes.NewReader(data))
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), qr.Size(), img.Bounds().Dx())
+
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(s.T(), uint32(0), r)
+}
+
+func (s *QRCodeSuite) Test_SVG() {
+	qr, err := New(shipText, LevelMedium)
+	assert.Nil(s.T(), err)
+
+	svg := qr.SVG(200)
+	assert.True(s.T(), strings.Contains(svg, `width="200" height="200"`))
+	total := qr.Size() + 2*DefaultQuietZone
+	assert.True(s.T(), strings.Contains(svg, "viewBox=\"0 0 "+strconv.Itoa(total)+" "+strconv.Itoa(total)+"\""))
+	assert.True(s.T(), strings.Contains(svg, "M4,4h1v1h-1z"))
+}
+
+func (s *QRCodeSuite) Test_ASCII() {
+	qr, err := New("a", LevelLow)
+	assert.Nil(s.T(), err)
+	qr.SetQuietZone(1)
+
+	lines := strings.Split(strings.TrimSuffix(qr.ASCII(false), "\n"), "\n")
+	assert.Equal(s.T(), 23, len(lines))
+	assert.Equal(s.T(), strings.Repeat(" ", 46), lines[0])
+	assert.True(s.T(), strings.HasPrefix(lines[1], "  ##############  "))
+
+	lines = strings.Split(strings.TrimSuffix(qr.ASCII(true), "\n"), "\n")
+	assert.Equal(s.T(), strings.Repeat("#", 46), lines[0])
+
+	lines = strings.Split(strings.TrimSuffix(qr.Terminal(false), "\n"), "\n")
+	assert.Equal(s.T(), 12, len(lines))
+	assert.True(s.T(), strings.HasPrefix(lines[0], " ▄▄▄▄▄▄▄ "))
+
+	qr.SetQuietZone(-1)
+	lines = strings.Split(strings.TrimSuffix(qr.Terminal(true), "\n"), "\n")
+	assert.Equal(s.T(), 11, len(lines))
+}
+
+func (s *QRCodeSuite) assertFinderPatterns(qr *QRCode) {
+	for _, corner := range [][2]int{{0, 0}, {qr.Size() - 7, 0}, {0, qr.Size() - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				dist := max(abs(dx-3), abs(dy-3))
+				assert.Equal(s.T(), dist != 2, qr.IsDark(corner[0]+dx, corner[1]+dy))
+			}
+		}
+	}
+}
+
+// read the format information from both copies and verify it matches the level and mask
+func (s *QRCodeSuite) assertFormatBits(qr *QRCode) {
+	var first, second int
+	for i := 0; i <= 5; i++ {
+		first |= bit(qr.IsDark(8, i)) << i
+	}
+	first |= bit(qr.IsDark(8, 7)) << 6
+	first |= bit(qr.IsDark(8, 8)) << 7
+	first |= bit(qr.IsDark(7, 8)) << 8
+	for i := 9; i < 15; i++ {
+		first |= bit(qr.IsDark(14-i, 8)) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= bit(qr.IsDark(qr.Size()-1-i, 8)) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= bit(qr.IsDark(8, qr.Size()-15+i)) << i
+	}
+
+	assert.Equal(s.T(), formatBits(qr.Level(), qr.mask), first)
+	assert.Equal(s.T(), first, second)
+	assert.True(s.T(), qr.IsDark(8, qr.Size()-8))
+}
+
+// decode the QR code by reverting the encoding steps
+func (s *QRCodeSuite) decode(qr *QRCode) string {
+	// read the codewords in zigzag order from the unmasked data modules
+	var codewords []byte
+	var bitIndex int
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < qr.size; vert++ {
+			y := vert
+			if upward {
+				y = qr.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if qr.isFunction[y][x] {
+					continue
+				}
+				if bitIndex%8 == 0 {
+					codewords = append(codewords, 0)
+				}
+				if qr.modules[y][x] != maskInverts(qr.mask, x, y) {
+					codewords[bitIndex/8] |= 1 << (7 - bitIndex%8)
+				}
+				bitIndex++
+			}
+		}
+	}
+
+	rawCodewords := numRawDataModules(qr.version) / 8
+	codewords = codewords[:rawCodewords]
+
+	// deinterleave the blocks and verify the error correction codewords
+	numBlocks := numErrorCorrectionBlocks[qr.level][qr.version]
+	eccLen := eccCodewordsPerBlock[qr.level][qr.version]
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLen := rawCodewords/numBlocks - eccLen
+
+	blocks := make([][]byte, numBlocks)
+	index := 0
+	for i := 0; i <= shortDataLen; i++ {
+		for j := range blocks {
+			if i < shortDataLen || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], codewords[index])
+				index++
+			}
+		}
+	}
+	var data []byte
+	for j := range blocks {
+		data = append(data, blocks[j]...)
+
+		var ecc []byte
+		for i := 0; i < eccLen; i++ {
+			ecc = append(ecc, codewords[index+i*numBlocks+j])
+		}
+		assert.Equal(s.T(), reedSolomonRemainder(blocks[j], reedSolomonDivisor(eccLen)), ecc)
+	}
+	assert.Equal(s.T(), numDataCodewords(qr.version, qr.level), len(data))
+
+	// parse the byte mode segment
+	readBits := func(offset, length int) int {
+		value := 0
+		for i := offset; i < offset+length; i++ {
+			value = value<<1 | int(data[i/8]>>(7-i%8))&1
+		}
+		return value
+	}
+
+	assert.Equal(s.T(), modeByte, readBits(0, 4))
+	countBits := charCountBits(qr.version)
+	length := readBits(4, countBits)
+
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = byte(readBits(4+countBits+i*8, 8))
+	}
+
+	return string(result)
+}
+
+func bit(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
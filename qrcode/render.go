@@ -0,0 +1,139 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// returns the width and height in modules, including the quiet zone
+func (q *QRCode) totalSize() int {
+	return q.size + 2*q.quietZone
+}
+
+// returns true if the module at the given position including the quiet zone is dark
+func (q *QRCode) isDarkWithQuietZone(x, y int) bool {
+	return q.IsDark(x-q.quietZone, y-q.quietZone)
+}
+
+// Returns the QR code as a black and white PNG image with the given width and height in pixels
+//
+// All modules have the same integer pixel size, the remaining pixels are added to the
+// light border. If size is too small for one pixel per module, the image is larger than size
+func (q *QRCode) PNG(size int) ([]byte, error) {
+	total := q.totalSize()
+	scale := max(1, size/total)
+	size = max(size, total*scale)
+	offset := (size - total*scale) / 2
+
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+
+	for y := 0; y < total; y++ {
+		for x := 0; x < total; x++ {
+			if !q.isDarkWithQuietZone(x, y) {
+				continue
+			}
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Returns the QR code as an SVG image with the given width and height
+//
+// The image is scalable, the size only defines the default dimensions
+func (q *QRCode) SVG(size int) string {
+	total := q.totalSize()
+
+	var path strings.Builder
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+q.quietZone, y+q.quietZone)
+			}
+		}
+	}
+
+	var svg strings.Builder
+	svg.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		size, size, total, total)
+	svg.WriteString(`<rect width="100%" height="100%" fill="#FFFFFF"/>` + "\n")
+	fmt.Fprintf(&svg, `<path d="%s" fill="#000000"/>`+"\n", path.String())
+	svg.WriteString("</svg>\n")
+
+	return svg.String()
+}
+
+// Returns the QR code as plain ASCII text with two characters per module,
+// using "##" for dark and spaces for light modules
+//
+// Terminals usually show light text on a dark background, for those set inverted to true,
+// so "##" is used for light modules
+func (q *QRCode) ASCII(inverted bool) string {
+	total := q.totalSize()
+
+	var result strings.Builder
+	for y := 0; y < total; y++ {
+		for x := 0; x < total; x++ {
+			if q.isDarkWithQuietZone(x, y) != inverted {
+				result.WriteString("##")
+			} else {
+				result.WriteString("  ")
+			}
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
+
+// Returns the QR code as compact text using Unicode half block characters,
+// with two modules per character vertically
+//
+// Terminals usually show light text on a dark background, for those set inverted to true,
+// so the block characters are used for light modules
+func (q *QRCode) Terminal(inverted bool) string {
+	total := q.totalSize()
+
+	var result strings.Builder
+	for y := 0; y < total; y += 2 {
+		for x := 0; x < total; x++ {
+			top := q.isDarkWithQuietZone(x, y) != inverted
+			// the row below the symbol has the quiet zone color
+			bottom := inverted
+			if y+1 < total {
+				bottom = q.isDarkWithQuietZone(x, y+1) != inverted
+			}
+
+			switch {
+			case top && bottom:
+				result.WriteString("█")
+			case top:
+				result.WriteString("▀")
+			case bottom:
+				result.WriteString("▄")
+			default:
+				result.WriteString(" ")
+			}
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
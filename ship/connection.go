@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/model"
 	"github.com/enbility/ship-go/util"
@@ -25,6 +27,19 @@ type ShipConnection struct {
 	// the remote SHIP Id
 	remoteShipID string
 
+	// the time the connection was established
+	connectedSince time.Time
+
+	// the time the connection was closed, zero while the connection is open
+	closedAt time.Time
+
+	// the source of the current time and all timers
+	clock api.ClockInterface
+
+	// the negotiated SHIP protocol version and message format
+	protocolVersion model.Version
+	protocolFormat  model.MessageProtocolFormatType
+
 	// The local SHIP ID
 	localShipID string
 
@@ -43,6 +58,15 @@ type ShipConnection struct {
 	// the current error value if SHIP state is in error
 	smeError error
 
+	// the recorded state transitions, limited to stateHistorySize
+	stateHistory []api.ShipStateTransition
+
+	// the number of transitions dropped from stateHistory
+	stateHistoryDropped uint64
+
+	// the event which triggers the current state transitions
+	stateTrigger api.ShipStateTrigger
+
 	// handles timeouts for the various states
 	//
 	// WaitForReady SHIP 13.4.4.1.3: The communication partner must send its "READY" state (or request for prolongation") before the timer expires.
@@ -50,20 +74,57 @@ type ShipConnection struct {
 	// SendProlongationRequest SHIP 13.4.4.1.3: Local timer to request for prolongation at the communication partner in time (i.e. before the communication partner's Wait-For-Ready-Timer expires).
 	//
 	// ProlongationRequestReply SHIP 13.4.4.1.3: Detection of response timeout on prolongation request.
-	handshakeTimerRunning  bool
-	handshakeTimerType     timeoutTimerType
-	handshakeTimerStopChan chan struct{}
-	handshakeTimerMux      sync.Mutex
+	handshakeTimerRunning bool
+	handshakeTimerType    timeoutTimerType
+	handshakeTimer        api.TimerInterface
+	handshakeTimerMux     sync.Mutex
+
+	// identifies the current handshake timer, to ignore timeouts of stopped timers
+	handshakeTimerGeneration uint64
 
 	lastReceivedWaitingValue time.Duration // required for Prolong-Request-Reply-Timer
 
 	shutdownOnce sync.Once
 
+	// why the connection was closed, set by the first close
+	closeDetails *api.ShipCloseDetails
+
+	// true once the connection termination was announced by either side
+	terminating bool
+
+	// closed when the termination confirmation was received
+	closeConfirmC    chan struct{}
+	closeConfirmOnce sync.Once
+
+	// closed when the data connection was closed by the remote service
+	dataClosedC    chan struct{}
+	dataClosedOnce sync.Once
+
 	// buffer for SPINE messages that came in before the handshake was completed
 	spineBuffer [][]byte
 
+	// the number of bytes in spineBuffer
+	spineBufferBytes int
+
+	// the limits and policy for spineBuffer
+	spineBufferConfig api.SpineBufferConfig
+
+	// the number of SPINE messages dropped from spineBuffer
+	spineBufferDropped uint64
+
+	// the queued events, processed sequentially by the event loop
+	events           []func()
+	eventsC          chan struct{}
+	eventLoopStopC   chan struct{}
+	eventLoopStopped bool
+	eventsMux        sync.Mutex
+
+	// limits the received messages waiting in the queued events
+	incomingSlots chan struct{}
+
 	mux       sync.Mutex
 	bufferMux sync.Mutex
+	infoMux   sync.Mutex
 }
 
 var _ api.ShipConnectionInterface = (*ShipConnection)(nil)
@@ -75,18 +136,24 @@ func NewConnectionHandler(
 	localShipID,
 	remoteSki,
 	remoteShipId string) *ShipConnection {
+	systemClock := clock.NewSystemClock()
 	ship := &ShipConnection{
-		infoProvider: dataProvider,
-		dataWriter:   dataHandler,
-		role:         role,
-		localShipID:  localShipID,
-		remoteSKI:    remoteSki,
-		remoteShipID: remoteShipId,
-		smeState:     model.CmiStateInitStart,
-		smeError:     nil,
+		infoProvider:   dataProvider,
+		dataWriter:     dataHandler,
+		role:           role,
+		localShipID:    localShipID,
+		remoteSKI:      remoteSki,
+		remoteShipID:   remoteShipId,
+		connectedSince: systemClock.Now(),
+		clock:          systemClock,
+		smeState:       model.CmiStateInitStart,
+		smeError:       nil,
 	}
 
-	ship.handshakeTimerStopChan = make(chan struct{})
+	ship.closeConfirmC = make(chan struct{})
+	ship.dataClosedC = make(chan struct{})
+	ship.SetSpineBufferConfig(api.SpineBufferConfig{})
+	ship.startEventLoop()
 
 	if dataHandler != nil {
 		dataHandler.InitDataProcessing(ship)
@@ -95,6 +162,50 @@ func NewConnectionHandler(
 	return ship
 }
 
+// set the limits and policy for SPINE messages received before the handshake is completed
+//
+// needs to be invoked before Run
+func (c *ShipConnection) SetSpineBufferConfig(config api.SpineBufferConfig) {
+	c.bufferMux.Lock()
+	defer c.bufferMux.Unlock()
+
+	if config.MaxMessages <= 0 {
+		config.MaxMessages = api.DefaultSpineBufferMaxMessages
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = api.DefaultSpineBufferMaxBytes
+	}
+
+	c.spineBufferConfig = config
+}
+
+// set the clock used for the connection time, all timeouts and delays
+//
+// needs to be invoked before Run
+func (c *ShipConnection) SetClock(clock api.ClockInterface) {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	c.clock = clock
+	c.connectedSince = clock.Now()
+}
+
+func (c *ShipConnection) getClock() api.ClockInterface {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	return c.clock
+}
+
+// return the number of SPINE messages received before the handshake was completed
+// which got dropped because the buffer limits were exceeded
+func (c *ShipConnection) DroppedSpineMessages() uint64 {
+	c.bufferMux.Lock()
+	defer c.bufferMux.Unlock()
+
+	return c.spineBufferDropped
+}
+
 func (c *ShipConnection) RemoteSKI() string {
 	return c.remoteSKI
 }
@@ -103,18 +214,66 @@ func (c *ShipConnection) DataHandler() api.WebsocketDataWriterInterface {
 	return c.dataWriter
 }
 
+// return a snapshot of the details of the connection
+func (c *ShipConnection) ConnectionInfo() api.ShipConnectionInfo {
+	c.infoMux.Lock()
+	info := api.ShipConnectionInfo{
+		RemoteSKI:      c.remoteSKI,
+		RemoteShipID:   c.remoteShipID,
+		Role:           api.ShipRole(c.role),
+		ConnectedSince: c.connectedSince,
+		ShipVersion:    c.protocolVersion,
+		ShipFormat:     c.protocolFormat,
+	}
+	c.infoMux.Unlock()
+
+	info.State = c.getState()
+	if c.dataWriter != nil {
+		info.Websocket = c.dataWriter.ConnectionInfo()
+	}
+
+	return info
+}
+
+// set the SHIP ID reported by the remote service
+func (c *ShipConnection) setRemoteShipID(shipID string) {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	c.remoteShipID = shipID
+}
+
+// set the negotiated SHIP protocol version and message format
+func (c *ShipConnection) setProtocol(version model.Version, format model.MessageProtocolFormatType) {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	c.protocolVersion = version
+	c.protocolFormat = format
+}
+
 // start SHIP communication
 func (c *ShipConnection) Run() {
-	c.handleShipMessage(false, nil)
+	c.postEvent(func() {
+		c.setStateTrigger(api.ShipStateTriggerStart)
+		c.handleShipMessage(false, nil)
+	})
 }
 
 // provides the current ship state and error value if the state is in error
 func (c *ShipConnection) ShipHandshakeState() (model.ShipMessageExchangeState, error) {
-	return c.getState(), c.smeError
+	return c.getState(), c.getStateError()
 }
 
 // invoked when pairing for a pending request is approved
 func (c *ShipConnection) ApprovePendingHandshake() {
+	c.postEvent(func() {
+		c.setStateTrigger(api.ShipStateTriggerApproved)
+		c.approvePendingHandshake()
+	})
+}
+
+func (c *ShipConnection) approvePendingHandshake() {
 	state := c.getState()
 	if state != model.SmeHelloStatePendingListen {
 		// TODO: what to do if the state is different?
@@ -134,6 +293,13 @@ func (c *ShipConnection) ApprovePendingHandshake() {
 
 // invoked when pairing for a pending request is denied
 func (c *ShipConnection) AbortPendingHandshake() {
+	c.postEvent(func() {
+		c.setStateTrigger(api.ShipStateTriggerAborted)
+		c.abortPendingHandshake()
+	})
+}
+
+func (c *ShipConnection) abortPendingHandshake() {
 	state := c.getState()
 	if state != model.SmeHelloStatePendingListen && state != model.SmeHelloStateReadyListen {
 		// TODO: what to do if the state is differnet?
@@ -148,10 +314,47 @@ func (c *ShipConnection) AbortPendingHandshake() {
 }
 
 // close this ship connection
-func (c *ShipConnection) CloseConnection(safe bool, code int, reason string) {
+func (c *ShipConnection) CloseConnection(safe bool, code api.ShipCloseCode, reason string) {
+	c.postEvent(func() {
+		c.setStateTrigger(api.ShipStateTriggerClose)
+		c.closeConnection(safe, code, reason)
+	})
+}
+
+// close this ship connection as the pairing with the remote service was removed
+func (c *ShipConnection) CloseConnectionPairingRemoved() {
+	c.postEvent(func() {
+		c.setStateTrigger(api.ShipStateTriggerClose)
+		c.closeConnectionWithReason(true, api.ShipCloseCodeUserClose, api.ShipCloseCodeUserClose.String(), model.ConnectionCloseReasonTypeRemovedconnection)
+	})
+}
+
+// close this ship connection from within the event loop
+func (c *ShipConnection) closeConnection(safe bool, code api.ShipCloseCode, reason string) {
+	c.closeConnectionWithReason(safe, code, reason, model.ConnectionCloseReasonTypeUnspecific)
+}
+
+// close this ship connection from within the event loop, closeReason is announced
+// to the remote service if the connection termination is announced
+func (c *ShipConnection) closeConnectionWithReason(safe bool, code api.ShipCloseCode, reason string, closeReason model.ConnectionCloseReasonType) {
+	// the remote service announced the connection termination, which closes the connection
+	if c.isTerminating() {
+		return
+	}
+
 	c.shutdownOnce.Do(func() {
 		c.stopHandshakeTimer()
 
+		frameReason := closeFrameReason(code, reason)
+
+		if code == api.ShipCloseCodeNone {
+			code = api.ShipCloseCodeNormal
+		}
+		details := api.ShipCloseDetails{
+			Code:   code,
+			Reason: reason,
+		}
+
 		// handshake is completed if approved or aborted
 		state := c.getState()
 		handshakeEnd := state == model.SmeStateComplete ||
@@ -161,35 +364,174 @@ func (c *ShipConnection) CloseConnection(safe bool, code int, reason string) {
 
 		// this may not be used for Connection Data Exchange is entered!
 		if safe && state == model.SmeStateComplete {
+			details.CloseReason = closeReason
+			c.setCloseDetails(details)
+			c.setTerminating()
+
 			// SHIP 13.4.7: Connection Termination Announce
 			closeMessage := model.ConnectionClose{
 				ConnectionClose: model.ConnectionCloseType{
 					Phase:   model.ConnectionClosePhaseTypeAnnounce,
-					MaxTime: util.Ptr(uint(500)),
-					Reason:  util.Ptr(model.ConnectionCloseReasonType(reason)),
+					MaxTime: util.Ptr(uint(cmiCloseMaxTime.Milliseconds())),
+					Reason:  util.Ptr(closeReason),
 				},
 			}
 
 			_ = c.sendShipModel(model.MsgTypeEnd, closeMessage)
 
 			go func() {
-				// wait a bit to let it send
-				<-time.After(500 * time.Millisecond)
-
-				//
-				c.dataWriter.CloseDataConnection(4001, "close")
-				c.infoProvider.HandleConnectionClosed(c, handshakeEnd)
+				// wait for the confirmation up to the announced time
+				select {
+				case <-c.closeConfirmC:
+				case <-c.dataClosedC:
+				case <-c.getClock().After(cmiCloseMaxTime):
+				}
+
+				c.postEvent(func() {
+					c.dataWriter.CloseDataConnection(int(code), frameReason)
+					c.infoProvider.HandleConnectionClosed(c, handshakeEnd)
+					c.stopEventLoop()
+				})
 			}()
 			return
 		}
 
-		closeCode := 4001
-		if code != 0 {
-			closeCode = code
-		}
-		c.dataWriter.CloseDataConnection(closeCode, reason)
+		c.setCloseDetails(details)
+		c.dataWriter.CloseDataConnection(int(code), frameReason)
 
 		c.infoProvider.HandleConnectionClosed(c, handshakeEnd)
+		c.stopEventLoop()
+	})
+}
+
+// return the reason sent to the remote service in the websocket close frame
+//
+// the details are not sent to the remote service for known close codes,
+// the same as for handshake errors
+func closeFrameReason(code api.ShipCloseCode, reason string) string {
+	if code != api.ShipCloseCodeNone {
+		return code.String()
+	}
+
+	if len(reason) > 0 {
+		return reason
+	}
+
+	return api.ShipCloseCodeNormal.String()
+}
+
+// return why the connection was closed, empty while the connection is open
+func (c *ShipConnection) CloseDetails() api.ShipCloseDetails {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	if c.closeDetails == nil {
+		return api.ShipCloseDetails{}
+	}
+
+	return *c.closeDetails
+}
+
+// set why the connection was closed, if it is not set yet
+func (c *ShipConnection) setCloseDetails(details api.ShipCloseDetails) {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	if c.closeDetails != nil {
+		return
+	}
+
+	c.closeDetails = &details
+	c.closedAt = c.clock.Now()
+}
+
+// mark the connection termination as announced by either side
+func (c *ShipConnection) setTerminating() {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	c.terminating = true
+}
+
+// return if the connection termination was announced by either side
+func (c *ShipConnection) isTerminating() bool {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	return c.terminating
+}
+
+// SHIP 13.4.7: handle a connection termination announced by the remote service
+func (c *ShipConnection) handleCloseAnnounce(closeMsg model.ConnectionCloseType) {
+	details := api.ShipCloseDetails{
+		Remote:      true,
+		Code:        api.ShipCloseCodeNormal,
+		Reason:      api.ShipCloseCodeNormal.String(),
+		CloseReason: model.ConnectionCloseReasonTypeUnspecific,
+	}
+	if closeMsg.Reason != nil {
+		details.CloseReason = *closeMsg.Reason
+	}
+	c.setCloseDetails(details)
+	c.setTerminating()
+
+	// SHIP 13.4.7: Connection Termination Confirm
+	closeMessage := model.ConnectionClose{
+		ConnectionClose: model.ConnectionCloseType{
+			Phase: model.ConnectionClosePhaseTypeConfirm,
+		},
+	}
+
+	_ = c.sendShipModel(model.MsgTypeEnd, closeMessage)
+
+	// SHIP 13.4.7: the remote service removed the pairing, so this service should do so as well
+	if details.CloseReason == model.ConnectionCloseReasonTypeRemovedconnection {
+		c.infoProvider.HandleRemotePairingRemoved(c.remoteSKI)
+	}
+
+	maxTime := cmiCloseMaxTime
+	if closeMsg.MaxTime != nil {
+		maxTime = time.Duration(*closeMsg.MaxTime) * time.Millisecond
+	}
+
+	// the remote service closes the connection after receiving the confirmation,
+	// if it does not do so within the announced time, the connection is closed locally
+	go func() {
+		select {
+		case <-c.dataClosedC:
+		case <-c.getClock().After(maxTime):
+		}
+
+		c.postEvent(c.closeTerminatedConnection)
+	}()
+}
+
+// SHIP 13.4.7: handle a connection termination confirmed by the remote service
+func (c *ShipConnection) handleCloseConfirm() {
+	if c.isTerminating() {
+		c.closeConfirmOnce.Do(func() {
+			close(c.closeConfirmC)
+		})
+		return
+	}
+
+	// the termination was not announced, so close the connection right away
+	c.setCloseDetails(api.ShipCloseDetails{
+		Remote: true,
+		Code:   api.ShipCloseCodeNormal,
+		Reason: api.ShipCloseCodeNormal.String(),
+	})
+	c.closeTerminatedConnection()
+}
+
+// close the connection after the connection termination
+func (c *ShipConnection) closeTerminatedConnection() {
+	c.shutdownOnce.Do(func() {
+		c.stopHandshakeTimer()
+
+		c.dataWriter.CloseDataConnection(int(api.ShipCloseCodeNormal), api.ShipCloseCodeNormal.String())
+		c.infoProvider.HandleConnectionClosed(c, c.getState() == model.SmeStateComplete)
+		c.stopEventLoop()
 	})
 }
 
@@ -203,6 +545,20 @@ func (c *ShipConnection) WriteShipMessageWithPayload(message []byte) {
 	}
 }
 
+// send a SPINE message and report if it was written to the connection
+func (c *ShipConnection) WriteShipMessageWithPayloadResult(message []byte) <-chan error {
+	shipMsg, err := c.spineDataMessage(message)
+	if err != nil {
+		logging.Log().Debug(c.RemoteSKI(), "Error sending spine message: ", err)
+
+		result := make(chan error, 1)
+		result <- err
+		return result
+	}
+
+	return c.dataWriter.WriteMessageToWebsocketConnectionWithResult(shipMsg)
+}
+
 var _ api.WebsocketDataReaderInterface = (*ShipConnection)(nil)
 
 func (c *ShipConnection) shipModelFromMessage(message []byte) (*model.ShipData, error) {
@@ -236,12 +592,62 @@ func (c *ShipConnection) processBufferedSpineMessages() {
 	}
 
 	c.spineBuffer = nil
+	c.spineBufferBytes = 0
+}
+
+// buffer a SPINE message that came in before the handshake was completed
+// according to the configured policy
+//
+// returns an error if the connection has to be closed
+func (c *ShipConnection) bufferSpineMessage(payload []byte) error {
+	c.bufferMux.Lock()
+	defer c.bufferMux.Unlock()
+
+	config := c.spineBufferConfig
+
+	if config.Policy == api.SpineBufferPolicyForbid {
+		return errors.New("received SPINE message before the handshake was completed")
+	}
+
+	c.spineBuffer = append(c.spineBuffer, payload)
+	c.spineBufferBytes += len(payload)
+
+	exceeded := func() bool {
+		return len(c.spineBuffer) > config.MaxMessages || c.spineBufferBytes > config.MaxBytes
+	}
+
+	if !exceeded() {
+		return nil
+	}
+
+	if config.Policy == api.SpineBufferPolicyReject {
+		return errors.New("buffer limit for SPINE messages received before the handshake was completed exceeded")
+	}
+
+	dropped := 0
+	for exceeded() && len(c.spineBuffer) > 0 {
+		c.spineBufferBytes -= len(c.spineBuffer[0])
+		c.spineBuffer = c.spineBuffer[1:]
+		dropped++
+	}
+	c.spineBufferDropped += uint64(dropped) // #nosec G115
+
+	logging.Log().Info(c.remoteSKI, "dropped", dropped, "buffered SPINE messages received before the handshake was completed, total:", c.spineBufferDropped)
+
+	return nil
 }
 
 // route the incoming message to either SHIP or SPINE message handlers
 func (c *ShipConnection) HandleIncomingWebsocketMessage(message []byte) {
+	c.postIncomingMessage(func() {
+		c.handleIncomingWebsocketMessage(message)
+	})
+}
+
+func (c *ShipConnection) handleIncomingWebsocketMessage(message []byte) {
 	// Check if this is a SHIP SME or SPINE message
 	if !c.hasSpineDatagram(message) {
+		c.setStateTrigger(c.shipMessageTrigger(message))
 		c.handleShipMessage(false, message)
 		return
 	}
@@ -253,10 +659,9 @@ func (c *ShipConnection) HandleIncomingWebsocketMessage(message []byte) {
 
 	if c.dataReader == nil {
 		// buffer message for processing once the handshake is completed
-		c.bufferMux.Lock()
-		defer c.bufferMux.Unlock()
-
-		c.spineBuffer = append(c.spineBuffer, []byte(data.Data.Payload))
+		if err := c.bufferSpineMessage([]byte(data.Data.Payload)); err != nil {
+			c.endHandshakeWithError(err)
+		}
 
 		return
 	}
@@ -272,32 +677,65 @@ func (c *ShipConnection) hasSpineDatagram(message []byte) bool {
 
 // the websocket data connection was closed from remote
 func (c *ShipConnection) ReportConnectionError(err error) {
+	details := api.ShipCloseDetails{}
+	var closeErr *api.WebsocketCloseError
+	if errors.As(err, &closeErr) {
+		details.Remote = true
+		details.Code = api.ShipCloseCode(closeErr.Code)
+		details.Reason = closeErr.Text
+	} else if err != nil {
+		details.Reason = err.Error()
+	}
+	c.setCloseDetails(details)
+
+	c.dataClosedOnce.Do(func() {
+		close(c.dataClosedC)
+	})
+
+	c.postEvent(func() {
+		c.setStateTrigger(api.ShipStateTriggerConnectionError)
+		c.handleConnectionError(err)
+	})
+}
+
+// handle the closed websocket data connection
+func (c *ShipConnection) handleConnectionError(err error) {
+	// the connection is expected to be closed during the connection termination
+	if c.isTerminating() {
+		return
+	}
+
 	// if the handshake is aborted, a closed connection is no error
 	currentState := c.getState()
 
 	// rejections are also received by sending `{"connectionHello":[{"phase":"pending"},{"waiting":60000}]}`
 	// and then closing the websocket connection with `4452: Node rejected by application.`
 	if currentState == model.SmeHelloStateReadyListen {
-		c.setState(model.SmeHelloStateRejected, nil)
-		c.CloseConnection(false, 0, "")
+		c.setState(model.SmeHelloStateRejected, api.ErrRejectedByRemote)
+		c.closeConnection(false, api.ShipCloseCodeNone, "")
 		return
 	}
 
 	if currentState == model.SmeHelloStateRemoteAbortDone {
 		// remote service should close the connection
-		c.CloseConnection(false, 0, "")
+		c.closeConnection(false, api.ShipCloseCodeNone, "")
 		return
 	}
 
 	if currentState == model.SmeHelloStateAbort ||
 		currentState == model.SmeHelloStateAbortDone {
-		c.CloseConnection(false, 4452, "Node rejected by application")
+		c.closeConnection(false, api.ShipCloseCodeRejected, api.ShipCloseCodeRejected.String())
 		return
 	}
 
+	var closeErr *api.WebsocketCloseError
+	if errors.As(err, &closeErr) && api.ShipCloseCode(closeErr.Code) == api.ShipCloseCodeRejected {
+		err = fmt.Errorf("%w: %w", api.ErrRejectedByRemote, err)
+	}
+
 	c.setState(model.SmeStateError, err)
 
-	c.CloseConnection(false, 0, "")
+	c.closeConnection(false, api.ShipCloseCodeNone, "")
 
 	state := model.ShipState{
 		State: model.SmeStateError,
@@ -346,21 +784,31 @@ func (c *ShipConnection) transformSpineDataIntoShipJson(data []byte) ([]byte, er
 	return []byte(eebusMsg), nil
 }
 
-func (c *ShipConnection) sendSpineData(data []byte) error {
+// transform SPINE data into a SHIP data message ready to be sent
+func (c *ShipConnection) spineDataMessage(data []byte) ([]byte, error) {
 	eebusMsg, err := c.transformSpineDataIntoShipJson(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if isClosed, err := c.dataWriter.IsDataConnectionClosed(); isClosed {
-		c.CloseConnection(false, 0, "")
-		return err
+		c.CloseConnection(false, api.ShipCloseCodeNone, "")
+		return nil, err
 	}
 
 	// Wrap the message into a binary message with the ship header
 	shipMsg := []byte{model.MsgTypeData}
 	shipMsg = append(shipMsg, eebusMsg...)
 
+	return shipMsg, nil
+}
+
+func (c *ShipConnection) sendSpineData(data []byte) error {
+	shipMsg, err := c.spineDataMessage(data)
+	if err != nil {
+		return err
+	}
+
 	err = c.dataWriter.WriteMessageToWebsocketConnection(shipMsg)
 	if err != nil {
 		logging.Log().Debug("error sending message: ", err)
@@ -395,7 +843,7 @@ func (c *ShipConnection) processShipJsonMessage(message []byte, target any) erro
 // transform a SHIP model into EEBUS specific JSON
 func (c *ShipConnection) shipMessage(typ byte, model interface{}) ([]byte, error) {
 	if isClosed, err := c.dataWriter.IsDataConnectionClosed(); isClosed {
-		c.CloseConnection(false, 0, "")
+		c.CloseConnection(false, api.ShipCloseCodeNone, "")
 		return nil, err
 	}
 
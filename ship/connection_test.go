@@ -7,8 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
+	"github.com/enbility/ship-go/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -39,6 +42,7 @@ func (s *ConnectionSuite) BeforeTest(suiteName, testName string) {
 	s.mux.Unlock()
 
 	s.infoProvider = mocks.NewShipConnectionInfoProviderInterface(s.T())
+	s.infoProvider.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDefault).Maybe()
 	s.infoProvider.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
 	s.infoProvider.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
 	s.infoProvider.EXPECT().IsRemoteServiceForSKIPaired(mock.Anything).Return(false).Maybe()
@@ -75,8 +79,29 @@ func (s *ConnectionSuite) Test_DataHandler() {
 	assert.NotNil(s.T(), handler)
 }
 
+func (s *ConnectionSuite) Test_ConnectionInfo() {
+	wsInfo := api.WebsocketConnectionInfo{
+		RemoteAddress: "192.168.1.2:4712",
+		MessagesIn:    2,
+	}
+	s.wsDataWriter.EXPECT().ConnectionInfo().Return(wsInfo).Once()
+
+	s.sut.setProtocol(model.Version{Major: 1, Minor: 0}, model.MessageProtocolFormatTypeUTF8)
+
+	info := s.sut.ConnectionInfo()
+	assert.Equal(s.T(), "RemoveDevice", info.RemoteSKI)
+	assert.Equal(s.T(), "RemoteShipID", info.RemoteShipID)
+	assert.Equal(s.T(), api.ShipRoleServer, info.Role)
+	assert.False(s.T(), info.ConnectedSince.IsZero())
+	assert.Equal(s.T(), model.CmiStateInitStart, info.State)
+	assert.Equal(s.T(), model.Version{Major: 1, Minor: 0}, info.ShipVersion)
+	assert.Equal(s.T(), model.MessageProtocolFormatTypeUTF8, info.ShipFormat)
+	assert.Equal(s.T(), wsInfo, info.Websocket)
+}
+
 func (s *ConnectionSuite) TestRun() {
 	s.sut.Run()
+	s.sut.waitForEvents()
 	state, err := s.sut.ShipHandshakeState()
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), model.CmiStateServerWait, state)
@@ -113,6 +138,138 @@ func (s *ConnectionSuite) Test_HandleShipCloseMessage() {
 	s.sut.handleShipMessage(false, msg)
 }
 
+func (s *ConnectionSuite) Test_HandleCloseAnnounce() {
+	closed := make(chan struct{})
+	wsDataWriter := mocks.NewWebsocketDataWriterInterface(s.T())
+	wsDataWriter.EXPECT().InitDataProcessing(mock.Anything).Return().Maybe()
+	wsDataWriter.EXPECT().WriteMessageToWebsocketConnection(mock.Anything).
+		RunAndReturn(func(message []byte) error {
+			s.mux.Lock()
+			defer s.mux.Unlock()
+
+			s.sentMessage = message
+
+			return nil
+		}).
+		Maybe()
+	wsDataWriter.EXPECT().IsDataConnectionClosed().Return(false, nil).Maybe()
+	wsDataWriter.EXPECT().CloseDataConnection(int(api.ShipCloseCodeNormal), mock.Anything).
+		Run(func(closeCode int, reason string) { close(closed) }).Once()
+
+	sut := NewConnectionHandler(s.infoProvider, wsDataWriter, ShipRoleServer, "LocalShipID", "RemoveDevice", "RemoteShipID")
+	sut.smeState = model.SmeStateComplete
+
+	s.infoProvider.EXPECT().HandleRemotePairingRemoved("RemoveDevice").Return().Once()
+
+	closeMsg := model.ConnectionClose{
+		ConnectionClose: model.ConnectionCloseType{
+			Phase:   model.ConnectionClosePhaseTypeAnnounce,
+			MaxTime: util.Ptr(uint(10)),
+			Reason:  util.Ptr(model.ConnectionCloseReasonTypeRemovedconnection),
+		},
+	}
+
+	msg, err := sut.shipMessage(model.MsgTypeEnd, closeMsg)
+	assert.Nil(s.T(), err)
+
+	sut.postEvent(func() { sut.handleShipMessage(false, msg) })
+	sut.waitForEvents()
+
+	s.mux.Lock()
+	sentMessage := s.sentMessage
+	s.mux.Unlock()
+	var confirm model.ConnectionClose
+	err = sut.processShipJsonMessage(sentMessage, &confirm)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.ConnectionClosePhaseTypeConfirm, confirm.ConnectionClose.Phase)
+
+	// the connection is closed locally after the announced max time
+	select {
+	case <-closed:
+	case <-time.After(cmiCloseMaxTime / 2):
+		s.T().Fatal("connection not closed after the announced max time")
+	}
+	<-sut.eventLoopStopC
+
+	details := sut.CloseDetails()
+	assert.True(s.T(), details.Remote)
+	assert.Equal(s.T(), api.ShipCloseCodeNormal, details.Code)
+	assert.Equal(s.T(), model.ConnectionCloseReasonTypeRemovedconnection, details.CloseReason)
+}
+
+func (s *ConnectionSuite) Test_CloseFrameReason() {
+	assert.Equal(s.T(), api.ShipCloseCodeRejected.String(), closeFrameReason(api.ShipCloseCodeRejected, "details"))
+	assert.Equal(s.T(), "details", closeFrameReason(api.ShipCloseCodeNone, "details"))
+	assert.Equal(s.T(), api.ShipCloseCodeNormal.String(), closeFrameReason(api.ShipCloseCodeNone, ""))
+
+	// the direct close uses the same reason as the announced close
+	wsDataWriter := mocks.NewWebsocketDataWriterInterface(s.T())
+	wsDataWriter.EXPECT().InitDataProcessing(mock.Anything).Return().Maybe()
+	wsDataWriter.EXPECT().CloseDataConnection(int(api.ShipCloseCodeUserClose), api.ShipCloseCodeUserClose.String()).Return().Once()
+
+	sut := NewConnectionHandler(s.infoProvider, wsDataWriter, ShipRoleServer, "LocalShipID", "RemoveDevice", "RemoteShipID")
+	sut.CloseConnection(false, api.ShipCloseCodeUserClose, "closed by the user")
+	<-sut.eventLoopStopC
+
+	assert.Equal(s.T(), "closed by the user", sut.CloseDetails().Reason)
+}
+
+func (s *ConnectionSuite) Test_CloseConnection_WaitsForConfirm() {
+	closed := make(chan struct{})
+	wsDataWriter := mocks.NewWebsocketDataWriterInterface(s.T())
+	wsDataWriter.EXPECT().InitDataProcessing(mock.Anything).Return().Maybe()
+	wsDataWriter.EXPECT().WriteMessageToWebsocketConnection(mock.Anything).Return(nil).Maybe()
+	wsDataWriter.EXPECT().IsDataConnectionClosed().Return(false, nil).Maybe()
+	wsDataWriter.EXPECT().CloseDataConnection(int(api.ShipCloseCodeUserClose), api.ShipCloseCodeUserClose.String()).
+		Run(func(closeCode int, reason string) { close(closed) }).Once()
+
+	sut := NewConnectionHandler(s.infoProvider, wsDataWriter, ShipRoleServer, "LocalShipID", "RemoveDevice", "RemoteShipID")
+	sut.smeState = model.SmeStateComplete
+	sut.CloseConnection(true, api.ShipCloseCodeUserClose, api.ShipCloseCodeUserClose.String())
+	sut.waitForEvents()
+
+	select {
+	case <-closed:
+		s.T().Fatal("connection closed before the confirmation was received")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sut.postEvent(sut.handleCloseConfirm)
+
+	select {
+	case <-closed:
+	case <-time.After(cmiCloseMaxTime / 2):
+		s.T().Fatal("connection not closed after the confirmation was received")
+	}
+	<-sut.eventLoopStopC
+
+	details := sut.CloseDetails()
+	assert.False(s.T(), details.Remote)
+	assert.Equal(s.T(), api.ShipCloseCodeUserClose, details.Code)
+}
+
+func (s *ConnectionSuite) Test_CloseConnectionPairingRemoved() {
+	s.sut.smeState = model.SmeStateComplete
+	s.sut.CloseConnectionPairingRemoved()
+	s.sut.waitForEvents()
+
+	s.mux.Lock()
+	sentMessage := s.sentMessage
+	s.mux.Unlock()
+	var announce model.ConnectionClose
+	err := s.sut.processShipJsonMessage(sentMessage, &announce)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.ConnectionClosePhaseTypeAnnounce, announce.ConnectionClose.Phase)
+	assert.Equal(s.T(), model.ConnectionCloseReasonTypeRemovedconnection, *announce.ConnectionClose.Reason)
+
+	details := s.sut.CloseDetails()
+	assert.False(s.T(), details.Remote)
+	assert.Equal(s.T(), api.ShipCloseCodeUserClose, details.Code)
+	assert.Equal(s.T(), model.ConnectionCloseReasonTypeRemovedconnection, details.CloseReason)
+
+	s.sut.handleCloseConfirm()
+}
+
 func (s *ConnectionSuite) TestShipHandshakeState() {
 	state, err := s.sut.ShipHandshakeState()
 	assert.Nil(s.T(), err)
@@ -132,26 +289,31 @@ func (s *ConnectionSuite) Test_HandleErrorState() {
 func (s *ConnectionSuite) TestApprovePendingHandshake() {
 	s.sut.smeState = model.CmiStateInitStart
 	s.sut.ApprovePendingHandshake()
+	s.sut.waitForEvents()
 	assert.Equal(s.T(), model.CmiStateInitStart, s.sut.smeState)
 
 	s.sut.smeState = model.SmeHelloStatePendingListen
 	s.sut.ApprovePendingHandshake()
+	s.sut.waitForEvents()
 	assert.Equal(s.T(), model.SmeProtHStateServerListenProposal, s.sut.smeState)
 }
 
 func (s *ConnectionSuite) TestAbortPendingHandshake() {
 	s.sut.smeState = model.CmiStateInitStart
 	s.sut.AbortPendingHandshake()
+	s.sut.waitForEvents()
 	assert.Equal(s.T(), model.CmiStateInitStart, s.sut.smeState)
 
 	s.sut.smeState = model.SmeHelloStatePendingListen
 	s.sut.AbortPendingHandshake()
+	s.sut.waitForEvents()
 	assert.Equal(s.T(), model.SmeHelloStateAbortDone, s.sut.smeState)
 }
 
 func (s *ConnectionSuite) TestCloseConnection_StateComplete() {
 	s.sut.smeState = model.SmeStateComplete
 	s.sut.CloseConnection(true, 450, "User Close")
+	s.sut.waitForEvents()
 	state, err := s.sut.ShipHandshakeState()
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), model.SmeStateComplete, state)
@@ -160,6 +322,7 @@ func (s *ConnectionSuite) TestCloseConnection_StateComplete() {
 func (s *ConnectionSuite) TestCloseConnection_StateComplete_2() {
 	s.sut.smeState = model.SmeStateError
 	s.sut.CloseConnection(false, 0, "User Close")
+	s.sut.waitForEvents()
 	state, err := s.sut.ShipHandshakeState()
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), model.SmeStateError, state)
@@ -168,6 +331,7 @@ func (s *ConnectionSuite) TestCloseConnection_StateComplete_2() {
 func (s *ConnectionSuite) TestCloseConnection_StateComplete_3() {
 	s.sut.smeState = model.SmeStateError
 	s.sut.CloseConnection(false, 450, "User Close")
+	s.sut.waitForEvents()
 	state, err := s.sut.ShipHandshakeState()
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), model.SmeStateError, state)
@@ -199,6 +363,7 @@ func (s *ConnectionSuite) TestHandleIncomingShipMessage() {
 	msg = append(msg, jsonData...)
 
 	s.sut.HandleIncomingWebsocketMessage(msg)
+	s.sut.waitForEvents()
 
 	spineData := `{"datagram":{}}`
 	jsonData = []byte(spineData)
@@ -215,31 +380,115 @@ func (s *ConnectionSuite) TestHandleIncomingShipMessage() {
 	msg = append(msg, jsonData...)
 
 	s.sut.HandleIncomingWebsocketMessage(msg)
+	s.sut.waitForEvents()
 
 	s.sut.dataReader = s.shipConnectionReader
 
 	s.sut.processBufferedSpineMessages()
 
 	s.sut.HandleIncomingWebsocketMessage(msg)
+	s.sut.waitForEvents()
+}
+
+func (s *ConnectionSuite) spineMessage(payload string) []byte {
+	modelData := model.ShipData{
+		Data: model.DataType{
+			Payload: []byte(payload),
+		},
+	}
+	jsonData, err := json.Marshal(modelData)
+	assert.Nil(s.T(), err)
+
+	msg := []byte{model.MsgTypeData}
+	return append(msg, jsonData...)
+}
+
+func (s *ConnectionSuite) TestSpineBuffer_DropOldest() {
+	s.sut.SetSpineBufferConfig(api.SpineBufferConfig{
+		Policy:      api.SpineBufferPolicyDropOldest,
+		MaxMessages: 2,
+	})
+
+	for i := 0; i < 3; i++ {
+		s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{}}`))
+		s.sut.waitForEvents()
+	}
+	assert.Equal(s.T(), 2, len(s.sut.spineBuffer))
+	assert.Equal(s.T(), uint64(1), s.sut.DroppedSpineMessages())
+
+	s.sut.SetSpineBufferConfig(api.SpineBufferConfig{
+		Policy:   api.SpineBufferPolicyDropOldest,
+		MaxBytes: 20,
+	})
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{"payload":{}}}`))
+	s.sut.waitForEvents()
+	assert.Equal(s.T(), 0, len(s.sut.spineBuffer))
+	assert.Equal(s.T(), 0, s.sut.spineBufferBytes)
+	assert.Equal(s.T(), uint64(4), s.sut.DroppedSpineMessages())
+	assert.NotEqual(s.T(), model.SmeStateError, s.sut.getState())
+}
+
+func (s *ConnectionSuite) TestSpineBuffer_Reject() {
+	s.sut.SetSpineBufferConfig(api.SpineBufferConfig{
+		Policy:      api.SpineBufferPolicyReject,
+		MaxMessages: 1,
+	})
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{}}`))
+	s.sut.waitForEvents()
+	assert.NotEqual(s.T(), model.SmeStateError, s.sut.getState())
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{}}`))
+	s.sut.waitForEvents()
+	state, err := s.sut.ShipHandshakeState()
+	assert.Equal(s.T(), model.SmeStateError, state)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *ConnectionSuite) TestSpineBuffer_Forbid() {
+	s.sut.SetSpineBufferConfig(api.SpineBufferConfig{
+		Policy: api.SpineBufferPolicyForbid,
+	})
+
+	s.sut.HandleIncomingWebsocketMessage(s.spineMessage(`{"datagram":{}}`))
+	s.sut.waitForEvents()
+	state, err := s.sut.ShipHandshakeState()
+	assert.Equal(s.T(), model.SmeStateError, state)
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(s.sut.spineBuffer))
 }
 
 func (s *ConnectionSuite) TestReportConnectionError() {
-	s.sut.ReportConnectionError(nil)
+	s.sut.handleConnectionError(nil)
 	assert.Equal(s.T(), model.SmeStateError, s.sut.smeState)
 
 	s.sut.smeState = model.SmeHelloStateReadyListen
-	s.sut.ReportConnectionError(nil)
+	s.sut.handleConnectionError(nil)
 	assert.Equal(s.T(), model.SmeHelloStateRejected, s.sut.smeState)
 
 	s.sut.smeState = model.SmeHelloStateRemoteAbortDone
-	s.sut.ReportConnectionError(nil)
+	s.sut.handleConnectionError(nil)
 	assert.Equal(s.T(), model.SmeHelloStateRemoteAbortDone, s.sut.smeState)
 
 	s.sut.smeState = model.SmeHelloStateAbort
-	s.sut.ReportConnectionError(nil)
+	s.sut.handleConnectionError(nil)
 	assert.Equal(s.T(), model.SmeHelloStateAbort, s.sut.smeState)
 }
 
+func (s *ConnectionSuite) TestReportConnectionError_CloseDetails() {
+	s.sut.ReportConnectionError(&api.WebsocketCloseError{Code: int(api.ShipCloseCodeUserClose), Text: "User close"})
+
+	details := s.sut.CloseDetails()
+	assert.True(s.T(), details.Remote)
+	assert.Equal(s.T(), api.ShipCloseCodeUserClose, details.Code)
+	assert.Equal(s.T(), "User close", details.Reason)
+
+	// the first close wins
+	s.sut.ReportConnectionError(errors.New("dropped"))
+	assert.Equal(s.T(), api.ShipCloseCodeUserClose, s.sut.CloseDetails().Code)
+}
+
 func (s *ConnectionSuite) TestSendShipModel() {
 	err := s.sut.sendShipModel(model.MsgTypeInit, nil)
 	assert.NotNil(s.T(), err)
@@ -279,14 +528,35 @@ func (s *ConnectionSuite) TestSendSpineMessage() {
 	assert.Nil(s.T(), err)
 }
 
+func (s *ConnectionSuite) TestSendSpineMessageWithResult() {
+	s.wsDataWriter.EXPECT().WriteMessageToWebsocketConnectionWithResult(mock.Anything).
+		RunAndReturn(func(message []byte) <-chan error {
+			result := make(chan error, 1)
+			result <- nil
+			return result
+		}).Once()
+
+	data := `{"datagram":{"header":{},"payload":{"cmd":[]}}}`
+
+	result := s.sut.WriteShipMessageWithPayloadResult([]byte(data))
+	assert.Nil(s.T(), <-result)
+
+	result = s.sut.WriteShipMessageWithPayloadResult([]byte("invalid"))
+	assert.NotNil(s.T(), <-result)
+}
+
 func (s *ConnectionSuite) Test_HandshakeTimer() {
 	s.sut.setState(model.CmiStateInitStart, nil)
 	assert.Equal(s.T(), model.CmiStateInitStart, s.sut.getState())
 
+	clock := clocktest.NewFakeClock(time.Now())
+	s.sut.SetClock(clock)
+
 	s.sut.setHandshakeTimer(timeoutTimerTypeWaitForReady, time.Duration(time.Millisecond*500))
 	assert.Equal(s.T(), true, s.sut.getHandshakeTimerRunning())
 
-	time.Sleep(time.Second * 1)
+	clock.Advance(time.Millisecond * 500)
+	s.sut.waitForEvents()
 	assert.Equal(s.T(), model.CmiStateServerWait, s.sut.getState())
 	assert.Equal(s.T(), timeoutTimerTypeWaitForReady, s.sut.getHandshakeTimerType())
 	assert.Equal(s.T(), true, s.sut.getHandshakeTimerRunning())
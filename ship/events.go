@@ -0,0 +1,98 @@
+package ship
+
+// Every input of a connection, e.g. a received message, a timeout, a pairing decision
+// or a close request, is an event. The events are processed sequentially by the event loop
+// of the connection, so the handshake state machine is never invoked concurrently
+
+// the maximum number of received messages waiting to be processed by the event loop
+//
+// receiving further messages is blocked, so the remote service is slowed down by TCP flow control
+const maxQueuedIncomingMessages = 100
+
+// start the event loop of this connection
+func (c *ShipConnection) startEventLoop() {
+	c.eventsC = make(chan struct{}, 1)
+	c.eventLoopStopC = make(chan struct{})
+	c.incomingSlots = make(chan struct{}, maxQueuedIncomingMessages)
+
+	go c.runEventLoop()
+}
+
+// process the queued events until the event loop is stopped
+func (c *ShipConnection) runEventLoop() {
+	for {
+		select {
+		case <-c.eventLoopStopC:
+			return
+		case <-c.eventsC:
+		}
+
+		for event := c.nextEvent(); event != nil; event = c.nextEvent() {
+			event()
+		}
+	}
+}
+
+// queue an event to be processed by the event loop
+//
+// never blocks, events queued after the event loop was stopped are dropped
+func (c *ShipConnection) postEvent(event func()) {
+	c.eventsMux.Lock()
+	if c.eventLoopStopped {
+		c.eventsMux.Unlock()
+		return
+	}
+	c.events = append(c.events, event)
+	c.eventsMux.Unlock()
+
+	select {
+	case c.eventsC <- struct{}{}:
+	default:
+	}
+}
+
+// queue a received message to be processed by the event loop
+//
+// blocks while maxQueuedIncomingMessages are queued, until the event loop is stopped
+func (c *ShipConnection) postIncomingMessage(event func()) {
+	select {
+	case c.incomingSlots <- struct{}{}:
+	case <-c.eventLoopStopC:
+		return
+	}
+
+	c.postEvent(func() {
+		<-c.incomingSlots
+		event()
+	})
+}
+
+// return the next queued event, nil if there is none or the event loop was stopped
+func (c *ShipConnection) nextEvent() func() {
+	c.eventsMux.Lock()
+	defer c.eventsMux.Unlock()
+
+	if c.eventLoopStopped || len(c.events) == 0 {
+		return nil
+	}
+
+	event := c.events[0]
+	c.events[0] = nil
+	c.events = c.events[1:]
+
+	return event
+}
+
+// stop the event loop once the connection is closed, queued events are dropped
+func (c *ShipConnection) stopEventLoop() {
+	c.eventsMux.Lock()
+	defer c.eventsMux.Unlock()
+
+	if c.eventLoopStopped {
+		return
+	}
+
+	c.eventLoopStopped = true
+	c.events = nil
+	close(c.eventLoopStopC)
+}
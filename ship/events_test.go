@@ -0,0 +1,153 @@
+package ship
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
+	"github.com/enbility/ship-go/mocks"
+	"github.com/enbility/ship-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// wait until the events queued so far are processed
+func (c *ShipConnection) waitForEvents() {
+	done := make(chan struct{})
+	c.postEvent(func() { close(done) })
+
+	select {
+	case <-done:
+	case <-c.eventLoopStopC:
+	}
+}
+
+func newEventTestConnection(t *testing.T) *ShipConnection {
+	infoProvider := mocks.NewShipConnectionInfoProviderInterface(t)
+	infoProvider.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
+	infoProvider.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
+
+	wsDataWriter := mocks.NewWebsocketDataWriterInterface(t)
+	wsDataWriter.EXPECT().InitDataProcessing(mock.Anything).Return().Maybe()
+	wsDataWriter.EXPECT().CloseDataConnection(mock.Anything, mock.Anything).Return().Maybe()
+
+	return NewConnectionHandler(infoProvider, wsDataWriter, ShipRoleServer, "LocalShipID", "RemoteDevice", "RemoteShipID")
+}
+
+func Test_EventLoop_Sequential(t *testing.T) {
+	sut := newEventTestConnection(t)
+
+	var mux sync.Mutex
+	running := false
+	concurrent := false
+	var order []int
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sut.postEvent(func() {
+				mux.Lock()
+				if running {
+					concurrent = true
+				}
+				running = true
+				order = append(order, i)
+				mux.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mux.Lock()
+				running = false
+				mux.Unlock()
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	sut.waitForEvents()
+
+	mux.Lock()
+	defer mux.Unlock()
+	assert.False(t, concurrent)
+	assert.Equal(t, 50, len(order))
+}
+
+func Test_EventLoop_IncomingMessagesBounded(t *testing.T) {
+	sut := newEventTestConnection(t)
+
+	// block the event loop
+	release := make(chan struct{})
+	sut.postEvent(func() { <-release })
+
+	for i := 0; i < maxQueuedIncomingMessages; i++ {
+		sut.postIncomingMessage(func() {})
+	}
+
+	// the next received message waits until the queued ones are processed
+	queued := make(chan struct{})
+	go func() {
+		sut.postIncomingMessage(func() {})
+		close(queued)
+	}()
+
+	select {
+	case <-queued:
+		t.Fatal("received message was queued beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-queued
+	sut.waitForEvents()
+
+	// a stopped event loop doesn't block receiving
+	sut.stopEventLoop()
+	for i := 0; i <= maxQueuedIncomingMessages; i++ {
+		sut.postIncomingMessage(func() {})
+	}
+}
+
+func Test_EventLoop_StoppedOnClose(t *testing.T) {
+	sut := newEventTestConnection(t)
+
+	sut.CloseConnection(false, api.ShipCloseCodeNone, "")
+	sut.waitForEvents()
+
+	processed := false
+	sut.postEvent(func() { processed = true })
+	sut.waitForEvents()
+
+	assert.False(t, processed)
+	assert.Equal(t, api.ShipCloseCodeNormal, sut.CloseDetails().Code)
+}
+
+func Test_EventLoop_StaleTimeout(t *testing.T) {
+	sut := newEventTestConnection(t)
+	clock := clocktest.NewFakeClock(time.Now())
+	sut.SetClock(clock)
+
+	sut.setHandshakeTimer(timeoutTimerTypeWaitForReady, 10*time.Millisecond)
+	generation := sut.getHandshakeTimerGeneration()
+
+	// the timer is replaced after the timeout fired, but before the timeout event is processed
+	fired := make(chan struct{})
+	sut.postEvent(func() {
+		<-fired
+		sut.setHandshakeTimer(timeoutTimerTypeWaitForReady, time.Hour)
+	})
+
+	clock.Advance(10 * time.Millisecond)
+	close(fired)
+	sut.waitForEvents()
+
+	// the timeout of the replaced timer is ignored
+	assert.NotEqual(t, generation, sut.getHandshakeTimerGeneration())
+	assert.True(t, sut.getHandshakeTimerRunning())
+	assert.Equal(t, model.CmiStateInitStart, sut.getState())
+	sut.stopHandshakeTimer()
+}
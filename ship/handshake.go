@@ -1,9 +1,9 @@
 package ship
 
 import (
-	"errors"
 	"time"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/model"
 )
@@ -16,25 +16,9 @@ func (c *ShipConnection) handleShipMessage(timeout bool, message []byte) {
 		if err == nil && closeMsg.ConnectionClose.Phase != "" {
 			switch closeMsg.ConnectionClose.Phase {
 			case model.ConnectionClosePhaseTypeAnnounce:
-				// SHIP 13.4.7: Connection Termination Confirm
-				closeMessage := model.ConnectionClose{
-					ConnectionClose: model.ConnectionCloseType{
-						Phase: model.ConnectionClosePhaseTypeConfirm,
-					},
-				}
-
-				_ = c.sendShipModel(model.MsgTypeEnd, closeMessage)
-
-				// wait a bit to let it send
-				<-time.After(500 * time.Millisecond)
-
-				//
-				c.dataWriter.CloseDataConnection(4001, "close")
-				c.infoProvider.HandleConnectionClosed(c, c.getState() == model.SmeStateComplete)
+				c.handleCloseAnnounce(closeMsg.ConnectionClose)
 			case model.ConnectionClosePhaseTypeConfirm:
-				// we got a confirmation so close this connection
-				c.dataWriter.CloseDataConnection(4001, "close")
-				c.infoProvider.HandleConnectionClosed(c, c.getState() == model.SmeStateComplete)
+				c.handleCloseConfirm()
 			}
 
 			return
@@ -71,6 +55,7 @@ func (c *ShipConnection) setState(newState model.ShipMessageExchangeState, err e
 	c.smeError = nil
 	if oldState != newState {
 		c.smeError = err
+		c.recordStateTransition(oldState, newState, err)
 		state := model.ShipState{
 			State: newState,
 			Error: err,
@@ -103,7 +88,7 @@ func (c *ShipConnection) handleState(timeout bool, message []byte) {
 
 	case model.CmiStateClientWait:
 		if timeout {
-			c.endHandshakeWithError(errors.New("ship client handshake timeout"))
+			c.endHandshakeWithError(&api.HandshakeTimeoutError{Phase: api.HandshakePhaseInit})
 			return
 		}
 
@@ -111,7 +96,7 @@ func (c *ShipConnection) handleState(timeout bool, message []byte) {
 
 	case model.CmiStateServerWait:
 		if timeout {
-			c.endHandshakeWithError(errors.New("ship server handshake timeout"))
+			c.endHandshakeWithError(&api.HandshakeTimeoutError{Phase: api.HandshakePhaseInit})
 			return
 		}
 		c.handshakeInit_cmiStateServerWait(message)
@@ -123,8 +108,17 @@ func (c *ShipConnection) handleState(timeout bool, message []byte) {
 		// which means it was initiated from this service usually by triggering the
 		// pairing service
 		// go to substate ready if so, otherwise to substate pending
+		// a pairing policy can accept or deny the remote service upfront
+
+		decision := c.infoProvider.PairingDecisionForSKI(c.remoteSKI)
+		if decision == api.PairingDecisionDeny {
+			logging.Log().Debug(c.RemoteSKI(), "remote service denied by pairing policy")
+			c.setAndHandleState(model.SmeHelloStateAbort)
+			return
+		}
 
-		if c.infoProvider.IsRemoteServiceForSKIPaired(c.remoteSKI) ||
+		if decision == api.PairingDecisionAccept ||
+			c.infoProvider.IsRemoteServiceForSKIPaired(c.remoteSKI) ||
 			c.infoProvider.IsAutoAcceptEnabled() ||
 			c.role == ShipRoleClient {
 			c.setState(model.SmeHelloStateReadyInit, nil)
@@ -152,20 +146,31 @@ func (c *ShipConnection) handleState(timeout bool, message []byte) {
 		c.handshakeHello_Abort()
 
 	case model.SmeHelloStateAbortDone, model.SmeHelloStateRemoteAbortDone:
-		go func() {
-			<-time.After(time.Second)
-			c.CloseConnection(false, 4452, "Node rejected by application")
-		}()
+		c.getClock().AfterFunc(time.Second, func() {
+			c.CloseConnection(false, api.ShipCloseCodeRejected, api.ShipCloseCodeRejected.String())
+		})
 
 	// smeProtocol
 
 	case model.SmeProtHStateServerListenProposal:
+		if timeout {
+			c.endHandshakeWithError(&api.HandshakeTimeoutError{Phase: api.HandshakePhaseProtocol})
+			return
+		}
 		c.handshakeProtocol_smeProtHStateServerListenProposal(message)
 
 	case model.SmeProtHStateServerListenConfirm:
+		if timeout {
+			c.endHandshakeWithError(&api.HandshakeTimeoutError{Phase: api.HandshakePhaseProtocol})
+			return
+		}
 		c.handshakeProtocol_smeProtHStateServerListenConfirm(message)
 
 	case model.SmeProtHStateClientListenChoice:
+		if timeout {
+			c.endHandshakeWithError(&api.HandshakeTimeoutError{Phase: api.HandshakePhaseProtocol})
+			return
+		}
 		c.stopHandshakeTimer()
 		c.handshakeProtocol_smeProtHStateClientListenChoice(message)
 
@@ -189,20 +194,46 @@ func (c *ShipConnection) handleState(timeout bool, message []byte) {
 	// smeAccessMethods
 
 	case model.SmeAccessMethodsRequest:
+		if timeout {
+			c.endHandshakeWithError(&api.HandshakeTimeoutError{Phase: api.HandshakePhaseAccessMethods})
+			return
+		}
 		c.handshakeAccessMethods_Request(message)
 	}
 }
 
 // set a state and trigger handling it
 func (c *ShipConnection) setAndHandleState(state model.ShipMessageExchangeState) {
-	c.setState(state, nil)
+	c.setAndHandleStateWithError(state, nil)
+}
+
+// set a state with the error causing it and trigger handling it
+func (c *ShipConnection) setAndHandleStateWithError(state model.ShipMessageExchangeState, err error) {
+	c.setState(state, err)
 	c.handleState(false, nil)
 }
 
+// return the error of the current handshake state
+func (c *ShipConnection) getStateError() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.smeError
+}
+
 // SHIP handshake is approved, now set the new state and the SPINE read handler
 func (c *ShipConnection) approveHandshake() {
+	c.infoProvider.HandleShipHandshakeCompleted(c)
+
 	// Report to SPINE local device about this remote device connection
-	c.dataReader = c.infoProvider.SetupRemoteDevice(c.remoteSKI, c)
+	dataReader, err := c.infoProvider.SetupRemoteDevice(c.remoteSKI, c)
+	if err != nil {
+		// the received SPINE messages can't be processed without a data reader
+		c.endHandshakeWithError(err)
+		return
+	}
+
+	c.dataReader = dataReader
 	c.stopHandshakeTimer()
 	c.setState(model.SmeStateComplete, nil)
 	c.processBufferedSpineMessages()
@@ -210,13 +241,23 @@ func (c *ShipConnection) approveHandshake() {
 
 // end the handshake process because of an error
 func (c *ShipConnection) endHandshakeWithError(err error) {
+	c.endHandshakeWithErrorCode(err, api.ShipCloseCodeNone)
+}
+
+// end the handshake process because of an error and close the connection with the code
+func (c *ShipConnection) endHandshakeWithErrorCode(err error, code api.ShipCloseCode) {
 	c.stopHandshakeTimer()
 
 	c.setState(model.SmeStateError, err)
 
 	logging.Log().Debug(c.RemoteSKI(), "SHIP handshake error:", err)
 
-	c.CloseConnection(true, 0, err.Error())
+	// the details of the error are not sent to the remote service for known close codes
+	reason := err.Error()
+	if code != api.ShipCloseCodeNone {
+		reason = code.String()
+	}
+	c.closeConnection(true, code, reason)
 
 	state := model.ShipState{
 		State: model.SmeStateError,
@@ -225,43 +266,62 @@ func (c *ShipConnection) endHandshakeWithError(err error) {
 	c.infoProvider.HandleShipHandshakeStateUpdate(c.remoteSKI, state)
 }
 
-// set the handshake timer to a new duration and start the channel
+// set the handshake timer to a new duration and start it
 func (c *ShipConnection) setHandshakeTimer(timerType timeoutTimerType, duration time.Duration) {
 	c.stopHandshakeTimer()
 
 	c.setHandshakeTimerRunning(true)
 	c.setHandshakeTimerType(timerType)
+	generation := c.getHandshakeTimerGeneration()
+
+	timer := c.getClock().AfterFunc(duration, func() {
+		c.postEvent(func() {
+			// the timer was stopped or replaced before the timeout was processed
+			if c.getHandshakeTimerGeneration() != generation {
+				return
+			}
 
-	go func() {
-		select {
-		case <-c.handshakeTimerStopChan:
-			return
-		case <-time.After(duration):
 			c.setHandshakeTimerRunning(false)
+			c.setStateTrigger(api.ShipStateTriggerTimeout)
 			c.handleState(true, nil)
-			return
-		}
-	}()
+		})
+	})
+
+	c.handshakeTimerMux.Lock()
+	c.handshakeTimer = timer
+	c.handshakeTimerMux.Unlock()
 }
 
-// stop the handshake timer and close the channel
+// stop the handshake timer
 func (c *ShipConnection) stopHandshakeTimer() {
 	if !c.getHandshakeTimerRunning() {
 		return
 	}
 
-	select {
-	case c.handshakeTimerStopChan <- struct{}{}:
-	default:
+	c.handshakeTimerMux.Lock()
+	if c.handshakeTimer != nil {
+		c.handshakeTimer.Stop()
+		c.handshakeTimer = nil
 	}
+	c.handshakeTimerMux.Unlock()
+
 	c.setHandshakeTimerRunning(false)
 }
 
+// every start and stop of the handshake timer starts a new generation
 func (c *ShipConnection) setHandshakeTimerRunning(value bool) {
 	c.handshakeTimerMux.Lock()
 	defer c.handshakeTimerMux.Unlock()
 
 	c.handshakeTimerRunning = value
+	c.handshakeTimerGeneration++
+}
+
+func (c *ShipConnection) getHandshakeTimerGeneration() uint64 {
+	c.handshakeTimerMux.Lock()
+	defer c.handshakeTimerMux.Unlock()
+
+	return c.handshakeTimerGeneration
 }
 
 func (c *ShipConnection) getHandshakeTimerRunning() bool {
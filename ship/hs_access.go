@@ -2,10 +2,10 @@ package ship
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/model"
 )
 
@@ -49,29 +49,33 @@ func (c *ShipConnection) handshakeAccessMethods_Request(message []byte) {
 
 		var accessMethods model.AccessMethods
 		if err := json.Unmarshal([]byte(data), &accessMethods); err != nil {
-			c.endHandshakeWithError(err)
+			c.endHandshakeWithError(fmt.Errorf("%w: %w", api.ErrInvalidHandshakeMessage, err))
 			return
 		}
 
 		if accessMethods.AccessMethods.Id == nil {
-			c.endHandshakeWithError(errors.New("Access methods response does not contain SHIP ID"))
+			c.endHandshakeWithError(fmt.Errorf("%w: access methods response does not contain SHIP ID", api.ErrInvalidHandshakeMessage))
 			return
 		}
 
 		// if the ID string is empty, then we don't know it yet and can't be verified
 		if len(c.remoteShipID) > 0 && c.remoteShipID != *accessMethods.AccessMethods.Id {
-			c.endHandshakeWithError(errors.New("SHIP id mismatch"))
+			err := &api.ShipIDMismatchError{
+				Expected: c.remoteShipID,
+				Actual:   *accessMethods.AccessMethods.Id,
+			}
+			c.endHandshakeWithErrorCode(err, api.ShipCloseCodeShipIDMismatch)
 			return
 		}
 
 		// save and report the SHIP ID
 		if len(c.remoteShipID) == 0 {
-			c.remoteShipID = *accessMethods.AccessMethods.Id
+			c.setRemoteShipID(*accessMethods.AccessMethods.Id)
 
 			c.infoProvider.ReportServiceShipID(c.remoteSKI, c.remoteShipID)
 		}
 	} else {
-		c.endHandshakeWithError(fmt.Errorf("access methods: invalid response: %s", dataString))
+		c.endHandshakeWithError(fmt.Errorf("%w: access methods: invalid response: %s", api.ErrInvalidHandshakeMessage, dataString))
 		return
 	}
 
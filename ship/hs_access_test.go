@@ -1,9 +1,11 @@
 package ship
 
 import (
+	"errors"
 	"sync"
 	"testing"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
 	"github.com/enbility/ship-go/util"
@@ -67,6 +69,7 @@ func (s *AccessSuite) BeforeTest(suiteName, testName string) {
 		}).Maybe()
 
 	s.mockShipInfo = mocks.NewShipConnectionInfoProviderInterface(s.T())
+	s.mockShipInfo.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDefault).Maybe()
 	s.mockShipInfo.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
 	s.mockShipInfo.EXPECT().IsRemoteServiceForSKIPaired(mock.Anything).Return(true).Maybe()
 	s.mockShipInfo.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
@@ -120,7 +123,8 @@ func (s *AccessSuite) Test_Request_Invalid() {
 
 func (s *AccessSuite) Test_Methods_Ok() {
 	reader := mocks.NewShipConnectionDataReaderInterface(s.T())
-	s.mockShipInfo.EXPECT().SetupRemoteDevice(mock.Anything, mock.Anything).Return(reader)
+	s.mockShipInfo.EXPECT().HandleShipHandshakeCompleted(s.sut).Return().Once()
+	s.mockShipInfo.EXPECT().SetupRemoteDevice(mock.Anything, mock.Anything).Return(reader, nil)
 	s.sut.setState(model.SmeAccessMethodsRequest, nil)
 
 	accessMsg := model.AccessMethods{
@@ -138,6 +142,33 @@ func (s *AccessSuite) Test_Methods_Ok() {
 	assert.Equal(s.T(), model.SmeStateComplete, s.sut.getState())
 }
 
+func (s *AccessSuite) Test_Methods_SetupRemoteDeviceFailed() {
+	s.mockShipInfo.EXPECT().HandleShipHandshakeCompleted(s.sut).Return().Once()
+	s.mockShipInfo.EXPECT().SetupRemoteDevice(mock.Anything, mock.Anything).Return(nil, errors.New("setup failed"))
+	s.sut.setState(model.SmeAccessMethodsRequest, nil)
+
+	// a SPINE message received before the handshake completed
+	err := s.sut.bufferSpineMessage([]byte(`{"datagram":{}}`))
+	assert.Nil(s.T(), err)
+
+	accessMsg := model.AccessMethods{
+		AccessMethods: model.AccessMethodsType{
+			Id: util.Ptr("RemoteShipID"),
+		},
+	}
+	msg, err := s.sut.shipMessage(model.MsgTypeControl, accessMsg)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), msg)
+
+	// the buffered message is not processed without a data reader
+	assert.NotPanics(s.T(), func() { s.sut.handleState(false, msg) })
+
+	assert.Equal(s.T(), false, s.sut.handshakeTimerRunning)
+	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
+	assert.Nil(s.T(), s.sut.dataReader)
+	s.mockWSWrite.AssertCalled(s.T(), "CloseDataConnection", mock.Anything, mock.Anything)
+}
+
 func (s *AccessSuite) Test_Methods_NoID() {
 	s.sut.setState(model.SmeAccessMethodsRequest, nil)
 
@@ -172,12 +203,21 @@ func (s *AccessSuite) Test_Methods_WrongShipID() {
 	assert.Equal(s.T(), false, s.sut.handshakeTimerRunning)
 	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
 	assert.Nil(s.T(), s.lastMessage())
+	s.mockWSWrite.AssertCalled(s.T(), "CloseDataConnection", int(api.ShipCloseCodeShipIDMismatch), mock.Anything)
+	assert.Equal(s.T(), api.ShipCloseCodeShipIDMismatch, s.sut.CloseDetails().Code)
+
+	var mismatchErr *api.ShipIDMismatchError
+	assert.ErrorAs(s.T(), s.sut.getStateError(), &mismatchErr)
+	assert.Equal(s.T(), "RemoteShipID", mismatchErr.Expected)
+	assert.Equal(s.T(), "WrongRemoteShipID", mismatchErr.Actual)
+	assert.ErrorIs(s.T(), s.sut.getStateError(), api.ErrShipIDMismatch)
 }
 
 func (s *AccessSuite) Test_Methods_NoShipID() {
 	reader := mocks.NewShipConnectionDataReaderInterface(s.T())
 	s.mockShipInfo.EXPECT().ReportServiceShipID(mock.Anything, mock.Anything)
-	s.mockShipInfo.EXPECT().SetupRemoteDevice(mock.Anything, mock.Anything).Return(reader)
+	s.mockShipInfo.EXPECT().HandleShipHandshakeCompleted(s.sut).Return().Once()
+	s.mockShipInfo.EXPECT().SetupRemoteDevice(mock.Anything, mock.Anything).Return(reader, nil)
 	s.sut.remoteShipID = ""
 
 	s.sut.setState(model.SmeAccessMethodsRequest, nil)
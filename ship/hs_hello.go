@@ -1,8 +1,10 @@
 package ship
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/model"
 	"github.com/enbility/ship-go/util"
@@ -29,7 +31,7 @@ func (c *ShipConnection) handshakeHello_ReadyListen(timeout bool, message []byte
 
 	var helloReturnMsg model.ConnectionHello
 	if err := c.processShipJsonMessage(message, &helloReturnMsg); err != nil {
-		c.setAndHandleState(model.SmeHelloStateAbort)
+		c.setAndHandleStateWithError(model.SmeHelloStateAbort, fmt.Errorf("%w: %w", api.ErrInvalidHandshakeMessage, err))
 		return
 	}
 
@@ -64,14 +66,15 @@ func (c *ShipConnection) handshakeHello_ReadyListen(timeout bool, message []byte
 		return
 
 	case model.ConnectionHelloPhaseTypeAborted:
-		c.setAndHandleState(model.SmeHelloStateRemoteAbortDone)
+		c.setAndHandleStateWithError(model.SmeHelloStateRemoteAbortDone, api.ErrRemoteAbort)
 
 		return
 
 	default:
 		// don't accept any other responses
 		logging.Log().Errorf("Unexpected connection hello phase: %s", hello.Phase)
-		c.setAndHandleState(model.SmeHelloStateAbort)
+		c.setAndHandleStateWithError(model.SmeHelloStateAbort,
+			fmt.Errorf("%w: unexpected connection hello phase %s", api.ErrInvalidHandshakeMessage, hello.Phase))
 		return
 	}
 
@@ -79,7 +82,7 @@ func (c *ShipConnection) handshakeHello_ReadyListen(timeout bool, message []byte
 }
 
 func (c *ShipConnection) handshakeHello_ReadyTimeout() {
-	c.setAndHandleState(model.SmeHelloStateAbort)
+	c.setAndHandleStateWithError(model.SmeHelloStateAbort, &api.HandshakeTimeoutError{Phase: api.HandshakePhaseHello})
 }
 
 // SME_HELLO_ABORT
@@ -91,7 +94,8 @@ func (c *ShipConnection) handshakeHello_Abort() {
 		return
 	}
 
-	c.setAndHandleState(model.SmeHelloStateAbortDone)
+	// keep the reason of the abort
+	c.setAndHandleStateWithError(model.SmeHelloStateAbortDone, c.getStateError())
 }
 
 // SME_HELLO_PENDING_INIT
@@ -124,7 +128,7 @@ func (c *ShipConnection) handshakeHello_PendingListen(timeout bool, message []by
 
 	var helloReturnMsg model.ConnectionHello
 	if err := c.processShipJsonMessage(message, &helloReturnMsg); err != nil {
-		c.setAndHandleState(model.SmeHelloStateAbort)
+		c.setAndHandleStateWithError(model.SmeHelloStateAbort, fmt.Errorf("%w: %w", api.ErrInvalidHandshakeMessage, err))
 		return
 	}
 
@@ -199,13 +203,14 @@ func (c *ShipConnection) handshakeHello_PendingListen(timeout bool, message []by
 		c.setAndHandleState(model.SmeHelloStateAbort)
 
 	case model.ConnectionHelloPhaseTypeAborted:
-		c.setAndHandleState(model.SmeHelloStateRemoteAbortDone)
+		c.setAndHandleStateWithError(model.SmeHelloStateRemoteAbortDone, api.ErrRemoteAbort)
 		return
 
 	default:
 		// don't accept any other responses
 		logging.Log().Errorf("Unexpected connection hello phase: %s", hello.Phase)
-		c.setAndHandleState(model.SmeHelloStateAbort)
+		c.setAndHandleStateWithError(model.SmeHelloStateAbort,
+			fmt.Errorf("%w: unexpected connection hello phase %s", api.ErrInvalidHandshakeMessage, hello.Phase))
 		return
 	}
 
@@ -224,7 +229,7 @@ func (c *ShipConnection) handshakeHello_PendingProlongationRequest() {
 
 func (c *ShipConnection) handshakeHello_PendingTimeout() {
 	if c.getHandshakeTimerType() != timeoutTimerTypeSendProlongationRequest {
-		c.setAndHandleState(model.SmeHelloStateAbort)
+		c.setAndHandleStateWithError(model.SmeHelloStateAbort, &api.HandshakeTimeoutError{Phase: api.HandshakePhaseHello})
 		return
 	}
 
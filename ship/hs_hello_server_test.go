@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
 	"github.com/enbility/ship-go/util"
@@ -24,7 +26,8 @@ type HelloSuite struct {
 	mockWSWrite  *mocks.WebsocketDataWriterInterface
 	mockShipInfo *mocks.ShipConnectionInfoProviderInterface
 
-	sut *ShipConnection
+	sut   *ShipConnection
+	clock *clocktest.FakeClock
 
 	sentMessage     []byte
 	wsReturnFailure error
@@ -77,11 +80,14 @@ func (s *HelloSuite) BeforeTest(suiteName, testName string) {
 		Maybe()
 
 	s.mockShipInfo = mocks.NewShipConnectionInfoProviderInterface(s.T())
+	s.mockShipInfo.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDefault).Maybe()
 	s.mockShipInfo.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
 	s.mockShipInfo.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
 	s.mockShipInfo.EXPECT().IsAutoAcceptEnabled().Return(false).Maybe()
 
+	s.clock = clocktest.NewFakeClock(time.Now())
 	s.sut = NewConnectionHandler(s.mockShipInfo, s.mockWSWrite, ShipRoleServer, "LocalShipID", "RemoveDevice", "RemoteShipID")
+	s.sut.SetClock(s.clock)
 }
 
 func (s *HelloSuite) AfterTest(suiteName, testName string) {
@@ -148,13 +154,9 @@ func (s *HelloSuite) Test_ReadyListen_Timeout() {
 	s.sut.setState(model.SmeHelloStateReadyInit, nil) // inits the timer
 	s.sut.setState(model.SmeHelloStateReadyListen, nil)
 
-	if !util.IsRunningOnCI() {
-		// test if the function is triggered correctly via the timer
-		time.Sleep(tHelloInit + time.Second)
-	} else {
-		// speed up the test by running the method directly
-		s.sut.handshakeHello_ReadyListen(true, nil)
-	}
+	// test if the function is triggered correctly via the timer
+	s.clock.Advance(tHelloInit)
+	s.sut.waitForEvents()
 
 	assert.Equal(s.T(), model.SmeHelloStateAbortDone, s.sut.getState())
 	assert.NotNil(s.T(), s.lastMessage())
@@ -239,6 +241,7 @@ func (s *HelloSuite) Test_ReadyListen_Abort() {
 
 	assert.Equal(s.T(), false, s.sut.getHandshakeTimerRunning())
 	assert.Equal(s.T(), model.SmeHelloStateRemoteAbortDone, s.sut.getState())
+	assert.ErrorIs(s.T(), s.sut.getStateError(), api.ErrRemoteAbort)
 	assert.Nil(s.T(), s.lastMessage())
 }
 
@@ -295,13 +298,9 @@ func (s *HelloSuite) Test_PendingListen_Timeout() {
 	s.sut.setState(model.SmeHelloStatePendingInit, nil) // inits the timer
 	s.sut.setState(model.SmeHelloStatePendingListen, nil)
 
-	if !util.IsRunningOnCI() {
-		// test if the function is triggered correctly via the timer
-		time.Sleep(tHelloInit + time.Second)
-	} else {
-		// speed up the test by running the method directly
-		s.sut.handshakeHello_PendingListen(true, nil)
-	}
+	// test if the function is triggered correctly via the timer
+	s.clock.Advance(tHelloInit)
+	s.sut.waitForEvents()
 
 	assert.Equal(s.T(), model.SmeHelloStateAbortDone, s.sut.getState())
 	assert.NotNil(s.T(), s.lastMessage())
@@ -344,11 +343,18 @@ func (s *HelloSuite) Test_PendingListen_Timeout_Prolongation() {
 	s.sut.setState(model.SmeHelloStatePendingInit, nil) // inits the timer
 	s.sut.setState(model.SmeHelloStatePendingListen, nil)
 
-	// speed up the test by running the method directly, the timer is already checked
-	s.sut.handshakeHello_PendingListen(true, nil)
+	s.clock.Advance(tHelloInit)
+	s.sut.waitForEvents()
 
 	assert.Equal(s.T(), model.SmeHelloStatePendingListen, s.sut.getState())
 	assert.NotNil(s.T(), s.lastMessage())
+	assert.Equal(s.T(), true, s.sut.getHandshakeTimerRunning())
+	assert.Equal(s.T(), timeoutTimerTypeProlongRequestReply, s.sut.getHandshakeTimerType())
+
+	// the timeout is not reached before the full duration elapsed
+	s.clock.Advance(tHelloInit - time.Second)
+	s.sut.waitForEvents()
+	assert.Equal(s.T(), true, s.sut.getHandshakeTimerRunning())
 }
 
 func (s *HelloSuite) Test_PendingListen_Timeout_Prolongation_Failure() {
@@ -485,3 +491,33 @@ func (s *HelloSuite) Test_HelloSend_Failure() {
 	err := s.sut.handshakeHelloSend(model.ConnectionHelloPhaseTypeAborted, 0, false)
 	assert.NotNil(s.T(), err)
 }
+
+func (s *HelloSuite) Test_InitialState_PairingPolicyDeny() {
+	infoProvider := mocks.NewShipConnectionInfoProviderInterface(s.T())
+	infoProvider.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDeny)
+	infoProvider.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
+	infoProvider.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
+	s.sut.infoProvider = infoProvider
+
+	s.sut.setState(model.SmeHelloState, nil)
+	s.sut.handleState(false, nil)
+
+	assert.Equal(s.T(), false, s.sut.getHandshakeTimerRunning())
+	assert.Equal(s.T(), model.SmeHelloStateAbortDone, s.sut.getState())
+	assert.NotNil(s.T(), s.lastMessage())
+}
+
+func (s *HelloSuite) Test_InitialState_PairingPolicyAccept() {
+	infoProvider := mocks.NewShipConnectionInfoProviderInterface(s.T())
+	infoProvider.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionAccept)
+	infoProvider.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
+	infoProvider.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
+	s.sut.infoProvider = infoProvider
+
+	s.sut.setState(model.SmeHelloState, nil)
+	s.sut.handleState(false, nil)
+
+	assert.Equal(s.T(), true, s.sut.getHandshakeTimerRunning())
+	assert.Equal(s.T(), model.SmeHelloStateReadyListen, s.sut.getState())
+	assert.NotNil(s.T(), s.lastMessage())
+}
@@ -3,6 +3,7 @@ package ship
 import (
 	"fmt"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/model"
 )
 
@@ -60,11 +61,11 @@ func (c *ShipConnection) handshakeInit_cmiStateEvaluate(message []byte) bool {
 	msgType, data := c.parseMessage(message, false)
 
 	if msgType != model.MsgTypeInit {
-		c.endHandshakeWithError(fmt.Errorf("Invalid SHIP MessageType, expected 0 and got %s", string(msgType)))
+		c.endHandshakeWithError(fmt.Errorf("%w: invalid SHIP MessageType, expected 0 and got %s", api.ErrInvalidHandshakeMessage, string(msgType)))
 		return false
 	}
 	if len(data) > 0 && data[0] != byte(0) {
-		c.endHandshakeWithError(fmt.Errorf("Invalid SHIP MessageValue, expected 0 and got %s", string(data)))
+		c.endHandshakeWithError(fmt.Errorf("%w: invalid SHIP MessageValue, expected 0 and got %s", api.ErrInvalidHandshakeMessage, string(data)))
 		return false
 	}
 
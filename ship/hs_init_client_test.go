@@ -4,6 +4,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
 	"github.com/stretchr/testify/assert"
@@ -67,6 +68,7 @@ func (s *InitClientSuite) BeforeTest(suiteName, testName string) {
 		Maybe()
 
 	s.mockShipInfo = mocks.NewShipConnectionInfoProviderInterface(s.T())
+	s.mockShipInfo.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDefault).Maybe()
 	s.mockShipInfo.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
 	s.mockShipInfo.EXPECT().IsRemoteServiceForSKIPaired(mock.Anything).Return(true).Maybe()
 	s.mockShipInfo.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
@@ -112,6 +114,10 @@ func (s *InitClientSuite) Test_ClientWait_Timeout() {
 
 	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
 	assert.Nil(s.T(), s.lastMessage())
+
+	var timeoutErr *api.HandshakeTimeoutError
+	assert.ErrorAs(s.T(), s.sut.getStateError(), &timeoutErr)
+	assert.Equal(s.T(), api.HandshakePhaseInit, timeoutErr.Phase)
 }
 
 func (s *InitClientSuite) Test_ClientWait_InvalidMsgType() {
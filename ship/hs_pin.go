@@ -2,8 +2,9 @@ package ship
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/model"
 )
 
@@ -31,20 +32,17 @@ func (c *ShipConnection) handshakePin_smePinStateCheckListen(message []byte) {
 
 	var connectionPinState model.ConnectionPinState
 	if err := json.Unmarshal([]byte(data), &connectionPinState); err != nil {
-		c.endHandshakeWithError(err)
+		c.endHandshakeWithError(fmt.Errorf("%w: %w", api.ErrInvalidHandshakeMessage, err))
 		return
 	}
 
-	switch connectionPinState.ConnectionPinState.PinState {
+	switch pinState := connectionPinState.ConnectionPinState.PinState; pinState {
 	case model.PinStateTypeNone:
 		c.setAndHandleState(model.SmePinStateCheckOk)
-	case model.PinStateTypeRequired:
-		c.endHandshakeWithError(errors.New("Got pin state: required (unsupported)"))
-	case model.PinStateTypeOptional:
-		c.endHandshakeWithError(errors.New("Got pin state: optional (unsupported)"))
-	case model.PinStateTypePinOk:
-		c.endHandshakeWithError(errors.New("Got pin state: ok (unsupported)"))
+	case model.PinStateTypeRequired, model.PinStateTypeOptional, model.PinStateTypePinOk:
+		// PIN verification is not supported
+		c.endHandshakeWithError(&api.PinStateError{State: pinState})
 	default:
-		c.endHandshakeWithError(errors.New("Got invalid pin state"))
+		c.endHandshakeWithError(fmt.Errorf("%w: invalid pin state %s", api.ErrInvalidHandshakeMessage, pinState))
 	}
 }
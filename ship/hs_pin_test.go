@@ -5,6 +5,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
 	"github.com/stretchr/testify/assert"
@@ -75,6 +76,7 @@ func (s *PinSuite) BeforeTest(suiteName, testName string) {
 		Maybe()
 
 	s.mockShipInfo = mocks.NewShipConnectionInfoProviderInterface(s.T())
+	s.mockShipInfo.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDefault).Maybe()
 	s.mockShipInfo.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
 	s.mockShipInfo.EXPECT().IsRemoteServiceForSKIPaired(mock.Anything).Return(true).Maybe()
 	s.mockShipInfo.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
@@ -150,6 +152,7 @@ func (s *PinSuite) Test_CheckListen_Required() {
 	assert.Equal(s.T(), false, s.sut.handshakeTimerRunning)
 	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
 	assert.Nil(s.T(), s.lastMessage())
+	assert.ErrorIs(s.T(), s.sut.getStateError(), api.ErrPinRequired)
 }
 
 func (s *PinSuite) Test_CheckListen_Optional() {
@@ -169,6 +172,10 @@ func (s *PinSuite) Test_CheckListen_Optional() {
 	assert.Equal(s.T(), false, s.sut.handshakeTimerRunning)
 	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
 	assert.Nil(s.T(), s.lastMessage())
+	var pinErr *api.PinStateError
+	assert.ErrorAs(s.T(), s.sut.getStateError(), &pinErr)
+	assert.Equal(s.T(), model.PinStateTypeOptional, pinErr.State)
+	assert.NotErrorIs(s.T(), s.sut.getStateError(), api.ErrPinRequired)
 }
 
 func (s *PinSuite) Test_CheckListen_Ok() {
@@ -207,4 +214,5 @@ func (s *PinSuite) Test_CheckListen_Invalid() {
 	assert.Equal(s.T(), false, s.sut.handshakeTimerRunning)
 	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
 	assert.Nil(s.T(), s.lastMessage())
+	assert.ErrorIs(s.T(), s.sut.getStateError(), api.ErrInvalidHandshakeMessage)
 }
@@ -2,8 +2,9 @@ package ship
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/model"
 )
@@ -41,12 +42,12 @@ func (c *ShipConnection) handshakeProtocol_smeProtHStateServerListenProposal(mes
 
 	messageProtocolHandshake := model.MessageProtocolHandshake{}
 	if err := json.Unmarshal([]byte(data), &messageProtocolHandshake); err != nil {
-		c.endHandshakeWithError(err)
+		c.endHandshakeWithError(fmt.Errorf("%w: %w", api.ErrInvalidHandshakeMessage, err))
 		return
 	}
 
 	if messageProtocolHandshake.MessageProtocolHandshake.HandshakeType != model.ProtocolHandshakeTypeTypeAnnounceMax {
-		c.endHandshakeWithError(errors.New("Invalid protocol handshake request"))
+		c.endHandshakeWithError(fmt.Errorf("%w: invalid protocol handshake request", api.ErrInvalidHandshakeMessage))
 		return
 	}
 
@@ -70,18 +71,24 @@ func (c *ShipConnection) handshakeProtocol_smeProtHStateServerListenConfirm(mess
 	var messageProtocolHandshake model.MessageProtocolHandshake
 	if err := json.Unmarshal([]byte(data), &messageProtocolHandshake); err != nil {
 		logging.Log().Debug(err)
-		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeUnexpectedMessage)
+		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeUnexpectedMessage,
+			fmt.Errorf("%w: %w", api.ErrInvalidHandshakeMessage, err))
 		return
 	}
 
 	if messageProtocolHandshake.MessageProtocolHandshake.HandshakeType != model.ProtocolHandshakeTypeTypeSelect {
 		logging.Log().Debug("invalid protocol handshake response")
-		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeSelectionMismatch)
+		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeSelectionMismatch,
+			c.protocolMismatchError(messageProtocolHandshake.MessageProtocolHandshake))
 		return
 	}
 
 	c.stopHandshakeTimer()
 
+	// the server selected its own version and format
+	selected := c.protocolHandshake().MessageProtocolHandshake
+	c.setProtocol(selected.Version, selected.Formats.Format[0])
+
 	c.setAndHandleState(model.SmeProtHStateServerOk)
 }
 
@@ -105,7 +112,8 @@ func (c *ShipConnection) handshakeProtocol_smeProtHStateClientListenChoice(messa
 	messageProtocolHandshake := model.MessageProtocolHandshake{}
 	if err := json.Unmarshal([]byte(data), &messageProtocolHandshake); err != nil {
 		logging.Log().Debug(err)
-		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeUnexpectedMessage)
+		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeUnexpectedMessage,
+			fmt.Errorf("%w: %w", api.ErrInvalidHandshakeMessage, err))
 		return
 	}
 
@@ -143,7 +151,7 @@ func (c *ShipConnection) handshakeProtocol_smeProtHStateClientListenChoice(messa
 	}
 
 	if abort {
-		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeSelectionMismatch)
+		c.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeSelectionMismatch, c.protocolMismatchError(msgHandshake))
 		return
 	}
 
@@ -157,19 +165,30 @@ func (c *ShipConnection) handshakeProtocol_smeProtHStateClientListenChoice(messa
 		return
 	}
 
+	c.setProtocol(msgHandshake.Version, msgHandshake.Formats.Format[0])
+
 	c.setAndHandleState(model.SmeProtHStateClientOk)
 }
 
-func (c *ShipConnection) abortProtocolHandshake(err model.MessageProtocolHandshakeErrorErrorType) {
+// return the error for a protocol handshake message which can not be accepted
+func (c *ShipConnection) protocolMismatchError(selected model.MessageProtocolHandshakeType) error {
+	return &api.ProtocolMismatchError{
+		Offered:  c.protocolHandshake().MessageProtocolHandshake.Version,
+		Selected: selected.Version,
+		Formats:  selected.Formats.Format,
+	}
+}
+
+func (c *ShipConnection) abortProtocolHandshake(errType model.MessageProtocolHandshakeErrorErrorType, err error) {
 	c.stopHandshakeTimer()
 
 	msg := model.MessageProtocolHandshakeError{
-		Error: err,
+		Error: errType,
 	}
 
 	_ = c.sendShipModel(model.MsgTypeControl, msg)
 
-	c.setState(model.SmeStateError, errors.New("handshake error"))
+	c.setState(model.SmeStateError, err)
 
-	c.CloseConnection(false, 0, "")
+	c.closeConnection(false, api.ShipCloseCodeNone, "")
 }
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/enbility/ship-go/api"
 	"github.com/enbility/ship-go/mocks"
 	"github.com/enbility/ship-go/model"
 	"github.com/stretchr/testify/assert"
@@ -67,6 +68,7 @@ func (s *ProClientSuite) BeforeTest(suiteName, testName string) {
 		Maybe()
 
 	s.mockShipInfo = mocks.NewShipConnectionInfoProviderInterface(s.T())
+	s.mockShipInfo.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDefault).Maybe()
 	s.mockShipInfo.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
 	s.mockShipInfo.EXPECT().IsRemoteServiceForSKIPaired(mock.Anything).Return(true).Maybe()
 	s.mockShipInfo.EXPECT().HandleConnectionClosed(mock.Anything, mock.Anything).Return().Maybe()
@@ -112,6 +114,9 @@ func (s *ProClientSuite) Test_ListenChoice() {
 	// state goes directly from smeProtHStateClientOk to smePinStateCheckInit to smePinStateCheckListen
 	assert.Equal(s.T(), model.SmePinStateCheckListen, s.sut.getState())
 	assert.NotNil(s.T(), s.lastMessage())
+
+	assert.Equal(s.T(), model.Version{Major: 1, Minor: 0}, s.sut.protocolVersion)
+	assert.Equal(s.T(), model.MessageProtocolFormatTypeUTF8, s.sut.protocolFormat)
 }
 
 func (s *ProClientSuite) Test_ListenChoice_Failures() {
@@ -152,14 +157,21 @@ func (s *ProClientSuite) Test_ListenChoice_Failures() {
 
 	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
 	assert.NotNil(s.T(), s.lastMessage())
+
+	var mismatchErr *api.ProtocolMismatchError
+	assert.ErrorAs(s.T(), s.sut.getStateError(), &mismatchErr)
+	assert.Equal(s.T(), model.Version{Major: 1, Minor: 0}, mismatchErr.Offered)
+	assert.Equal(s.T(), model.Version{Major: 0, Minor: 1}, mismatchErr.Selected)
+	assert.ErrorIs(s.T(), s.sut.getStateError(), api.ErrProtocolMismatch)
 }
 
 func (s *ProClientSuite) Test_Abort() {
 	s.sut.setState(model.SmeProtHStateClientListenChoice, nil)
 
-	s.sut.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeTimeout)
+	s.sut.abortProtocolHandshake(model.MessageProtocolHandshakeErrorErrorTypeTimeout, &api.HandshakeTimeoutError{Phase: api.HandshakePhaseProtocol})
 
 	assert.Equal(s.T(), model.SmeStateError, s.sut.getState())
+	assert.ErrorIs(s.T(), s.sut.getStateError(), api.ErrHandshakeTimeout)
 	assert.NotNil(s.T(), s.lastMessage())
 
 	timer := s.sut.getHandshakeTimerRunning()
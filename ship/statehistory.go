@@ -0,0 +1,89 @@
+package ship
+
+import (
+	"encoding/json"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/model"
+)
+
+// the maximum number of recorded state transitions per connection, older ones are dropped
+const stateHistorySize = 100
+
+// set the event which triggers the following state transitions
+func (c *ShipConnection) setStateTrigger(trigger api.ShipStateTrigger) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.stateTrigger = trigger
+}
+
+// record a state transition
+//
+// needs to be called with mux locked
+func (c *ShipConnection) recordStateTransition(from, to model.ShipMessageExchangeState, err error) {
+	transition := api.ShipStateTransition{
+		Time:    c.getClock().Now(),
+		From:    from,
+		To:      to,
+		Trigger: c.stateTrigger,
+		Error:   err,
+	}
+
+	if len(c.stateHistory) >= stateHistorySize {
+		c.stateHistory[0] = api.ShipStateTransition{}
+		c.stateHistory = c.stateHistory[1:]
+		c.stateHistoryDropped++
+	}
+
+	c.stateHistory = append(c.stateHistory, transition)
+}
+
+// return the recorded state transitions of this connection
+func (c *ShipConnection) StateHistory() api.ShipConnectionHistory {
+	c.mux.Lock()
+	transitions := make([]api.ShipStateTransition, len(c.stateHistory))
+	copy(transitions, c.stateHistory)
+	dropped := c.stateHistoryDropped
+	c.mux.Unlock()
+
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+
+	history := api.ShipConnectionHistory{
+		RemoteSKI:          c.remoteSKI,
+		Role:               api.ShipRole(c.role),
+		ConnectedSince:     c.connectedSince,
+		ClosedAt:           c.closedAt,
+		Transitions:        transitions,
+		DroppedTransitions: dropped,
+	}
+	if c.closeDetails != nil {
+		history.CloseDetails = *c.closeDetails
+	}
+
+	return history
+}
+
+// return the trigger for a received SHIP message, which is its message type
+func (c *ShipConnection) shipMessageTrigger(message []byte) api.ShipStateTrigger {
+	if len(message) == 0 {
+		return api.ShipStateTriggerNone
+	}
+
+	if message[0] == model.MsgTypeInit {
+		return api.ShipStateTriggerInit
+	}
+
+	// SHIP messages contain a single element named by the message type
+	var data map[string]json.RawMessage
+	if err := c.processShipJsonMessage(message, &data); err != nil {
+		return api.ShipStateTriggerNone
+	}
+
+	for messageType := range data {
+		return api.ShipStateTrigger(messageType)
+	}
+
+	return api.ShipStateTriggerNone
+}
@@ -0,0 +1,109 @@
+package ship
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
+	"github.com/enbility/ship-go/mocks"
+	"github.com/enbility/ship-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_StateHistory(t *testing.T) {
+	infoProvider := mocks.NewShipConnectionInfoProviderInterface(t)
+	infoProvider.EXPECT().IsRemoteServiceForSKIPaired(mock.Anything).Return(true).Maybe()
+	infoProvider.EXPECT().PairingDecisionForSKI(mock.Anything).Return(api.PairingDecisionDefault).Maybe()
+	infoProvider.EXPECT().HandleShipHandshakeStateUpdate(mock.Anything, mock.Anything).Return().Maybe()
+
+	wsDataWriter := mocks.NewWebsocketDataWriterInterface(t)
+	wsDataWriter.EXPECT().InitDataProcessing(mock.Anything).Return().Maybe()
+	wsDataWriter.EXPECT().IsDataConnectionClosed().Return(false, nil).Maybe()
+	wsDataWriter.EXPECT().WriteMessageToWebsocketConnection(mock.Anything).Return(nil).Maybe()
+
+	clock := clocktest.NewFakeClock(time.Now())
+	sut := NewConnectionHandler(infoProvider, wsDataWriter, ShipRoleServer, "LocalShipID", "RemoteDevice", "RemoteShipID")
+	sut.SetClock(clock)
+
+	sut.Run()
+	sut.HandleIncomingWebsocketMessage(model.ShipInit)
+	sut.waitForEvents()
+
+	clock.Advance(tHelloInit)
+	sut.waitForEvents()
+
+	history := sut.StateHistory()
+	assert.Equal(t, "RemoteDevice", history.RemoteSKI)
+	assert.Equal(t, api.ShipRoleServer, history.Role)
+	assert.True(t, history.ClosedAt.IsZero())
+
+	transitions := history.Transitions
+	if !assert.LessOrEqual(t, 3, len(transitions)) {
+		return
+	}
+
+	assert.Equal(t, model.CmiStateInitStart, transitions[0].From)
+	assert.Equal(t, model.CmiStateServerWait, transitions[0].To)
+	assert.Equal(t, api.ShipStateTriggerStart, transitions[0].Trigger)
+
+	assert.Equal(t, model.CmiStateServerWait, transitions[1].From)
+	assert.Equal(t, api.ShipStateTriggerInit, transitions[1].Trigger)
+
+	// the hello phase is aborted as the remote service did not respond in time
+	last := transitions[len(transitions)-1]
+	assert.Equal(t, api.ShipStateTriggerTimeout, last.Trigger)
+	assert.Equal(t, clock.Now(), last.Time)
+
+	var timeoutErr *api.HandshakeTimeoutError
+	found := false
+	for _, transition := range transitions {
+		if errors.As(transition.Error, &timeoutErr) {
+			found = true
+			assert.Equal(t, api.ShipStateTriggerTimeout, transition.Trigger)
+		}
+	}
+	assert.True(t, found)
+}
+
+func Test_StateHistory_Bounded(t *testing.T) {
+	sut := newEventTestConnection(t)
+
+	for i := 0; i < stateHistorySize+5; i++ {
+		state := model.SmeHelloStateOk
+		if i%2 == 1 {
+			state = model.SmeStateApproved
+		}
+		sut.setState(state, nil)
+	}
+
+	history := sut.StateHistory()
+	assert.Equal(t, stateHistorySize, len(history.Transitions))
+	assert.Equal(t, uint64(5), history.DroppedTransitions)
+	assert.Equal(t, model.SmeHelloStateOk, history.Transitions[stateHistorySize-1].To)
+}
+
+func Test_StateHistory_Closed(t *testing.T) {
+	sut := newEventTestConnection(t)
+
+	sut.CloseConnection(false, api.ShipCloseCodeNone, "")
+	sut.waitForEvents()
+
+	history := sut.StateHistory()
+	assert.False(t, history.ClosedAt.IsZero())
+	assert.Equal(t, api.ShipCloseCodeNormal, history.CloseDetails.Code)
+}
+
+func Test_ShipMessageTrigger(t *testing.T) {
+	sut := newEventTestConnection(t)
+
+	assert.Equal(t, api.ShipStateTriggerNone, sut.shipMessageTrigger(nil))
+	assert.Equal(t, api.ShipStateTriggerInit, sut.shipMessageTrigger(model.ShipInit))
+	assert.Equal(t, api.ShipStateTriggerNone, sut.shipMessageTrigger([]byte{model.MsgTypeControl, '{'}))
+
+	hello := []byte(`{"connectionHello":[{"phase":"ready"}]}`)
+	msg := append([]byte{model.MsgTypeControl}, hello...)
+	assert.Equal(t, api.ShipStateTrigger("connectionHello"), sut.shipMessageTrigger(msg))
+}
@@ -14,7 +14,8 @@ const (
 const (
 	cmiTimeout              = 10 * time.Second // SHIP 4.2
 	cmiCloseTimeout         = 100 * time.Millisecond
-	tHelloInit              = 60 * time.Second // SHIP 13.4.4.1.3
+	cmiCloseMaxTime         = 500 * time.Millisecond // SHIP 13.4.7: the time announced to wait for the termination confirmation
+	tHelloInit              = 60 * time.Second       // SHIP 13.4.4.1.3
 	tHelloInc               = 60 * time.Second
 	tHelloProlongThrInc     = 30 * time.Second
 	tHelloProlongWaitingGap = 15 * time.Second
@@ -0,0 +1,58 @@
+package ws
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// token bucket limiting the rate of incoming messages
+type rateLimiter struct {
+	// the number of tokens added per second
+	rate float64
+
+	// the maximum number of tokens
+	burst float64
+
+	// the currently available tokens
+	tokens float64
+
+	// the last time tokens were added
+	last time.Time
+
+	mux sync.Mutex
+}
+
+// create a new rate limiter allowing rate messages per second
+// and bursts of up to burst messages
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	burstValue := float64(burst)
+	if burstValue < 1 {
+		burstValue = math.Max(1, math.Ceil(rate))
+	}
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  burstValue,
+		tokens: burstValue,
+	}
+}
+
+// returns true if another message is allowed at the given time
+func (r *rateLimiter) allow(now time.Time) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if !r.last.IsZero() {
+		elapsed := now.Sub(r.last).Seconds()
+		r.tokens = math.Min(r.burst, r.tokens+elapsed*r.rate)
+	}
+	r.last = now
+
+	if r.tokens < 1 {
+		return false
+	}
+
+	r.tokens--
+	return true
+}
@@ -0,0 +1,48 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestRateLimiterSuite(t *testing.T) {
+	suite.Run(t, new(RateLimiterSuite))
+}
+
+type RateLimiterSuite struct {
+	suite.Suite
+}
+
+func (s *RateLimiterSuite) Test_Allow() {
+	sut := newRateLimiter(2, 3)
+	now := time.Now()
+
+	// the burst is available right away
+	assert.True(s.T(), sut.allow(now))
+	assert.True(s.T(), sut.allow(now))
+	assert.True(s.T(), sut.allow(now))
+	assert.False(s.T(), sut.allow(now))
+
+	// 2 messages per second
+	now = now.Add(500 * time.Millisecond)
+	assert.True(s.T(), sut.allow(now))
+	assert.False(s.T(), sut.allow(now))
+
+	// never more than the burst
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.True(s.T(), sut.allow(now))
+	}
+	assert.False(s.T(), sut.allow(now))
+}
+
+func (s *RateLimiterSuite) Test_DefaultBurst() {
+	sut := newRateLimiter(0.5, 0)
+	assert.Equal(s.T(), float64(1), sut.burst)
+
+	sut = newRateLimiter(10, 0)
+	assert.Equal(s.T(), float64(10), sut.burst)
+}
@@ -12,4 +12,7 @@ const (
 
 	// SHIP 9.2: Set maximum fragment length to 1024 bytes
 	MaxMessageSize = 1024
+
+	// The default number of outgoing messages that can be queued for each priority
+	DefaultWriteQueueSize = 100
 )
@@ -2,12 +2,16 @@ package ws
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock"
 	"github.com/enbility/ship-go/logging"
 	"github.com/enbility/ship-go/model"
 	"github.com/gorilla/websocket"
@@ -15,6 +19,29 @@ import (
 
 const connIsClosedError string = "connection is closed"
 
+// the maximum length of the reason in a websocket close frame, RFC 6455 5.5
+const maxCloseReasonLength = 123
+
+// an outgoing message and the optional channel to report the write result to
+type writeRequest struct {
+	message []byte
+
+	// the websocket message type, a binary message if 0
+	messageType int
+
+	// buffered channel receiving the result once the message was written
+	result chan error
+}
+
+// report the write result if it was requested
+func (r writeRequest) reportResult(err error) {
+	if r.result == nil {
+		return
+	}
+
+	r.result <- err
+}
+
 // Handling of the actual websocket connection to a remote device
 type WebsocketConnection struct {
 	// The actual websocket connection
@@ -26,8 +53,22 @@ type WebsocketConnection struct {
 	// The connection was closed
 	closeChannel chan struct{}
 
-	// The ship write channel for outgoing SHIP messages
-	shipWriteChannel chan []byte
+	// The write channel for outgoing SHIP control messages (init, control)
+	// these have priority over SPINE data messages
+	controlWriteChannel chan writeRequest
+
+	// The write channel for outgoing SPINE data messages and SHIP end messages,
+	// so a connection close is sent after the data queued before it
+	dataWriteChannel chan writeRequest
+
+	// The maximum number of queued messages per write channel
+	writeQueueSize int
+
+	// the highest number of queued messages observed
+	writeQueueMaxQueued int
+
+	// the number of messages rejected because the write queue was full
+	writeQueueRejected uint64
 
 	// internal handling of closed connections
 	connectionClosed bool
@@ -37,8 +78,27 @@ type WebsocketConnection struct {
 
 	remoteSki string
 
+	// limits the rate of incoming messages, nil if not limited
+	readRateLimiter *rateLimiter
+
+	// the source of the current time and the ping ticker
+	//
+	// the deadlines of the network connection always use the system time
+	clock api.ClockInterface
+
+	// the statistics of the transferred messages
+	bytesIn, bytesOut, messagesIn, messagesOut uint64
+
+	// the time the last ping was sent, zero if it was answered
+	lastPingSent time.Time
+
+	// the round trip time of the last answered ping
+	lastPingRTT time.Duration
+
 	muxConnClosed sync.Mutex
+	muxStats      sync.Mutex
 	muxShipWrite  sync.Mutex
+	muxQueueStats sync.Mutex
 	muxConWrite   sync.Mutex
 	shutdownOnce  sync.Once
 }
@@ -49,7 +109,43 @@ func NewWebsocketConnection(conn *websocket.Conn, remoteSki string) *WebsocketCo
 		conn:                  conn,
 		remoteSki:             remoteSki,
 		connectionClosedError: nil,
+		writeQueueSize:        DefaultWriteQueueSize,
+		clock:                 clock.NewSystemClock(),
+	}
+}
+
+// set the clock used for the ping interval, the ping round trip time and the message rate limit
+//
+// needs to be invoked before InitDataProcessing
+func (w *WebsocketConnection) SetClock(clock api.ClockInterface) {
+	w.clock = clock
+}
+
+// set the maximum number of outgoing messages that can be queued per priority
+//
+// needs to be invoked before InitDataProcessing, values less than 1 are ignored
+func (w *WebsocketConnection) SetWriteQueueSize(size int) {
+	if size < 1 {
+		return
+	}
+
+	w.muxShipWrite.Lock()
+	defer w.muxShipWrite.Unlock()
+
+	w.writeQueueSize = size
+}
+
+// limit the number of incoming messages per second, bursts of up to burst messages are allowed
+//
+// the connection is closed if the limit is exceeded
+// needs to be invoked before InitDataProcessing, a rate less or equal to 0 disables the limit
+func (w *WebsocketConnection) SetMessageRateLimit(rate float64, burst int) {
+	if rate <= 0 {
+		w.readRateLimiter = nil
+		return
 	}
+
+	w.readRateLimiter = newRateLimiter(rate, burst)
 }
 
 // sets the error message for the closed connection
@@ -80,55 +176,116 @@ func (w *WebsocketConnection) isConnClosed() bool {
 }
 
 func (w *WebsocketConnection) run() {
-	w.shipWriteChannel = make(chan []byte, 1) // Send outgoing ship messages
-	w.closeChannel = make(chan struct{}, 1)   // Listen to close events
+	w.initWriteQueues()
 
 	go w.readShipPump()
 	go w.writeShipPump()
 }
 
-// writePump pumps messages from the SPINE and SHIP writeChannels to the websocket connection
+// create the channels for outgoing messages and close events
+func (w *WebsocketConnection) initWriteQueues() {
+	w.muxShipWrite.Lock()
+	defer w.muxShipWrite.Unlock()
+
+	w.controlWriteChannel = make(chan writeRequest, w.writeQueueSize) // Send outgoing SHIP control messages
+	w.dataWriteChannel = make(chan writeRequest, w.writeQueueSize)    // Send outgoing SPINE data messages
+	w.closeChannel = make(chan struct{}, 1)                           // Listen to close events
+}
+
+// writePump pumps messages from the SHIP and SPINE write channels to the websocket connection
 func (w *WebsocketConnection) writeShipPump() {
-	ticker := time.NewTicker(pingPeriod)
+	ticker := w.clock.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
-		close(w.shipWriteChannel)
+		w.failQueuedMessages()
 	}()
 
 	for {
+		// SHIP control messages always take precedence over SPINE data messages
 		select {
 		case <-w.closeChannel:
 			return
 
-		case message, ok := <-w.shipWriteChannel:
-			if w.isConnClosed() {
+		case request := <-w.controlWriteChannel:
+			if !w.writeShipMessage(request) {
 				return
 			}
+			continue
 
-			if !ok {
-				logging.Log().Debug(w.remoteSki, "ship write channel closed")
-				// The write channel has been closed
-				_ = w.writeMessage(websocket.CloseMessage, []byte{})
-				return
-			}
+		default:
+		}
 
-			w.muxConWrite.Lock()
-			_ = w.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			w.muxConWrite.Unlock()
+		select {
+		case <-w.closeChannel:
+			return
 
-			if !w.writeMessage(websocket.BinaryMessage, message) {
+		case request := <-w.controlWriteChannel:
+			if !w.writeShipMessage(request) {
 				return
 			}
 
-			text := w.textFromMessage(message)
-			logging.Log().Trace("Send:", w.remoteSki, text)
+		case request := <-w.dataWriteChannel:
+			if !w.writeShipMessage(request) {
+				return
+			}
 
-		case <-ticker.C:
+		case <-ticker.C():
 			w.handlePing()
 		}
 	}
 }
 
+// write a queued message to the websocket connection and report the result
+//
+// returns false if the connection is closed or the message could not be written
+func (w *WebsocketConnection) writeShipMessage(request writeRequest) bool {
+	if w.isConnClosed() {
+		request.reportResult(errors.New(connIsClosedError))
+		return false
+	}
+
+	w.muxConWrite.Lock()
+	_ = w.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	w.muxConWrite.Unlock()
+
+	messageType := request.messageType
+	if messageType == 0 {
+		messageType = websocket.BinaryMessage
+	}
+
+	if err := w.writeMessageWithoutErrorHandling(messageType, request.message); err != nil {
+		w.closeWithError(err, "error writing to websocket: ")
+		request.reportResult(err)
+		return false
+	}
+
+	request.reportResult(nil)
+	if messageType != websocket.BinaryMessage {
+		return true
+	}
+
+	w.countMessage(false, len(request.message))
+
+	text := w.textFromMessage(request.message)
+	logging.Log().Trace("Send:", w.remoteSki, text)
+
+	return true
+}
+
+// report all messages still queued as not sent, as the connection is closed
+func (w *WebsocketConnection) failQueuedMessages() {
+	for {
+		select {
+		case request := <-w.controlWriteChannel:
+			request.reportResult(errors.New(connIsClosedError))
+		case request := <-w.dataWriteChannel:
+			request.reportResult(errors.New(connIsClosedError))
+		default:
+			return
+		}
+	}
+}
+
 func (w *WebsocketConnection) handlePing() {
 	if w.isConnClosed() {
 		return
@@ -137,7 +294,47 @@ func (w *WebsocketConnection) handlePing() {
 	w.muxConWrite.Lock()
 	_ = w.conn.SetWriteDeadline(time.Now().Add(writeWait))
 	w.muxConWrite.Unlock()
-	_ = w.writeMessage(websocket.PingMessage, nil)
+
+	// set before sending, as the pong may be handled before the write returns
+	w.muxStats.Lock()
+	w.lastPingSent = w.clock.Now()
+	w.muxStats.Unlock()
+
+	if !w.writeMessage(websocket.PingMessage, nil) {
+		w.muxStats.Lock()
+		w.lastPingSent = time.Time{}
+		w.muxStats.Unlock()
+	}
+}
+
+// handle a pong answering the last ping
+func (w *WebsocketConnection) handlePong(string) error {
+	_ = w.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+	w.muxStats.Lock()
+	defer w.muxStats.Unlock()
+
+	if !w.lastPingSent.IsZero() {
+		w.lastPingRTT = w.clock.Now().Sub(w.lastPingSent)
+		w.lastPingSent = time.Time{}
+	}
+
+	return nil
+}
+
+// update the statistics of the transferred messages
+func (w *WebsocketConnection) countMessage(incoming bool, size int) {
+	w.muxStats.Lock()
+	defer w.muxStats.Unlock()
+
+	if incoming {
+		w.messagesIn++
+		w.bytesIn += uint64(size) // #nosec G115
+		return
+	}
+
+	w.messagesOut++
+	w.bytesOut += uint64(size) // #nosec G115
 }
 
 func (w *WebsocketConnection) closeWithError(err error, reason string) {
@@ -149,7 +346,7 @@ func (w *WebsocketConnection) closeWithError(err error, reason string) {
 // readShipPump checks for messages from the websocket connection
 func (w *WebsocketConnection) readShipPump() {
 	_ = w.conn.SetReadDeadline(time.Now().Add(pongWait))
-	w.conn.SetPongHandler(func(string) error { _ = w.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+	w.conn.SetPongHandler(w.handlePong)
 
 	for {
 		select {
@@ -169,12 +366,29 @@ func (w *WebsocketConnection) readShipPump() {
 
 			if err != nil {
 				logging.Log().Debug(w.remoteSki, "websocket read error: ", err)
+
+				var closeErr *websocket.CloseError
+				if errors.As(err, &closeErr) {
+					err = &api.WebsocketCloseError{Code: closeErr.Code, Text: closeErr.Text}
+				}
+
 				w.close()
 				w.setConnClosedError(err)
 				w.dataProcessing.ReportConnectionError(err)
 				return
 			}
 
+			if w.readRateLimiter != nil && !w.readRateLimiter.allow(w.clock.Now()) {
+				err := errors.New("incoming message rate limit exceeded")
+				logging.Log().Debug(w.remoteSki, err)
+				w.CloseDataConnection(websocket.ClosePolicyViolation, err.Error())
+				w.setConnClosedError(err)
+				w.dataProcessing.ReportConnectionError(err)
+				return
+			}
+
+			w.countMessage(true, len(message))
+
 			text := w.textFromMessage(message)
 			logging.Log().Trace("Recv:", w.remoteSki, text)
 
@@ -249,17 +463,158 @@ func (w *WebsocketConnection) InitDataProcessing(dataProcessing api.WebsocketDat
 	w.run()
 }
 
-// write a message to the websocket connection
-func (w *WebsocketConnection) WriteMessageToWebsocketConnection(message []byte) error {
+// return the write channel matching the priority of the message
+func (w *WebsocketConnection) writeQueueForMessage(message []byte) (chan writeRequest, error) {
 	w.muxShipWrite.Lock()
 	defer w.muxShipWrite.Unlock()
 
-	if w.isConnClosed() || w.shipWriteChannel == nil {
+	if w.isConnClosed() || w.controlWriteChannel == nil || w.dataWriteChannel == nil {
+		return nil, errors.New(connIsClosedError)
+	}
+
+	if len(message) > 0 && (message[0] == model.MsgTypeData || message[0] == model.MsgTypeEnd) {
+		return w.dataWriteChannel, nil
+	}
+
+	return w.controlWriteChannel, nil
+}
+
+// write a message to the websocket connection
+//
+// blocks until the message is queued or the connection is closed
+func (w *WebsocketConnection) WriteMessageToWebsocketConnection(message []byte) error {
+	return w.WriteMessageToWebsocketConnectionWithContext(context.Background(), message)
+}
+
+// write a message to the websocket connection
+//
+// the returned channel receives exactly one value: nil once the message was written
+// to the connection, or the error why it was not queued or written
+func (w *WebsocketConnection) WriteMessageToWebsocketConnectionWithResult(message []byte) <-chan error {
+	request := writeRequest{
+		message: message,
+		result:  make(chan error, 1),
+	}
+
+	if err := w.queueMessage(request); err != nil {
+		request.reportResult(err)
+	}
+
+	return request.result
+}
+
+// add a message to the write queue without blocking
+func (w *WebsocketConnection) queueMessage(request writeRequest) error {
+	queue, err := w.writeQueueForMessage(request.message)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case queue <- request:
+		w.updateWriteQueueStats(false)
+		w.checkQueuedAfterClose()
+		return nil
+	default:
+		w.updateWriteQueueStats(true)
+		logging.Log().Debug(w.remoteSki, "websocket write queue is full, rejecting message")
+		return api.ErrWebsocketWriteQueueFull
+	}
+}
+
+// a message could have been queued after the write pump stopped,
+// make sure it is not left without a result
+func (w *WebsocketConnection) checkQueuedAfterClose() {
+	if w.isConnClosed() {
+		w.failQueuedMessages()
+	}
+}
+
+// write a message to the websocket connection
+//
+// blocks until the message is queued, the context is done or the connection is closed
+func (w *WebsocketConnection) WriteMessageToWebsocketConnectionWithContext(ctx context.Context, message []byte) error {
+	queue, err := w.writeQueueForMessage(message)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case queue <- writeRequest{message: message}:
+		w.updateWriteQueueStats(false)
+		w.checkQueuedAfterClose()
+		return nil
+	case <-w.closeChannel:
 		return errors.New(connIsClosedError)
+	case <-ctx.Done():
+		w.updateWriteQueueStats(true)
+		return ctx.Err()
 	}
+}
 
-	w.shipWriteChannel <- message
-	return nil
+// update the queue statistics after a message was queued or rejected
+func (w *WebsocketConnection) updateWriteQueueStats(rejected bool) {
+	w.muxQueueStats.Lock()
+	defer w.muxQueueStats.Unlock()
+
+	if rejected {
+		w.writeQueueRejected++
+		return
+	}
+
+	queued := len(w.controlWriteChannel) + len(w.dataWriteChannel)
+	if queued > w.writeQueueMaxQueued {
+		w.writeQueueMaxQueued = queued
+	}
+}
+
+// return the current statistics of the outgoing message queue
+func (w *WebsocketConnection) WriteQueueStats() api.WebsocketWriteQueueStats {
+	w.muxShipWrite.Lock()
+	stats := api.WebsocketWriteQueueStats{
+		Capacity:      w.writeQueueSize,
+		ControlQueued: len(w.controlWriteChannel),
+		DataQueued:    len(w.dataWriteChannel),
+	}
+	w.muxShipWrite.Unlock()
+
+	w.muxQueueStats.Lock()
+	defer w.muxQueueStats.Unlock()
+
+	stats.MaxQueued = w.writeQueueMaxQueued
+	stats.Rejected = w.writeQueueRejected
+
+	return stats
+}
+
+// return the details and statistics of the connection
+func (w *WebsocketConnection) ConnectionInfo() api.WebsocketConnectionInfo {
+	var info api.WebsocketConnectionInfo
+
+	if w.conn != nil {
+		info.RemoteAddress = w.conn.RemoteAddr().String()
+		info.LocalAddress = w.conn.LocalAddr().String()
+
+		if tlsConn, ok := w.conn.NetConn().(*tls.Conn); ok {
+			state := tlsConn.ConnectionState()
+			info.TLSVersion = state.Version
+			info.CipherSuite = state.CipherSuite
+			if len(state.PeerCertificates) > 0 {
+				info.PeerCertificate = state.PeerCertificates[0]
+			}
+		}
+	}
+
+	w.muxStats.Lock()
+	defer w.muxStats.Unlock()
+
+	info.BytesIn = w.bytesIn
+	info.BytesOut = w.bytesOut
+	info.MessagesIn = w.messagesIn
+	info.MessagesOut = w.messagesOut
+	info.LastPingRTT = w.lastPingRTT
+
+	return info
 }
 
 // make sure websocket Write is only called once at a time
@@ -295,12 +650,61 @@ func (w *WebsocketConnection) writeMessageWithoutErrorHandling(messageType int,
 func (w *WebsocketConnection) CloseDataConnection(closeCode int, reason string) {
 	// send a close message to the remote side if we have a reason
 	if reason != "" {
-		_ = w.writeMessageWithoutErrorHandling(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, reason))
+		closeMessage := websocket.FormatCloseMessage(closeCode, truncateCloseReason(reason))
+		if !w.queueCloseMessage(closeMessage) {
+			_ = w.writeMessageWithoutErrorHandling(websocket.CloseMessage, closeMessage)
+		}
 	}
 
 	w.close()
 }
 
+// shorten a close reason to fit into a close frame, without splitting a character
+func truncateCloseReason(reason string) string {
+	if len(reason) <= maxCloseReasonLength {
+		return reason
+	}
+
+	cut := maxCloseReasonLength
+	for cut > 0 && !utf8.RuneStart(reason[cut]) {
+		cut--
+	}
+
+	return reason[:cut]
+}
+
+// send the close message after the queued SPINE data and SHIP end messages
+//
+// returns false if the close message could not be queued, e.g. as the queue is full
+func (w *WebsocketConnection) queueCloseMessage(closeMessage []byte) bool {
+	w.muxShipWrite.Lock()
+	queue := w.dataWriteChannel
+	w.muxShipWrite.Unlock()
+
+	if queue == nil || w.isConnClosed() {
+		return false
+	}
+
+	request := writeRequest{
+		message:     closeMessage,
+		messageType: websocket.CloseMessage,
+		result:      make(chan error, 1),
+	}
+
+	select {
+	case queue <- request:
+	default:
+		return false
+	}
+
+	w.checkQueuedAfterClose()
+
+	// each queued message is written with the write deadline or failed once the connection is closed
+	<-request.result
+
+	return true
+}
+
 // return if the connection is closed
 func (w *WebsocketConnection) IsDataConnectionClosed() (bool, error) {
 	isClosed := w.isConnClosed()
@@ -1,16 +1,21 @@
 package ws
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"github.com/enbility/ship-go/api"
+	"github.com/enbility/ship-go/clock/clocktest"
 	"github.com/enbility/ship-go/mocks"
-	util "github.com/enbility/ship-go/util"
+	"github.com/enbility/ship-go/model"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -129,21 +134,68 @@ func (s *WebsocketSuite) TestConnectionClose() {
 }
 
 func (s *WebsocketSuite) TestPingPeriod() {
-	isClosed, err := s.sut.IsDataConnectionClosed()
+	clock := clocktest.NewFakeClock(time.Now())
+
+	// body close is done at the end of the test
+	//nolint:bodyclose
+	testServer, testResponse, testWsConn := newWSServer(s.T(), &testServer{clock: clock})
+	defer func() {
+		testResponse.Body.Close()
+		testServer.Close()
+	}()
+
+	sut := NewWebsocketConnection(testWsConn, "remoteSki")
+	sut.SetClock(clock)
+	sut.InitDataProcessing(s.wsDataReader)
+
+	// the ping ticker is created by the write pump
+	clock.BlockUntil(1)
+
+	// no ping is sent before the ping period elapsed
+	clock.Advance(pingPeriod - time.Second)
+	assert.Equal(s.T(), time.Duration(0), sut.ConnectionInfo().LastPingRTT)
+
+	// test if the ping is triggered correctly via the ticker,
+	// the test server advances the clock before answering the ping
+	clock.Advance(time.Second)
+	assert.Eventually(s.T(), func() bool {
+		return sut.ConnectionInfo().LastPingRTT == testPongDelay
+	}, time.Second, time.Millisecond*10)
+
+	isClosed, err := sut.IsDataConnectionClosed()
 	assert.Equal(s.T(), false, isClosed)
 	assert.Nil(s.T(), err)
 
-	if !util.IsRunningOnCI() {
-		// test if the function is triggered correctly via the timer
-		time.Sleep(time.Second * 51)
-	} else {
-		// speed up the test by running the method directly
-		s.sut.handlePing()
-	}
+	sut.CloseDataConnection(4001, "")
+}
 
-	isClosed, err = s.sut.IsDataConnectionClosed()
-	assert.Equal(s.T(), false, isClosed)
+func (s *WebsocketSuite) TestConnectionInfo() {
+	info := s.sut.ConnectionInfo()
+	assert.Equal(s.T(), s.testWsConn.RemoteAddr().String(), info.RemoteAddress)
+	assert.Equal(s.T(), uint64(0), info.MessagesOut)
+	assert.Equal(s.T(), time.Duration(0), info.LastPingRTT)
+	// the test server does not use TLS
+	assert.Equal(s.T(), uint16(0), info.TLSVersion)
+	assert.Nil(s.T(), info.PeerCertificate)
+
+	msg := []byte{1}
+	msg = append(msg, []byte("message")...)
+	err := s.sut.WriteMessageToWebsocketConnection(msg)
 	assert.Nil(s.T(), err)
+
+	s.sut.handlePing()
+
+	// the test server echos the message and answers the ping
+	assert.Eventually(s.T(), func() bool {
+		info = s.sut.ConnectionInfo()
+		return info.MessagesIn == 1 && info.LastPingRTT > 0
+	}, time.Second, time.Millisecond*10)
+
+	assert.Equal(s.T(), uint64(1), info.MessagesOut)
+	assert.Equal(s.T(), uint64(len(msg)), info.BytesOut)
+	assert.Equal(s.T(), uint64(len(msg)), info.BytesIn)
+
+	s.sut.CloseDataConnection(4001, "")
 }
 
 func (s *WebsocketSuite) TestCloseWithError() {
@@ -159,6 +211,238 @@ func (s *WebsocketSuite) TestCloseWithError() {
 	assert.NotNil(s.T(), err)
 }
 
+func (s *WebsocketSuite) TestWriteQueueFull() {
+	sut := NewWebsocketConnection(s.testWsConn, "remoteSki")
+	sut.SetWriteQueueSize(0)
+	assert.Equal(s.T(), DefaultWriteQueueSize, sut.writeQueueSize)
+
+	sut.SetWriteQueueSize(1)
+	// only create the queues, so nothing is sent
+	sut.initWriteQueues()
+
+	dataMsg := []byte{model.MsgTypeData, 0}
+	controlMsg := []byte{model.MsgTypeControl, 0}
+
+	err := sut.WriteMessageToWebsocketConnection(dataMsg)
+	assert.Nil(s.T(), err)
+
+	err = <-sut.WriteMessageToWebsocketConnectionWithResult(dataMsg)
+	assert.ErrorIs(s.T(), err, api.ErrWebsocketWriteQueueFull)
+
+	// control messages use their own queue
+	err = sut.WriteMessageToWebsocketConnection(controlMsg)
+	assert.Nil(s.T(), err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	err = sut.WriteMessageToWebsocketConnectionWithContext(ctx, controlMsg)
+	assert.ErrorIs(s.T(), err, context.DeadlineExceeded)
+
+	stats := sut.WriteQueueStats()
+	assert.Equal(s.T(), 1, stats.Capacity)
+	assert.Equal(s.T(), 1, stats.ControlQueued)
+	assert.Equal(s.T(), 1, stats.DataQueued)
+	assert.Equal(s.T(), 2, stats.MaxQueued)
+	assert.Equal(s.T(), uint64(2), stats.Rejected)
+
+	sut.close()
+
+	err = sut.WriteMessageToWebsocketConnectionWithContext(context.Background(), controlMsg)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *WebsocketSuite) TestWriteBlocksOnFullQueue() {
+	sut := NewWebsocketConnection(s.testWsConn, "remoteSki")
+	sut.SetWriteQueueSize(1)
+	// only create the queues, so nothing is sent
+	sut.initWriteQueues()
+
+	dataMsg := []byte{model.MsgTypeData, 0}
+	assert.Nil(s.T(), sut.WriteMessageToWebsocketConnection(dataMsg))
+
+	written := make(chan error, 1)
+	go func() {
+		written <- sut.WriteMessageToWebsocketConnection(dataMsg)
+	}()
+
+	select {
+	case <-written:
+		s.T().Fatal("message was not blocked by the full queue")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	<-sut.dataWriteChannel
+	assert.Nil(s.T(), <-written)
+
+	// blocked writes return once the connection got closed
+	go func() {
+		written <- sut.WriteMessageToWebsocketConnection(dataMsg)
+	}()
+	sut.close()
+	assert.NotNil(s.T(), <-written)
+}
+
+func (s *WebsocketSuite) TestCloseAfterQueuedData() {
+	sut := NewWebsocketConnection(s.testWsConn, "remoteSki")
+	sut.SetWriteQueueSize(3)
+	// only create the queues, so nothing is sent
+	sut.initWriteQueues()
+
+	dataMsg := []byte{model.MsgTypeData, 0}
+	endMsg := []byte{model.MsgTypeEnd, 0}
+	assert.Nil(s.T(), sut.WriteMessageToWebsocketConnection(dataMsg))
+	assert.Nil(s.T(), sut.WriteMessageToWebsocketConnection(endMsg))
+
+	closed := make(chan struct{})
+	go func() {
+		sut.CloseDataConnection(4001, "close")
+		close(closed)
+	}()
+
+	// the end message and the close message are sent after the queued data
+	request := <-sut.dataWriteChannel
+	assert.Equal(s.T(), dataMsg, request.message)
+	request = <-sut.dataWriteChannel
+	assert.Equal(s.T(), endMsg, request.message)
+	request = <-sut.dataWriteChannel
+	assert.Equal(s.T(), websocket.CloseMessage, request.messageType)
+
+	request.reportResult(nil)
+	<-closed
+	assert.True(s.T(), sut.isConnClosed())
+}
+
+func (s *WebsocketSuite) TestCloseReasonTruncated() {
+	sut := NewWebsocketConnection(s.testWsConn, "remoteSki")
+	// only create the queues, so nothing is sent
+	sut.initWriteQueues()
+
+	// a reason exceeding the close frame limit, with characters of multiple bytes
+	reason := strings.Repeat("ä", maxCloseReasonLength)
+
+	closed := make(chan struct{})
+	go func() {
+		sut.CloseDataConnection(4001, reason)
+		close(closed)
+	}()
+
+	request := <-sut.dataWriteChannel
+	assert.Equal(s.T(), websocket.CloseMessage, request.messageType)
+
+	// the close code is followed by the reason
+	frameReason := string(request.message[2:])
+	assert.LessOrEqual(s.T(), len(frameReason), maxCloseReasonLength)
+	assert.True(s.T(), utf8.ValidString(frameReason))
+	assert.True(s.T(), strings.HasPrefix(reason, frameReason))
+
+	request.reportResult(nil)
+	<-closed
+
+	assert.Equal(s.T(), "close", truncateCloseReason("close"))
+}
+
+func (s *WebsocketSuite) TestWriteWithResult() {
+	msg := []byte{model.MsgTypeData, 0}
+
+	result := s.sut.WriteMessageToWebsocketConnectionWithResult(msg)
+	select {
+	case err := <-result:
+		assert.Nil(s.T(), err)
+	case <-time.After(time.Second):
+		s.T().Fatal("no write result received")
+	}
+
+	sut := NewWebsocketConnection(s.testWsConn, "remoteSki")
+	sut.SetWriteQueueSize(1)
+	// only create the queues, so nothing is sent
+	sut.initWriteQueues()
+
+	queued := sut.WriteMessageToWebsocketConnectionWithResult(msg)
+	result = sut.WriteMessageToWebsocketConnectionWithResult(msg)
+	assert.ErrorIs(s.T(), <-result, api.ErrWebsocketWriteQueueFull)
+
+	// queued messages fail once the connection got closed
+	sut.close()
+	sut.failQueuedMessages()
+	assert.NotNil(s.T(), <-queued)
+
+	result = sut.WriteMessageToWebsocketConnectionWithResult(msg)
+	assert.NotNil(s.T(), <-result)
+}
+
+func (s *WebsocketSuite) TestMessageRateLimit() {
+	s.sut.SetMessageRateLimit(0, 0)
+	assert.Nil(s.T(), s.sut.readRateLimiter)
+
+	// body close is done at the end of the test
+	//nolint:bodyclose
+	testServer, testResponse, testWsConn := newWSServer(s.T(), &testServer{})
+	defer func() {
+		testResponse.Body.Close()
+		testServer.Close()
+	}()
+
+	sut := NewWebsocketConnection(testWsConn, "remoteSki")
+	sut.SetMessageRateLimit(1, 1)
+	assert.NotNil(s.T(), sut.readRateLimiter)
+	sut.InitDataProcessing(s.wsDataReader)
+
+	// the echo server returns the messages, the second one exceeds the limit
+	msg := []byte{model.MsgTypeControl, 0}
+	assert.Nil(s.T(), sut.WriteMessageToWebsocketConnection(msg))
+	assert.Nil(s.T(), sut.WriteMessageToWebsocketConnection(msg))
+
+	// make sure we have enough time to read and write
+	time.Sleep(time.Millisecond * 500)
+
+	isClosed, err := sut.IsDataConnectionClosed()
+	assert.True(s.T(), isClosed)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *WebsocketSuite) TestWriteQueuePriority() {
+	var mux sync.Mutex
+	var received []byte
+
+	wsDataReader := mocks.NewWebsocketDataReaderInterface(s.T())
+	wsDataReader.EXPECT().ReportConnectionError(mock.Anything).Return().Maybe()
+	wsDataReader.EXPECT().HandleIncomingWebsocketMessage(mock.Anything).Run(func(message []byte) {
+		mux.Lock()
+		defer mux.Unlock()
+
+		received = append(received, message[0])
+	}).Return().Maybe()
+
+	// body close is done at the end of the test
+	//nolint:bodyclose
+	testServer, testResponse, testWsConn := newWSServer(s.T(), &testServer{})
+	defer func() {
+		testResponse.Body.Close()
+		testServer.Close()
+	}()
+
+	sut := NewWebsocketConnection(testWsConn, "remoteSki")
+	sut.dataProcessing = wsDataReader
+	sut.initWriteQueues()
+
+	err := sut.WriteMessageToWebsocketConnection([]byte{model.MsgTypeData, 0})
+	assert.Nil(s.T(), err)
+	err = sut.WriteMessageToWebsocketConnectionWithContext(context.Background(), []byte{model.MsgTypeControl, 0})
+	assert.Nil(s.T(), err)
+
+	go sut.readShipPump()
+	go sut.writeShipPump()
+
+	// make sure we have enough time to read and write
+	time.Sleep(time.Millisecond * 500)
+
+	mux.Lock()
+	assert.Equal(s.T(), []byte{model.MsgTypeControl, model.MsgTypeData}, received)
+	mux.Unlock()
+
+	sut.CloseDataConnection(4001, "")
+}
+
 var upgrader = websocket.Upgrader{}
 
 func newWSServer(t *testing.T, h http.Handler) (*httptest.Server, *http.Response, *websocket.Conn) {
@@ -176,7 +460,12 @@ func newWSServer(t *testing.T, h http.Handler) (*httptest.Server, *http.Response
 	return s, resp, ws
 }
 
+// the time the test server advances the clock before answering a ping
+const testPongDelay = 5 * time.Millisecond
+
 type testServer struct {
+	// advanced before answering a ping if set
+	clock *clocktest.FakeClock
 }
 
 func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -188,6 +477,13 @@ func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer ws.Close()
 
+	if s.clock != nil {
+		ws.SetPingHandler(func(appData string) error {
+			s.clock.Advance(testPongDelay)
+			return ws.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(time.Second))
+		})
+	}
+
 	for {
 		_, msg, err := ws.ReadMessage()
 		if err != nil {